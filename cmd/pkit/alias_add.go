@@ -7,31 +7,44 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/whisller/pkit/internal/alias"
+	"github.com/whisller/pkit/internal/bookmark"
+	"github.com/whisller/pkit/internal/compose"
 	"github.com/whisller/pkit/internal/config"
 	"github.com/whisller/pkit/internal/index"
 	"github.com/whisller/pkit/pkg/models"
 )
 
 var aliasAddCmd = &cobra.Command{
-	Use:   "add <prompt-id> <alias-name>",
+	Use:   "add <prompt-id>... <alias-name>",
 	Short: "Create an alias for a prompt",
 	Long: `Create a short alias name for a prompt ID.
 
+When several prompt IDs (or other aliases) are given, the alias points at a
+composition: 'pkit get <alias>' stacks them into one document in that order.
+
 Examples:
   pkit alias add fabric:code-review review
   pkit alias add awesome:linux-terminal term
-  pkit alias add fabric:dialog_with_socrates socrates`,
-	Args: cobra.ExactArgs(2),
+  pkit alias add fabric:dialog_with_socrates socrates
+
+  # Composition: house style preamble followed by a review prompt
+  pkit alias add local:house-style fabric:code-review styled-review
+  pkit alias add intro review outro full-review --separator '\n\n---\n\n'`,
+	Args: cobra.MinimumNArgs(2),
 	RunE: runAliasAdd,
 }
 
+var aliasAddSeparator string
+
 func init() {
 	aliasCmd.AddCommand(aliasAddCmd)
+
+	aliasAddCmd.Flags().StringVar(&aliasAddSeparator, "separator", "", "Separator between composed prompts (supports \\n and \\t)")
 }
 
 func runAliasAdd(cmd *cobra.Command, args []string) (err error) {
-	promptID := args[0]
-	aliasName := args[1]
+	targets := args[:len(args)-1]
+	aliasName := args[len(args)-1]
 
 	// Validate alias name format
 	if err := alias.ValidateAliasName(aliasName); err != nil {
@@ -60,25 +73,38 @@ func runAliasAdd(cmd *cobra.Command, args []string) (err error) {
 		}
 	}()
 
-	_, err = indexer.GetPromptByID(promptID)
-	if err != nil {
-		return fmt.Errorf("prompt not found: %w", err)
+	// Composition parts may be prompt IDs or existing aliases
+	manager := alias.NewManager()
+	for _, target := range targets {
+		if _, aliasErr := manager.GetAlias(target); aliasErr == nil && len(targets) > 1 {
+			continue
+		}
+
+		_, err = indexer.GetPromptByID(target)
+		if err != nil {
+			return fmt.Errorf("prompt not found: %w", err)
+		}
 	}
 
 	// Create alias
 	a := models.Alias{
-		Name:     aliasName,
-		PromptID: promptID,
+		Name: aliasName,
+	}
+	if len(targets) == 1 {
+		a.PromptID = targets[0]
+	} else {
+		a.Compose = targets
+		a.Separator = compose.UnescapeDelimiter(aliasAddSeparator)
 	}
 
 	// Add alias using manager
-	manager := alias.NewManager()
 	if err := manager.AddAlias(a); err != nil {
 		return fmt.Errorf("failed to create alias: %w", err)
 	}
+	syncIndexedMetadata(indexer, bookmark.AliasPromptIDs(a)...)
 
 	// Output to stdout - error extremely rare (stdout closed/redirected)
-	_, _ = fmt.Fprintf(os.Stdout, "Created alias '%s' for prompt '%s'\n", aliasName, promptID)
+	_, _ = fmt.Fprintf(os.Stdout, "Created alias '%s' for prompt '%s'\n", aliasName, a.Target())
 
 	return nil
 }
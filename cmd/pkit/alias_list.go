@@ -49,7 +49,7 @@ func runAliasList(cmd *cobra.Command, args []string) error {
 	for _, a := range aliases {
 		_ = table.Append(
 			a.Name,
-			a.PromptID,
+			a.Target(),
 		)
 	}
 
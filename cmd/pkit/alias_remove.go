@@ -6,6 +6,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/whisller/pkit/internal/alias"
+	"github.com/whisller/pkit/internal/bookmark"
 )
 
 var aliasRemoveCmd = &cobra.Command{
@@ -27,13 +28,20 @@ func init() {
 func runAliasRemove(cmd *cobra.Command, args []string) error {
 	aliasName := args[0]
 
-	// Remove alias using manager
+	// Remember what the alias pointed at to update the index afterwards
 	manager := alias.NewManager()
+	var promptIDs []string
+	if a, err := manager.GetAlias(aliasName); err == nil {
+		promptIDs = bookmark.AliasPromptIDs(*a)
+	}
+
+	// Remove alias using manager
 	err := manager.RemoveAlias(aliasName)
 
 	if err != nil {
 		return fmt.Errorf("failed to remove alias: %w", err)
 	}
+	syncIndexedMetadata(nil, promptIDs...)
 
 	_, _ = fmt.Fprintf(os.Stdout, "Removed alias '%s'\n", aliasName)
 
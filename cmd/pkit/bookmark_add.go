@@ -73,6 +73,7 @@ func runBookmarkAdd(cmd *cobra.Command, args []string) (err error) {
 	if err := manager.AddBookmark(bm); err != nil {
 		return fmt.Errorf("failed to add bookmark: %w", err)
 	}
+	syncIndexedMetadata(indexer, promptID)
 
 	_, _ = fmt.Fprintf(os.Stdout, "Bookmarked prompt '%s'\n", prompt.Name)
 
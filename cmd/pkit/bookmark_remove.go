@@ -34,6 +34,7 @@ func runBookmarkRemove(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to remove bookmark: %w", err)
 	}
+	syncIndexedMetadata(nil, promptID)
 
 	// Output to stdout - error extremely rare (stdout closed/redirected)
 	_, _ = fmt.Fprintf(os.Stdout, "Removed bookmark for prompt '%s'\n", promptID)
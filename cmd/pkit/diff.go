@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"github.com/whisller/pkit/internal/bookmark"
+	"github.com/whisller/pkit/internal/config"
+	"github.com/whisller/pkit/internal/diff"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <a> <b>",
+	Short: "Show differences between two prompts or two versions of a prompt",
+	Long: `Diff compares the resolved contents of two prompts.
+
+Each side is an alias, a prompt ID, or either of them followed by @<revision> to
+use the prompt as of a git revision of its source (commit, branch, tag, HEAD~N).
+Without a revision, include directives are expanded; with one, the file is shown
+as it was at that revision.
+
+Output is a unified diff, or a word-level diff with --words. Colour is used when
+writing to a terminal (see --color).
+
+Examples:
+  pkit diff review fabric:review_code             # Two prompts
+  pkit diff fabric:summarize@HEAD~5 fabric:summarize   # What an upstream edit did
+  pkit diff sum@v1.2 sum --words                  # Word-level changes
+  pkit diff a b -U 10                             # More context lines
+  pkit diff a b --exit-code && echo identical`,
+	Args: cobra.ExactArgs(2),
+	RunE: runDiff,
+}
+
+var (
+	diffWords    bool
+	diffContext  int
+	diffColor    string
+	diffExitCode bool
+)
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().BoolVarP(&diffWords, "words", "w", false, "Show a word-level diff")
+	diffCmd.Flags().IntVarP(&diffContext, "context", "U", 3, "Number of context lines in unified diffs")
+	diffCmd.Flags().StringVar(&diffColor, "color", "auto", "Colour output (auto, always, never)")
+	diffCmd.Flags().BoolVar(&diffExitCode, "exit-code", false, "Exit with status 1 when the prompts differ")
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	useColor, err := diffUseColor()
+	if err != nil {
+		return err
+	}
+
+	a, err := bookmark.ResolveAtWithContext(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to resolve '%s': %w", args[0], err)
+	}
+	b, err := bookmark.ResolveAtWithContext(args[1])
+	if err != nil {
+		return fmt.Errorf("failed to resolve '%s': %w", args[1], err)
+	}
+
+	var edits []diff.Edit
+	if diffWords {
+		edits = diff.Words(a.Content, b.Content)
+	} else {
+		edits = diff.Lines(a.Content, b.Content)
+	}
+
+	if !diff.Changed(edits) {
+		fmt.Fprintln(os.Stderr, "No differences")
+		return nil
+	}
+
+	if diffWords {
+		err = diff.WriteWords(os.Stdout, edits, useColor)
+	} else {
+		err = diff.WriteUnified(os.Stdout, args[0], args[1], edits, diffContext, useColor)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write diff: %w", err)
+	}
+
+	if diffExitCode {
+		return &exitCodeError{code: 1}
+	}
+	return nil
+}
+
+// diffUseColor decides whether to colour the diff from --color, NO_COLOR,
+// the display.color setting and whether stdout is a terminal.
+func diffUseColor() (bool, error) {
+	switch diffColor {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" || !isatty.IsTerminal(os.Stdout.Fd()) {
+			return false, nil
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return true, nil
+		}
+		return cfg.Display.Color, nil
+	default:
+		return false, fmt.Errorf("invalid --color '%s' (use auto, always or never)", diffColor)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/whisller/pkit/internal/config"
+	"github.com/whisller/pkit/internal/index"
+	"github.com/whisller/pkit/internal/similarity"
+)
+
+var dupesCmd = &cobra.Command{
+	Use:   "dupes [prompt-id]",
+	Short: "List near-duplicate prompts across sources",
+	Long: `Dupes lists clusters of near-identical prompts, e.g. the same "code reviewer"
+prompt published with small wording changes by several sources.
+
+Prompts are compared with MinHash fingerprints computed at index time, so run
+'pkit reindex' once after upgrading pkit. Similarity is an estimate of the share
+of three-word phrases two prompts have in common.
+
+With a prompt ID, only the near-duplicates of that prompt are listed.
+
+Examples:
+  pkit dupes                          # All clusters
+  pkit dupes --threshold 0.6          # Looser matching
+  pkit dupes fabric:review_code       # Near-duplicates of one prompt
+  pkit dupes --format json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDupes,
+}
+
+var (
+	dupesThreshold float64
+	dupesFormat    string
+)
+
+func init() {
+	rootCmd.AddCommand(dupesCmd)
+
+	dupesCmd.Flags().Float64Var(&dupesThreshold, "threshold", similarity.DefaultThreshold, "Minimum estimated similarity (0-1)")
+	dupesCmd.Flags().StringVar(&dupesFormat, "format", "table", "Output format (table, json)")
+}
+
+func runDupes(cmd *cobra.Command, args []string) (err error) {
+	if dupesThreshold <= 0 || dupesThreshold > 1 {
+		return fmt.Errorf("threshold must be between 0 and 1")
+	}
+	if dupesFormat != "table" && dupesFormat != "json" {
+		return fmt.Errorf("unknown format: %s (supported: table, json)", dupesFormat)
+	}
+
+	indexBasePath, err := config.GetIndexPath()
+	if err != nil {
+		return fmt.Errorf("failed to get index path: %w", err)
+	}
+
+	indexPath := filepath.Join(indexBasePath, "prompts.bleve")
+	indexer, err := index.NewReader(indexPath)
+	if err != nil {
+		return fmt.Errorf("failed to open index: %w", err)
+	}
+	defer func() {
+		if closeErr := indexer.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close index: %w", closeErr)
+		}
+	}()
+
+	if len(args) == 1 {
+		return runDupesOf(indexer, args[0])
+	}
+
+	clusters, err := indexer.DuplicateClusters(dupesThreshold)
+	if err != nil {
+		return fmt.Errorf("failed to find duplicates: %w", err)
+	}
+
+	if dupesFormat == "json" {
+		type jsonPrompt struct {
+			ID          string `json:"id"`
+			SourceID    string `json:"source_id"`
+			Description string `json:"description"`
+		}
+		type jsonCluster struct {
+			Similarity float64      `json:"similarity"`
+			Prompts    []jsonPrompt `json:"prompts"`
+		}
+
+		output := struct {
+			Clusters []jsonCluster `json:"clusters"`
+		}{Clusters: []jsonCluster{}}
+
+		for _, c := range clusters {
+			jc := jsonCluster{Similarity: c.Similarity}
+			for _, p := range c.Prompts {
+				jc.Prompts = append(jc.Prompts, jsonPrompt{ID: p.ID, SourceID: p.SourceID, Description: p.Description})
+			}
+			output.Clusters = append(output.Clusters, jc)
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(output); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	}
+
+	if len(clusters) == 0 {
+		fmt.Fprintln(os.Stderr, "No near-duplicate prompts found")
+		return nil
+	}
+
+	for n, c := range clusters {
+		if n > 0 {
+			_, _ = fmt.Fprintln(os.Stdout)
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "Cluster %d: %d prompts, %.0f%%+ similar\n", n+1, len(c.Prompts), c.Similarity*100)
+		for _, p := range c.Prompts {
+			_, _ = fmt.Fprintf(os.Stdout, "  %-40s %s\n", p.ID, truncateText(p.Description, 70))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "\nFound %d cluster(s)\n", len(clusters))
+	return nil
+}
+
+// runDupesOf lists the near-duplicates of a single prompt.
+func runDupesOf(indexer *index.Indexer, promptID string) error {
+	results, err := indexer.DuplicatesOf(promptID, dupesThreshold)
+	if err != nil {
+		return fmt.Errorf("failed to find duplicates: %w", err)
+	}
+
+	if dupesFormat == "json" {
+		type jsonPrompt struct {
+			ID          string  `json:"id"`
+			SourceID    string  `json:"source_id"`
+			Description string  `json:"description"`
+			Similarity  float64 `json:"similarity"`
+		}
+
+		output := struct {
+			PromptID   string       `json:"prompt_id"`
+			Duplicates []jsonPrompt `json:"duplicates"`
+		}{PromptID: promptID, Duplicates: []jsonPrompt{}}
+
+		for _, r := range results {
+			output.Duplicates = append(output.Duplicates, jsonPrompt{
+				ID:          r.Prompt.ID,
+				SourceID:    r.Prompt.SourceID,
+				Description: r.Prompt.Description,
+				Similarity:  r.Score,
+			})
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(output); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintf(os.Stderr, "No near-duplicates of %s found\n", promptID)
+		return nil
+	}
+
+	for _, r := range results {
+		_, _ = fmt.Fprintf(os.Stdout, "%3.0f%%  %-40s %s\n", r.Score*100, r.Prompt.ID, truncateText(r.Prompt.Description, 60))
+	}
+	return nil
+}
+
+// truncateText shortens s to at most max runes, adding "..." when cut.
+func truncateText(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-3]) + "..."
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
@@ -21,28 +22,37 @@ var findCmd = &cobra.Command{
 	Long: `Find launches an interactive TUI for browsing and selecting prompts.
 
 The find command provides:
-- Real-time fuzzy search filtering
+- Real-time search filtering (same query syntax as 'pkit search')
 - Interactive prompt selection with arrow keys
 - Keyboard shortcuts for common actions:
   - Enter: Select prompt (outputs ID)
   - Ctrl+G: Get prompt content
   - Ctrl+S: Bookmark prompt
   - Ctrl+T: Add tags to prompt
+  - P: Preview prompt, with similar prompts from the index
+  - S: Change sort order (score, usage, recent, updated, name, frecency)
   - Q/Esc: Quit
 
+Prompts are listed by frecency unless --sort or search.sort in config.yml says
+otherwise, so the prompts you use most often and most recently come first.
+
 When not running in a TTY (e.g., piped), falls back to search command behavior.
 
 Examples:
   pkit find                         # Launch interactive finder
   pkit find code                    # Pre-filter by "code"
-  pkit find --get | claude          # Interactive select + auto-get`,
+  pkit find --get | claude          # Interactive select + auto-get
+  pkit find --collapse              # Hide near-duplicate prompts
+  pkit find --sort name             # List prompts alphabetically`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runFind,
 }
 
 var (
-	findGet     bool
-	findVerbose bool
+	findGet      bool
+	findVerbose  bool
+	findCollapse bool
+	findSort     string
 )
 
 func init() {
@@ -50,8 +60,13 @@ func init() {
 
 	findCmd.Flags().BoolVarP(&findGet, "get", "g", false, "Automatically get the selected prompt content")
 	findCmd.Flags().BoolVarP(&findVerbose, "verbose", "v", false, "Show detailed progress")
+	findCmd.Flags().BoolVar(&findCollapse, "collapse", false, "Collapse near-duplicate prompts into the best-ranked one")
+	findCmd.Flags().StringVar(&findSort, "sort", "", "List order: "+strings.Join(index.SortOrders, ", ")+" (default frecency)")
 }
 
+// findSimilar is how many similar prompts the preview lists.
+const findSimilar = 5
+
 func runFind(cmd *cobra.Command, args []string) (err error) {
 	// Check if stdout is a TTY
 	isTTY := isatty.IsTerminal(os.Stdout.Fd())
@@ -82,6 +97,11 @@ Then use find:
   pkit find`)
 	}
 
+	sortOrder, err := resolveSort(findSort, cfg, index.SortFrecency)
+	if err != nil {
+		return err
+	}
+
 	// Get index path
 	indexBasePath, err := config.GetIndexPath()
 	if err != nil {
@@ -100,6 +120,9 @@ Then use find:
 		}
 	}()
 
+	// Frecency needs the usage of bookmarks used while the index was read-only
+	_ = bookmark.SyncUserMetadata(indexer)
+
 	// Search for prompts
 	query := ""
 	if len(args) > 0 {
@@ -107,13 +130,16 @@ Then use find:
 	}
 
 	searchOpts := index.SearchOptions{
-		Query:      query,
-		MaxResults: 1000, // Get all prompts for interactive filtering
+		Query:         query,
+		MaxResults:    0, // Get all prompts for interactive filtering
+		Fuzzy:         cfg.Search.FuzzyMatch,
+		CaseSensitive: cfg.Search.CaseSensitive,
+		Collapse:      findCollapse,
 	}
 
 	results, err := indexer.Search(searchOpts)
 	if err != nil {
-		return fmt.Errorf("search failed: %w", err)
+		return searchError(query, err)
 	}
 
 	if len(results) == 0 {
@@ -127,8 +153,52 @@ Then use find:
 		prompts[i] = result.Prompt
 	}
 
-	// Run interactive finder
-	selectedID, action, err := tui.RunFinder(prompts)
+	return runFinder(indexer, cfg, prompts, sortOrder, findGet)
+}
+
+// runFinder runs the interactive finder over prompts and carries out the chosen
+// action. With get, a selected prompt's content is output instead of its ID.
+func runFinder(indexer *index.Indexer, cfg *models.Config, prompts []models.Prompt, sortOrder string, get bool) error {
+	// The search box uses the same query syntax as 'pkit search'
+	search := func(query string, order string) ([]tui.SearchHit, error) {
+		results, err := indexer.Search(index.SearchOptions{
+			Query:         query,
+			MaxResults:    0, // Every match; the finder keeps those in its list
+			Fuzzy:         cfg.Search.FuzzyMatch,
+			Prefix:        true,
+			CaseSensitive: cfg.Search.CaseSensitive,
+			Highlight:     query != "",
+			Sort:          order,
+		})
+		if err != nil {
+			return nil, err
+		}
+		hits := make([]tui.SearchHit, len(results))
+		for i, result := range results {
+			hits[i] = tui.SearchHit{ID: result.Prompt.ID, Highlights: result.Highlights}
+		}
+		return hits, nil
+	}
+
+	// Run interactive finder; tag and bookmark changes are written to the open index
+	selectedID, action, err := tui.RunFinder(prompts, tui.FinderOptions{
+		Search: search,
+		Sort:   sortOrder,
+		MetadataChanged: func(promptID string) {
+			_ = bookmark.SyncUserMetadata(indexer, promptID)
+		},
+		Similar: func(promptID string) ([]string, error) {
+			results, err := indexer.Similar(promptID, index.SimilarOptions{MaxResults: findSimilar})
+			if err != nil {
+				return nil, err
+			}
+			ids := make([]string, len(results))
+			for i, result := range results {
+				ids[i] = result.Prompt.ID
+			}
+			return ids, nil
+		},
+	})
 	if err != nil {
 		return fmt.Errorf("finder error: %w", err)
 	}
@@ -143,12 +213,12 @@ Then use find:
 	case "get":
 		return handleFindGet(selectedID)
 	case "bookmark":
-		return handleFindBookmark(selectedID)
+		return handleFindBookmark(indexer, selectedID)
 	case "tag":
 		return handleFindTag(selectedID)
 	case "select":
 		// If --get flag is set, get the prompt
-		if findGet {
+		if get {
 			return handleFindGet(selectedID)
 		}
 		// Otherwise just output the ID
@@ -170,7 +240,7 @@ func handleFindGet(promptID string) error {
 	return display.PrintPromptText(os.Stdout, prompt)
 }
 
-func handleFindBookmark(promptID string) error {
+func handleFindBookmark(indexer *index.Indexer, promptID string) error {
 	// Add bookmark
 	mgr := bookmark.NewManager()
 
@@ -182,6 +252,7 @@ func handleFindBookmark(promptID string) error {
 	if err := mgr.AddBookmark(bm); err != nil {
 		return fmt.Errorf("failed to bookmark: %w", err)
 	}
+	syncIndexedMetadata(indexer, promptID)
 
 	fmt.Fprintf(os.Stderr, "✓ Bookmarked: %s\n", promptID)
 	return nil
@@ -226,14 +297,23 @@ func runFindFallback(args []string) (err error) {
 		query = args[0]
 	}
 
+	sortOrder, err := resolveSort(findSort, cfg, index.SortFrecency)
+	if err != nil {
+		return err
+	}
+
 	searchOpts := index.SearchOptions{
-		Query:      query,
-		MaxResults: 50,
+		Query:         query,
+		MaxResults:    50,
+		Fuzzy:         cfg.Search.FuzzyMatch,
+		CaseSensitive: cfg.Search.CaseSensitive,
+		Collapse:      findCollapse,
+		Sort:          sortOrder,
 	}
 
 	results, err := indexer.Search(searchOpts)
 	if err != nil {
-		return fmt.Errorf("search failed: %w", err)
+		return searchError(query, err)
 	}
 
 	// Output prompt IDs (one per line for piping) - error extremely rare
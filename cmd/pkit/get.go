@@ -1,16 +1,24 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/whisller/pkit/internal/bookmark"
+	"github.com/whisller/pkit/internal/compose"
+	"github.com/whisller/pkit/internal/config"
 	"github.com/whisller/pkit/internal/display"
+	"github.com/whisller/pkit/internal/parser"
+	"github.com/whisller/pkit/internal/source"
+	"github.com/whisller/pkit/internal/tokens"
+	"github.com/whisller/pkit/pkg/models"
 )
 
 var getCmd = &cobra.Command{
-	Use:   "get <alias|prompt-id>",
+	Use:   "get <alias|prompt-id>...",
 	Short: "Get prompt content for piping to execution tools",
 	Long: `Get prompt content for piping to execution tools like claude, llm, fabric, and mods.
 
@@ -43,45 +51,158 @@ Examples:
   git diff HEAD~1 | claude -p "$(pkit get review)" "explain these changes"
   git diff --cached | mods -f "$(pkit get fabric:review-commit)"
 
+  # Combine prompt and input into one document
+  cat article.txt | pkit get sum --input - | llm
+  pkit get sum --input article.txt | claude -p
+  pkit get sum --input notes.md --delimiter '\n\n=== INPUT ===\n\n'
+
+  # Prompts containing {{input}} get the input substituted in place
+
+  # Attach files and command output as fenced blocks
+  pkit get review --attach main.go --attach 'src/**/*.go' --attach-cmd 'git diff --cached'
+  pkit get review --attach internal/ --attach-max-size 64KB
+  pkit get review --attach 'build/**' --no-ignore     # Include .gitignore'd files
+  # Warns when the assembled document exceeds tokens.budget in ~/.pkit/config.yml
+
+  # Stack several prompts into one document
+  pkit get house-style fabric:review_code
+  pkit get a b c --separator '\n\n---\n\n'
+
+  # Prompts may include others: {{include "local:house-style"}}
+
   # Output as JSON
-  pkit get review --json                       # Metadata + content`,
-	Args: cobra.ExactArgs(1),
+  pkit get review --json                       # Metadata + content
+
+  # Chat API request skeletons (prompt = system message, input = user message)
+  # Piped stdin becomes the user message only with --input -, by design: it is
+  # never read just because it is not a terminal, so scripts with an open stdin
+  # don't hang waiting for it
+  git diff | pkit get review --input - --format openai --model gpt-4o | curl ... -d @-
+  pkit get sum --input article.md --format anthropic --max-tokens 2048
+  pkit get sum --format messages               # Bare message array
+  # model/temperature/top_p/max_tokens may also come from prompt front matter`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: runGet,
 }
 
 var (
-	getJSON    bool
-	getVerbose bool
-	getDebug   bool
+	getJSON      bool
+	getVerbose   bool
+	getDebug     bool
+	getInput     string
+	getDelimiter string
+	getSeparator string
+	getFormat    string
+
+	getModel       string
+	getTemperature float64
+	getTopP        float64
+	getMaxTokens   int
+
+	getAttach        []string
+	getAttachCmd     []string
+	getAttachMaxSize string
+	getAttachBinary  bool
+	getNoIgnore      bool
 )
 
 func init() {
 	rootCmd.AddCommand(getCmd)
 
 	getCmd.Flags().BoolVar(&getJSON, "json", false, "Output prompt metadata as JSON")
+	getCmd.Flags().StringVar(&getFormat, "format", "text", "Output format: text, json, openai, anthropic, messages")
+	getCmd.Flags().StringVar(&getModel, "model", "", "Model for chat formats (overrides front matter)")
+	getCmd.Flags().Float64Var(&getTemperature, "temperature", 0, "Temperature for chat formats (overrides front matter)")
+	getCmd.Flags().Float64Var(&getTopP, "top-p", 0, "top_p for chat formats (overrides front matter)")
+	getCmd.Flags().IntVar(&getMaxTokens, "max-tokens", 0, "max_tokens for chat formats (overrides front matter)")
 	getCmd.Flags().BoolVarP(&getVerbose, "verbose", "v", false, "Show operation details to stderr")
 	getCmd.Flags().BoolVar(&getDebug, "debug", false, "Show full trace to stderr")
+	getCmd.Flags().StringVar(&getInput, "input", "", "Combine the prompt with input from a file (use - for stdin)")
+	getCmd.Flags().StringVar(&getDelimiter, "delimiter", compose.DefaultDelimiter, "Delimiter between prompt and input (supports \\n and \\t)")
+	getCmd.Flags().StringVar(&getSeparator, "separator", compose.DefaultSeparator, "Separator between stacked prompts (supports \\n and \\t)")
+	getCmd.Flags().StringArrayVar(&getAttach, "attach", nil, "Attach a file, directory or glob (repeatable, supports **)")
+	getCmd.Flags().StringArrayVar(&getAttachCmd, "attach-cmd", nil, "Attach the output of a shell command (repeatable)")
+	getCmd.Flags().StringVar(&getAttachMaxSize, "attach-max-size", "256KB", "Truncate each attachment to this size (0 for no limit)")
+	getCmd.Flags().BoolVar(&getAttachBinary, "attach-binary", false, "Include binary files instead of skipping them")
+	getCmd.Flags().BoolVar(&getNoIgnore, "no-ignore", false, "Do not apply .gitignore rules when expanding --attach globs")
 }
 
 func runGet(cmd *cobra.Command, args []string) error {
-	identifier := args[0]
+	identifier := strings.Join(args, " ")
+
+	format := getFormat
+	if getJSON {
+		format = "json"
+	}
+	if format != "text" && format != "json" && !display.IsChatFormat(format) {
+		return fmt.Errorf("invalid format '%s' (use text, json, openai, anthropic or messages)", format)
+	}
 
 	if getVerbose || getDebug {
 		fmt.Fprintf(os.Stderr, "→ Resolving: %s\n", identifier)
 	}
 
-	// Resolve identifier to prompt
-	prompt, err := bookmark.ResolveWithContext(identifier)
-	if err != nil {
-		return fmt.Errorf("failed to resolve '%s': %w", identifier, err)
+	// Resolve identifier(s) to prompt, stacking several into one document. Plain
+	// text only needs the content, which the resolve cache has without opening
+	// the index
+	var prompt *models.Prompt
+	var err error
+	cached := false
+	if format == "text" {
+		prompt, cached = bookmark.ResolveStackCached(args, compose.UnescapeDelimiter(getSeparator))
+	}
+	if !cached {
+		prompt, err = bookmark.ResolveStackWithContext(args, compose.UnescapeDelimiter(getSeparator))
+		if err != nil {
+			return fmt.Errorf("failed to resolve '%s': %w", identifier, err)
+		}
 	}
 
 	if getVerbose || getDebug {
-		fmt.Fprintf(os.Stderr, "→ Found: %s (%s)\n", prompt.Name, prompt.ID)
+		from := "index"
+		if cached {
+			from = "resolve cache"
+		}
+		fmt.Fprintf(os.Stderr, "→ Found: %s (%s) in %s\n", prompt.Name, prompt.ID, from)
+	}
+
+	// Collect attachments
+	var attachments []compose.Attachment
+	if len(getAttach) > 0 || len(getAttachCmd) > 0 {
+		attachments, err = collectAttachments()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Chat formats put input and attachments into the user message
+	if display.IsChatFormat(format) {
+		return outputChatRequest(cmd, prompt, attachments, format)
+	}
+
+	// Append attachments as fenced blocks
+	prompt.Content = compose.WithAttachments(prompt.Content, attachments)
+
+	// Combine with input document if requested
+	if getInput != "" {
+		input, err := compose.ReadInput(getInput, os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		if getVerbose || getDebug {
+			fmt.Fprintf(os.Stderr, "→ Input: %d bytes from %s\n", len(input), getInput)
+		}
+
+		prompt.Content = compose.WithInput(prompt.Content, input, compose.UnescapeDelimiter(getDelimiter))
+	}
+
+	if len(attachments) > 0 || getInput != "" {
+		warnOverBudget(prompt.Content)
 	}
 
 	// Output based on format
-	if getJSON {
+	if format == "json" {
 		if err := display.PrintPromptJSON(os.Stdout, prompt); err != nil {
 			return fmt.Errorf("failed to output JSON: %w", err)
 		}
@@ -94,3 +215,161 @@ func runGet(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// outputChatRequest prints a chat API request skeleton. The prompt body becomes the
+// system message; a fabric-style user.md, the input (--input, - for stdin) and any
+// attachments form the user message. Model parameters come from the prompt's front
+// matter, overridden by flags.
+func outputChatRequest(cmd *cobra.Command, prompt *models.Prompt, attachments []compose.Attachment, format string) error {
+	frontMatter, system, err := parser.SplitFrontMatter(prompt.Content)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring front matter of %s: %v\n", prompt.ID, err)
+	}
+
+	// Fabric patterns may ship a user.md next to system.md
+	user, err := source.LoadUserPart(prompt)
+	if err != nil {
+		return err
+	}
+	user = parser.StripFrontMatter(user)
+
+	// Stdin is only read with --input -, so scripts with an open stdin don't block
+	if getInput != "" {
+		input, err := compose.ReadInput(getInput, os.Stdin)
+		if err != nil {
+			return err
+		}
+		if user == "" {
+			user = input
+		} else {
+			user = compose.WithInput(user, input, compose.UnescapeDelimiter(getDelimiter))
+		}
+	}
+
+	user = strings.Trim(compose.WithAttachments(user, attachments), "\n")
+
+	if len(attachments) > 0 || getInput != "" {
+		warnOverBudget(system + "\n" + user)
+	}
+
+	// The input travels as the user message, so drop its placeholder from the system prompt
+	system = strings.TrimRight(strings.ReplaceAll(system, compose.InputPlaceholder, ""), "\n")
+
+	params := chatParamsFromFrontMatter(frontMatter)
+	if cmd.Flags().Changed("model") {
+		params.Model = getModel
+	}
+	if cmd.Flags().Changed("temperature") {
+		params.Temperature = &getTemperature
+	}
+	if cmd.Flags().Changed("top-p") {
+		params.TopP = &getTopP
+	}
+	if cmd.Flags().Changed("max-tokens") {
+		params.MaxTokens = getMaxTokens
+	}
+
+	err = display.PrintChatRequest(os.Stdout, format, system, user, params)
+	if errors.Is(err, display.ErrNoUserMessage) {
+		return fmt.Errorf("%w: pass --input (- for stdin) or --attach, or use --format openai or messages", err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to output %s request: %w", format, err)
+	}
+
+	return nil
+}
+
+// chatParamsFromFrontMatter reads model, temperature, top_p and max_tokens from front matter.
+func chatParamsFromFrontMatter(frontMatter map[string]interface{}) display.ChatParams {
+	var params display.ChatParams
+
+	if model, ok := frontMatter["model"].(string); ok {
+		params.Model = model
+	}
+	if temperature, ok := toFloat(frontMatter["temperature"]); ok {
+		params.Temperature = &temperature
+	}
+	if topP, ok := toFloat(frontMatter["top_p"]); ok {
+		params.TopP = &topP
+	}
+	if maxTokens, ok := toFloat(frontMatter["max_tokens"]); ok {
+		params.MaxTokens = int(maxTokens)
+	}
+
+	return params
+}
+
+// toFloat converts a YAML number to float64.
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case uint64:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// collectAttachments loads --attach files and runs --attach-cmd commands in flag order.
+func collectAttachments() ([]compose.Attachment, error) {
+	maxSize, err := compose.ParseSize(getAttachMaxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := compose.AttachOptions{
+		MaxSize:       maxSize,
+		RespectIgnore: !getNoIgnore,
+		AllowBinary:   getAttachBinary,
+	}
+
+	attachments, skipped, err := compose.CollectFiles(getAttach, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range skipped {
+		fmt.Fprintf(os.Stderr, "Warning: skipping binary file %s (use --attach-binary to include)\n", path)
+	}
+
+	for _, command := range getAttachCmd {
+		if getVerbose || getDebug {
+			fmt.Fprintf(os.Stderr, "→ Running: %s\n", command)
+		}
+
+		attachment, err := compose.RunCommand(command, os.Stderr, opts)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, *attachment)
+	}
+
+	for _, attachment := range attachments {
+		if attachment.Truncated {
+			fmt.Fprintf(os.Stderr, "Warning: %s truncated to %s\n", attachment.Label, getAttachMaxSize)
+		}
+		if getVerbose || getDebug {
+			fmt.Fprintf(os.Stderr, "→ Attached: %s (%d bytes)\n", attachment.Label, len(attachment.Content))
+		}
+	}
+
+	return attachments, nil
+}
+
+// warnOverBudget prints a warning when an assembled document exceeds the token
+// budget configured in ~/.pkit/config.yml (tokens.budget).
+func warnOverBudget(content string) {
+	cfg, err := config.Load()
+	if err != nil || cfg.Tokens.Budget <= 0 {
+		return
+	}
+
+	if count := tokens.Count(content); count > cfg.Tokens.Budget {
+		fmt.Fprintf(os.Stderr, "Warning: assembled document is ~%d tokens, over the budget of %d (tokens.budget in config.yml)\n", count, cfg.Tokens.Budget)
+	}
+}
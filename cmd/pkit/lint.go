@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/whisller/pkit/internal/alias"
+	"github.com/whisller/pkit/internal/config"
+	"github.com/whisller/pkit/internal/index"
+	"github.com/whisller/pkit/internal/lint"
+	"github.com/whisller/pkit/internal/source"
+	"github.com/whisller/pkit/pkg/models"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [source|path]",
+	Short: "Check prompts for broken includes, undefined variables and other problems",
+	Long: `Lint parses prompts and reports quality and consistency problems.
+
+The argument is a subscribed source ID or a directory (e.g. a prompt repository
+you have not subscribed to yet). Without an argument all subscribed sources are
+checked. pkit exits with status 1 when errors are found, so lint can run in
+pre-commit hooks and CI.
+
+Checks:
+  empty-content        Prompt has no content
+  front-matter         Front matter is not valid YAML
+  broken-include       {{include "..."}} points to a missing prompt or forms a cycle
+  undefined-var        {{variable}} is not built in or declared under "vars" in front matter
+  duplicate-name       Several prompts share the same ID
+  empty-description    Prompt has no description
+  trailing-whitespace  Trailing whitespace or blank lines at end of file
+
+Examples:
+  pkit lint                                  # All subscribed sources
+  pkit lint local                            # One subscribed source
+  pkit lint .                                # Repository in the current directory
+  pkit lint . --id team                      # Resolve includes as source "team"
+  pkit lint . --format sarif > pkit.sarif    # SARIF for code scanning
+  pkit lint --disable trailing-whitespace    # Skip a check
+  pkit lint . --fail-on warning              # Also fail on warnings`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLint,
+}
+
+var (
+	lintFormat  string
+	lintDisable []string
+	lintFailOn  string
+	lintID      string
+)
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+
+	lintCmd.Flags().StringVar(&lintFormat, "format", "text", "Output format (text, json, sarif)")
+	lintCmd.Flags().StringSliceVar(&lintDisable, "disable", []string{}, "Checks to skip (can specify multiple)")
+	lintCmd.Flags().StringVar(&lintFailOn, "fail-on", "error", "Exit non-zero on: error, warning")
+	lintCmd.Flags().StringVar(&lintID, "id", "", "Source ID to assume when linting a directory (default: directory name)")
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	if lintFailOn != string(lint.SeverityError) && lintFailOn != string(lint.SeverityWarning) {
+		return fmt.Errorf("invalid --fail-on '%s' (use error or warning)", lintFailOn)
+	}
+	if lintFormat != lint.FormatText && lintFormat != lint.FormatJSON && lintFormat != lint.FormatSARIF {
+		return fmt.Errorf("unknown format: %s (supported: text, json, sarif)", lintFormat)
+	}
+
+	known := make(map[string]bool)
+	for _, check := range lint.Checks() {
+		known[check.Name()] = true
+	}
+	for _, name := range lintDisable {
+		if !known[name] {
+			return fmt.Errorf("unknown check: %s", name)
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	sources, err := lintSources(cfg, args)
+	if err != nil {
+		return err
+	}
+
+	// Parse prompts
+	var targets []lint.Target
+	for i := range sources {
+		src := &sources[i]
+
+		p, err := source.GetParser(src.Format)
+		if err != nil {
+			return fmt.Errorf("failed to get parser for %s: %w", src.ID, err)
+		}
+
+		prompts, err := p.ParsePrompts(src)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", src.ID, err)
+		}
+
+		for _, prompt := range prompts {
+			file, wholeFile := lintFilePath(src, &prompt)
+			targets = append(targets, lint.Target{
+				Prompt:     prompt,
+				File:       file,
+				PartOfFile: !wholeFile,
+			})
+		}
+	}
+
+	linted := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		linted[t.Prompt.ID] = true
+	}
+
+	resolveInclude, closeIndex := includeResolver(linted)
+	defer closeIndex()
+
+	diagnostics := lint.Run(&lint.Context{
+		Targets:        targets,
+		ResolveInclude: resolveInclude,
+	}, lintDisable)
+
+	if err := lint.Write(os.Stdout, lintFormat, diagnostics, len(targets), version); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	// Summary
+	errors, warnings := lint.Count(diagnostics)
+	if errors == 0 && warnings == 0 {
+		fmt.Fprintf(os.Stderr, "✓ No problems found in %d prompts\n", len(targets))
+	} else {
+		fmt.Fprintf(os.Stderr, "✗ %d error(s), %d warning(s) in %d prompts\n", errors, warnings, len(targets))
+	}
+
+	if errors > 0 || (lintFailOn == string(lint.SeverityWarning) && warnings > 0) {
+		return &exitCodeError{code: 1}
+	}
+
+	return nil
+}
+
+// lintSources returns the sources to lint: a subscribed source by ID, a directory,
+// or all subscribed sources.
+func lintSources(cfg *models.Config, args []string) ([]models.Source, error) {
+	if len(args) == 0 {
+		if len(cfg.Sources) == 0 {
+			return nil, fmt.Errorf("no sources subscribed. Pass a directory to lint: pkit lint <path>")
+		}
+		return cfg.Sources, nil
+	}
+
+	target := args[0]
+	for _, src := range cfg.Sources {
+		if src.ID == target {
+			return []models.Source{src}, nil
+		}
+	}
+
+	info, err := os.Stat(target)
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("'%s' is neither a subscribed source nor a directory", target)
+	}
+
+	absPath, err := filepath.Abs(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path: %w", err)
+	}
+
+	id := lintID
+	if id == "" {
+		id = strings.ToLower(filepath.Base(absPath))
+	}
+
+	return []models.Source{{
+		ID:        id,
+		Name:      id,
+		LocalPath: absPath,
+		Format:    source.DetectSourceFormat(absPath),
+	}}, nil
+}
+
+// lintFilePath returns the file the prompt is written in, relative to the working
+// directory when possible, and whether the prompt's content is the whole file.
+// Prompts of awesome_chatgpt sources are a cell of the source's prompts.csv.
+func lintFilePath(src *models.Source, prompt *models.Prompt) (string, bool) {
+	if src.Format == "awesome_chatgpt" {
+		return relativeToWorkDir(filepath.Join(src.LocalPath, "prompts.csv")), false
+	}
+
+	path, err := source.PromptFilePathIn(src, prompt)
+	if err != nil {
+		return prompt.FilePath, true
+	}
+	return relativeToWorkDir(path), true
+}
+
+// relativeToWorkDir returns path relative to the working directory when it is
+// inside it.
+func relativeToWorkDir(path string) string {
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return path
+}
+
+// includeResolver resolves include targets against aliases, the linted prompts and
+// the search index. The index is only opened when needed.
+func includeResolver(linted map[string]bool) (func(target string) (string, bool), func()) {
+	aliases, err := alias.LoadAliases()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load aliases: %v\n", err)
+	}
+
+	var indexer *index.Indexer
+	indexOpened := false
+
+	resolve := func(target string) (string, bool) {
+		for _, a := range aliases {
+			if a.Name == target {
+				if a.IsComposition() {
+					return a.Name, true
+				}
+				target = a.PromptID
+				break
+			}
+		}
+
+		if linted[target] {
+			return target, true
+		}
+		if !strings.Contains(target, ":") {
+			return "", false
+		}
+
+		if !indexOpened {
+			indexOpened = true
+			if indexBasePath, err := config.GetIndexPath(); err == nil {
+				indexPath := filepath.Join(indexBasePath, "prompts.bleve")
+				if _, err := os.Stat(indexPath); err == nil {
+					indexer, err = index.NewReader(indexPath)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Warning: failed to open index: %v\n", err)
+					}
+				}
+			}
+		}
+		if indexer == nil {
+			return "", false
+		}
+
+		if _, err := indexer.GetPromptByID(target); err != nil {
+			return "", false
+		}
+		return target, true
+	}
+
+	closeIndex := func() {
+		if indexer != nil {
+			_ = indexer.Close()
+		}
+	}
+
+	return resolve, closeIndex
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -30,6 +31,16 @@ like claude, llm, fabric, and mods.`,
 	SuggestionsMinimumDistance: 2,
 }
 
+// exitCodeError makes pkit exit with a specific code without printing an error
+// (e.g. to propagate the exit code of a tool started by 'pkit run').
+type exitCodeError struct {
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
 func init() {
 	// Set custom help function to show subcommands (only for root command)
 	rootCmd.SetHelpFunc(customHelp)
@@ -64,7 +75,13 @@ func customHelp(cmd *cobra.Command, args []string) {
 		"search",
 		"find",
 		"get",
+		"run",
 		"show",
+		"diff",
+		"dupes",
+		"similar",
+		"suggest",
+		"lint",
 		"serve",
 		"bookmark",
 		"alias",
@@ -138,6 +155,11 @@ func main() {
 	}
 
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
+
 		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
@@ -1,13 +1,22 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/whisller/pkit/internal/bookmark"
 	"github.com/whisller/pkit/internal/config"
+	"github.com/whisller/pkit/internal/embed"
 	"github.com/whisller/pkit/internal/index"
+	"github.com/whisller/pkit/internal/resolvecache"
 	"github.com/whisller/pkit/internal/source"
 	"github.com/whisller/pkit/pkg/models"
 )
@@ -15,29 +24,55 @@ import (
 var reindexCmd = &cobra.Command{
 	Use:   "reindex",
 	Short: "Rebuild the search index for all subscribed sources",
-	Long: `Reindex rebuilds the search index by re-parsing all subscribed sources.
+	Long: `Reindex re-parses subscribed sources and replaces their prompts in the
+search index, deleting prompts gone from them. Every source has its own index,
+built next to the old one and swapped in when complete, so searches never see a
+source half indexed. Sources are indexed in parallel, their prompt files read
+and parsed on all CPUs and indexed as they are parsed, so even sources with
+tens of thousands of prompts are never held in memory whole (--verbose reports
+progress). The indexes of sources no longer subscribed are deleted. A source
+index that can't be opened is deleted and built again.
 
 This is useful when:
-- The index schema has changed (after pkit upgrades)
-- The index is corrupted
 - Source files have been updated manually
+- The index is corrupted (use --full)
+
+--full deletes the index and builds it again from scratch. An index built by a
+pkit version with another index schema is rebuilt like this automatically when
+opened (unless cache.auto_rebuild is off in config.yml), and by reindex. A full
+rebuild can't run while 'pkit web' has the index open; a plain reindex goes
+through it.
+
+With embeddings.command set in config.yml, prompts are also embedded for
+search --semantic. Only prompts whose text changed since the last reindex are
+embedded again.
 
 Examples:
   pkit reindex                    # Reindex all sources
-  pkit reindex --source fabric    # Reindex only fabric source`,
+  pkit reindex --source fabric    # Reindex only fabric, keeping other sources
+  pkit reindex --full             # Rebuild the index from scratch`,
 	RunE: runReindex,
 }
 
 var (
 	reindexSource  string
 	reindexVerbose bool
+	reindexFull    bool
 )
 
 func init() {
 	rootCmd.AddCommand(reindexCmd)
 
 	reindexCmd.Flags().StringVar(&reindexSource, "source", "", "Reindex only this source")
+	reindexCmd.Flags().BoolVar(&reindexFull, "full", false, "Delete the index and rebuild it from scratch")
 	reindexCmd.Flags().BoolVarP(&reindexVerbose, "verbose", "v", false, "Show detailed progress")
+
+	// Indexes built with another schema are rebuilt when a command opens them
+	index.BuildVersion = version
+	index.Rebuild = rebuildOutdatedIndex
+
+	// 'pkit get' resolves prompts from a cache that follows the index
+	index.Changed = updateResolveCache
 }
 
 func runReindex(cmd *cobra.Command, args []string) (err error) {
@@ -50,6 +85,9 @@ func runReindex(cmd *cobra.Command, args []string) (err error) {
 	if len(cfg.Sources) == 0 {
 		return fmt.Errorf("no sources subscribed. Use 'pkit subscribe' first")
 	}
+	if reindexFull && reindexSource != "" {
+		return fmt.Errorf("--full rebuilds every source and can't be combined with --source")
+	}
 
 	// Get index path
 	indexBasePath, err := config.GetIndexPath()
@@ -59,27 +97,18 @@ func runReindex(cmd *cobra.Command, args []string) (err error) {
 
 	indexPath := filepath.Join(indexBasePath, "prompts.bleve")
 
-	// Delete old index
+	var progress io.Writer
 	if reindexVerbose {
-		fmt.Println("Deleting old index...")
-	}
-	if err := index.DeleteIndex(indexPath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to delete old index: %w", err)
+		progress = os.Stdout
 	}
 
-	// Create new index
-	if reindexVerbose {
-		fmt.Println("Creating new index...")
-	}
-	indexer, err := index.NewIndexer(indexPath)
-	if err != nil {
-		return fmt.Errorf("failed to create index: %w", err)
-	}
-	defer func() {
-		if closeErr := indexer.Close(); closeErr != nil && err == nil {
-			err = fmt.Errorf("failed to close index: %w", closeErr)
+	if reindexFull {
+		if err := rebuildIndex(cfg, indexBasePath, progress); err != nil {
+			return err
 		}
-	}()
+		fmt.Printf("✓ Reindexed %d source(s)\n", len(cfg.Sources))
+		return nil
+	}
 
 	// Reindex each source
 	sourcesToReindex := cfg.Sources
@@ -98,36 +127,396 @@ func runReindex(cmd *cobra.Command, args []string) (err error) {
 		}
 	}
 
-	for _, src := range sourcesToReindex {
-		if reindexVerbose {
-			fmt.Printf("Reindexing %s...\n", src.ID)
+	// A running 'pkit web' holds the index: reindex through it
+	var indexer *index.Indexer
+	if index.Serving(indexPath) {
+		indexer, err = index.NewIndexer(indexPath)
+	} else {
+		indexer, err = index.OpenOrCreate(indexPath)
+	}
+	// A source index that can't be opened is deleted and the source indexed again
+	var sourceErr *index.SourceError
+	for errors.As(err, &sourceErr) {
+		fmt.Fprintf(os.Stderr, "→ Warning: the search index of %s is broken (%v), indexing it again\n", sourceErr.SourceID, sourceErr.Err)
+		if err := index.DeleteSourceIndex(indexPath, sourceErr.SourceID); err != nil {
+			return fmt.Errorf("failed to delete index of %s: %w", sourceErr.SourceID, err)
 		}
+		sourcesToReindex = withSource(sourcesToReindex, cfg.Sources, sourceErr.SourceID)
+		indexer, err = index.OpenOrCreate(indexPath)
+	}
+	var schemaErr *index.SchemaError
+	if errors.As(err, &schemaErr) {
+		// Prompts can't be replaced in an index of another schema
+		fmt.Fprintf(os.Stderr, "→ The search index has schema version %d, this pkit needs %d: rebuilding it from scratch\n",
+			schemaErr.Version, index.SchemaVersion)
+		if err := rebuildIndex(cfg, indexBasePath, progress); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Reindexed %d source(s)\n", len(cfg.Sources))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open index: %w", err)
+	}
+	defer func() {
+		if closeErr := indexer.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close index: %w", closeErr)
+		}
+	}()
 
-		// Get parser for this source
-		p, err := source.GetParser(src.Format)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to get parser for %s: %v\n", src.ID, err)
-			continue
+	// Sources no longer subscribed lose their index
+	if reindexSource == "" {
+		if err := dropUnsubscribed(indexer, cfg.Sources); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	indexed := indexSources(indexer, sourcesToReindex, cfg.Embeddings.Command != "", progress)
+
+	if cfg.Embeddings.Command != "" {
+		// Embeddings of prompts gone from the sources are dropped when reindexing all
+		if err := updateEmbeddings(cfg, indexBasePath, indexed, reindexSource == "", progress); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	fmt.Printf("✓ Reindexed %d source(s)\n", len(sourcesToReindex))
+	return nil
+}
+
+// rebuildIndex deletes the index and builds it again from all subscribed
+// sources, writing progress to progress when it is not nil.
+func rebuildIndex(cfg *models.Config, indexBasePath string, progress io.Writer) (err error) {
+	indexPath := filepath.Join(indexBasePath, "prompts.bleve")
+	if index.Serving(indexPath) {
+		return fmt.Errorf("the index is in use by 'pkit web', stop it before rebuilding the index")
+	}
+
+	// Delete old index
+	if progress != nil {
+		_, _ = fmt.Fprintln(progress, "Deleting old index...")
+	}
+	if err := index.DeleteIndex(indexPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete old index: %w", err)
+	}
+	if err := os.Remove(resolvecache.Path(indexBasePath)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete resolve cache: %w", err)
+	}
+
+	// Create new index
+	if progress != nil {
+		_, _ = fmt.Fprintln(progress, "Creating new index...")
+	}
+	indexer, err := index.OpenOrCreate(indexPath)
+	if err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+	defer func() {
+		if closeErr := indexer.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close index: %w", closeErr)
 		}
+	}()
+
+	indexed := indexSources(indexer, cfg.Sources, cfg.Embeddings.Command != "", progress)
+
+	if cfg.Embeddings.Command != "" {
+		if err := updateEmbeddings(cfg, indexBasePath, indexed, true, progress); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// rebuildOutdatedIndex is index.Rebuild: it rebuilds an index built with
+// another schema when cache.auto_rebuild is on, reporting progress on stderr.
+func rebuildOutdatedIndex(indexPath string, schemaErr *index.SchemaError) error {
+	cfg, err := config.Load()
+	if err != nil || !cfg.Cache.AutoRebuild || len(cfg.Sources) == 0 {
+		return schemaErr
+	}
+
+	fmt.Fprintf(os.Stderr, "→ The search index has schema version %d, this pkit needs %d: rebuilding it from %d source(s)...\n",
+		schemaErr.Version, index.SchemaVersion, len(cfg.Sources))
+	if err := rebuildIndex(cfg, filepath.Dir(indexPath), os.Stderr); err != nil {
+		return fmt.Errorf("failed to rebuild index: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, "✓ Rebuilt the search index")
+	return nil
+}
+
+// updateResolveCache is index.Changed: it records where the prompts indexed
+// are read from in the resolve cache and forgets those deleted. Failures are
+// only warned about, 'pkit get' then resolves those prompts from the index.
+func updateResolveCache(indexPath string, prompts []models.Prompt, deletedIDs []string) {
+	if err := applyResolveCache(filepath.Dir(indexPath), prompts, deletedIDs); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to update resolve cache: %v\n", err)
+	}
+}
+
+func applyResolveCache(indexBasePath string, prompts []models.Prompt, deletedIDs []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	sources := make(map[string]*models.Source, len(cfg.Sources))
+	for n := range cfg.Sources {
+		sources[cfg.Sources[n].ID] = &cfg.Sources[n]
+	}
 
-		// Parse prompts
-		prompts, err := p.ParsePrompts(&src)
+	cachePath := resolvecache.Path(indexBasePath)
+	cache, err := resolvecache.Load(cachePath)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range deletedIDs {
+		cache.Delete(id)
+	}
+	for n := range prompts {
+		prompt := &prompts[n]
+		src, ok := sources[prompt.SourceID]
+		if !ok || prompt.Content == "" {
+			cache.Delete(prompt.ID)
+			continue
+		}
+		path, err := source.PromptFilePathIn(src, prompt)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to parse %s: %v\n", src.ID, err)
+			cache.Delete(prompt.ID)
 			continue
 		}
+		cache.Put(prompt.ID, resolvecache.Entry{
+			Name: prompt.Name,
+			Path: path,
+			Hash: resolvecache.Hash([]byte(prompt.Content)),
+		})
+	}
+
+	return cache.Save(cachePath)
+}
+
+// withSource returns sources with the subscribed source of ID sourceID added,
+// when it is not in them yet.
+func withSource(sources, subscribed []models.Source, sourceID string) []models.Source {
+	for _, src := range sources {
+		if src.ID == sourceID {
+			return sources
+		}
+	}
+	for _, src := range subscribed {
+		if src.ID == sourceID {
+			return append(sources, src)
+		}
+	}
+	return sources
+}
 
-		// Index prompts
-		if err := indexer.IndexPrompts(prompts); err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to index %s: %v\n", src.ID, err)
+// dropUnsubscribed deletes the indexes of sources that are no longer subscribed.
+func dropUnsubscribed(indexer *index.Indexer, subscribed []models.Source) error {
+	sourceIDs, err := indexer.SourceIDs()
+	if err != nil {
+		return fmt.Errorf("failed to list indexed sources: %w", err)
+	}
+
+	for _, sourceID := range sourceIDs {
+		if len(withSource(nil, subscribed, sourceID)) > 0 {
 			continue
 		}
+		if err := indexer.DeletePromptsBySource(sourceID); err != nil {
+			return fmt.Errorf("failed to delete index of %s: %w", sourceID, err)
+		}
+	}
+	return nil
+}
 
-		if reindexVerbose {
-			fmt.Printf("  ✓ Indexed %d prompts\n", len(prompts))
+// indexSources parses sources and replaces their prompts in the index, several
+// sources at once. With keep, it returns the prompts indexed. Sources that fail
+// are warned about and skipped.
+func indexSources(indexer *index.Indexer, sources []models.Source, keep bool, progress io.Writer) []models.Prompt {
+	// Prompts are indexed with the user's tags, bookmarks and aliases
+	setIndexUserMetadata(indexer)
+
+	var indexed collectedPrompts
+	var collect func(models.Prompt)
+	if keep {
+		collect = indexed.add
+	}
+
+	g := new(errgroup.Group)
+	g.SetLimit(runtime.NumCPU())
+
+	for n, src := range sources {
+		g.Go(func() error {
+			if progress != nil {
+				_, _ = fmt.Fprintf(progress, "Reindexing %s (%d/%d)...\n", src.ID, n+1, len(sources))
+			}
+
+			count, err := indexSource(indexer, &src, progress, collect)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to reindex %s: %v\n", src.ID, err)
+				return nil
+			}
+
+			if progress != nil {
+				_, _ = fmt.Fprintf(progress, "  ✓ Indexed %d prompts of %s\n", count, src.ID)
+			}
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+	return indexed.prompts
+}
+
+// collectedPrompts gathers the prompts indexSource hands over from several
+// goroutines at once.
+type collectedPrompts struct {
+	mu      sync.Mutex
+	prompts []models.Prompt
+}
+
+// add is a collect function for indexSource.
+func (c *collectedPrompts) add(prompt models.Prompt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prompts = append(c.prompts, prompt)
+}
+
+// embeddingCollector returns a collect function for indexSource that gathers
+// prompts into c when an embedding command is configured, nil otherwise.
+func embeddingCollector(cfg *models.Config, c *collectedPrompts) func(models.Prompt) {
+	if cfg.Embeddings.Command == "" {
+		return nil
+	}
+	return c.add
+}
+
+// embedIndexed embeds the prompts subscribe and upgrade indexed when an
+// embedding command is configured, keeping the embeddings of other prompts.
+// Failures are only warned about: 'pkit reindex' embeds what is missing.
+func embedIndexed(cfg *models.Config, prompts []models.Prompt, progress io.Writer) {
+	if cfg.Embeddings.Command == "" || len(prompts) == 0 {
+		return
+	}
+
+	indexBasePath, err := config.GetIndexPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to embed prompts: %v\n", err)
+		return
+	}
+	if err := updateEmbeddings(cfg, indexBasePath, prompts, false, progress); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// indexProgressInterval is how many prompts of a source are indexed between
+// progress lines.
+const indexProgressInterval = 1000
+
+// indexSource parses a source's prompts straight into a new index of the
+// source, replacing its old one, and returns how many prompts it indexed. It
+// writes progress to progress when it is not nil and hands every prompt
+// indexed to collect when it is not nil. It is how subscribe, upgrade and
+// reindex index whole sources.
+func indexSource(indexer *index.Indexer, src *models.Source, progress io.Writer, collect func(models.Prompt)) (int, error) {
+	p, err := source.GetParser(src.Format)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get parser: %w", err)
+	}
+
+	w, err := indexer.NewSourceWriter(src.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to index prompts: %w", err)
+	}
+
+	var indexErr error
+	err = p.StreamPrompts(src, func(prompt models.Prompt) error {
+		if err := w.Add(prompt); err != nil {
+			indexErr = err
+			return err
+		}
+		if collect != nil {
+			collect(prompt)
+		}
+		if progress != nil && w.Count()%indexProgressInterval == 0 {
+			_, _ = fmt.Fprintf(progress, "  %s: %d prompts indexed...\n", src.ID, w.Count())
 		}
+		return nil
+	})
+	if indexErr != nil {
+		w.Abort()
+		return 0, fmt.Errorf("failed to index prompts: %w", indexErr)
+	}
+	if err != nil {
+		w.Abort()
+		return 0, fmt.Errorf("failed to parse prompts: %w", err)
 	}
 
-	fmt.Printf("✓ Reindexed %d source(s)\n", len(sourcesToReindex))
+	if err := w.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to index prompts: %w", err)
+	}
+	return w.Count(), nil
+}
+
+// updateEmbeddings embeds the prompts whose text changed since they were last
+// embedded, writing progress to progress when it is not nil. With prune,
+// embeddings of other prompts are dropped. Prompts embedded before a failure are
+// saved.
+func updateEmbeddings(cfg *models.Config, indexBasePath string, prompts []models.Prompt, prune bool, progress io.Writer) error {
+	storePath := embed.StorePath(indexBasePath)
+	store, err := embed.LoadStore(storePath)
+	if err != nil {
+		return err
+	}
+
+	provider := embed.NewProvider(cfg.Embeddings.Command)
+	if stale := store.Stale(prompts, provider); len(stale) > 0 {
+		fmt.Fprintf(os.Stderr, "→ Embedding %d prompt(s)...\n", len(stale))
+	}
+
+	updateErr := store.Update(prompts, provider, func(done, total int) {
+		if progress != nil && (done%50 == 0 || done == total) {
+			_, _ = fmt.Fprintf(progress, "  ✓ Embedded %d/%d prompts\n", done, total)
+		}
+	})
+	if updateErr == nil && prune {
+		keep := make(map[string]bool, len(prompts))
+		for _, prompt := range prompts {
+			keep[prompt.ID] = true
+		}
+		store.Prune(keep)
+	}
+
+	if err := store.Save(storePath); err != nil {
+		return err
+	}
+	if updateErr != nil {
+		return fmt.Errorf("%w (run 'pkit reindex' to retry)", updateErr)
+	}
 	return nil
 }
+
+// setIndexUserMetadata loads tags, bookmarks and aliases so prompts indexed
+// afterwards carry them. Failing to load them only skips the metadata.
+func setIndexUserMetadata(indexer *index.Indexer) {
+	meta, err := bookmark.LoadUserMetadata()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load tags, bookmarks and aliases for the index: %v\n", err)
+		return
+	}
+	indexer.SetUserMetadata(meta)
+}
+
+// syncIndexedMetadata updates the indexed tags, bookmark and aliases of prompts
+// after one of them changed. With a nil indexer the index is opened. Failures are
+// only warned about: the YAML files stay the source of truth.
+func syncIndexedMetadata(indexer *index.Indexer, promptIDs ...string) {
+	var err error
+	if indexer != nil {
+		err = bookmark.SyncUserMetadata(indexer, promptIDs...)
+	} else {
+		err = bookmark.SyncUserMetadataWithContext(promptIDs...)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: search index not updated: %v (run 'pkit reindex')\n", err)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"github.com/whisller/pkit/internal/bookmark"
+	"github.com/whisller/pkit/internal/compose"
+	"github.com/whisller/pkit/internal/config"
+	"github.com/whisller/pkit/internal/executor"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run <alias|prompt-id>... [-- extra args]",
+	Short: "Run a prompt with an execution tool (claude, llm, mods, fabric)",
+	Long: `Run resolves a prompt and executes a tool with it, streaming the tool's output.
+
+Piped stdin is forwarded to the tool as input. Arguments after "--" are passed to
+the tool. The tool's exit code becomes pkit's exit code, and bookmark usage is
+recorded just like 'pkit get'.
+
+Executors are named command templates. Built-in profiles exist for claude, llm,
+mods and fabric; define your own (or override these) in ~/.pkit/config.yml:
+
+  default_executor: llm
+  executors:
+    llm:
+      command: llm
+      args: ["-s", "{{prompt}}", "{{args}}"]
+      stdin: input          # input | prompt | combined | none
+    ollama:
+      command: ollama
+      args: ["run", "llama3", "{{args}}"]
+      stdin: combined
+
+Placeholders: {{prompt}} (prompt text), {{prompt_file}} (temp file with the prompt),
+{{args}} (extra args, appended when absent).
+
+Examples:
+  git diff | pkit run review                   # Default executor
+  cat article.md | pkit run sum --with llm     # Pick an executor
+  pkit run review --with llm -- -m gpt-4o      # Extra args for the tool
+  pkit run house-style review --with claude    # Stacked prompts
+  pkit run review --input main.go --with mods  # Input from a file`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRun,
+}
+
+var (
+	runWith    string
+	runInput   string
+	runVerbose bool
+)
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().StringVar(&runWith, "with", "", "Executor to use (default: default_executor or first tool found in PATH)")
+	runCmd.Flags().StringVar(&runInput, "input", "", "Read input from a file instead of stdin (use - for stdin)")
+	runCmd.Flags().BoolVarP(&runVerbose, "verbose", "v", false, "Show the executed command to stderr")
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	// Split identifiers from extra args after "--"
+	identifiers := args
+	var extra []string
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		identifiers = args[:dash]
+		extra = args[dash:]
+	}
+	if len(identifiers) == 0 {
+		return fmt.Errorf("requires at least 1 alias or prompt ID before --")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	exe, err := executor.Lookup(cfg, runWith)
+	if err != nil {
+		return err
+	}
+
+	// Resolve prompt (records bookmark usage)
+	prompt, err := bookmark.ResolveStackWithContext(identifiers, compose.DefaultSeparator)
+	if err != nil {
+		return fmt.Errorf("failed to resolve '%s': %w", strings.Join(identifiers, " "), err)
+	}
+
+	// Input: explicit file, or stdin when it is piped
+	var input io.Reader
+	switch {
+	case runInput != "" && runInput != "-":
+		f, err := os.Open(runInput)
+		if err != nil {
+			return fmt.Errorf("failed to open input file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		input = f
+	case runInput == "-" || !isatty.IsTerminal(os.Stdin.Fd()):
+		input = os.Stdin
+	}
+
+	execCmd, cleanup, err := exe.Command(context.Background(), prompt.Content, input, extra)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+	execCmd.Stdout = os.Stdout
+	execCmd.Stderr = os.Stderr
+
+	if runVerbose {
+		fmt.Fprintf(os.Stderr, "→ Running %s (%s) with %s\n", exe.Name, execCmd.Path, prompt.ID)
+	}
+
+	// Let the tool handle Ctrl+C; pkit waits for it and reports its exit code
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+
+	if err := execCmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code := exitErr.ExitCode()
+			if code < 0 {
+				// Terminated by a signal
+				code = 1
+			}
+			return &exitCodeError{code: code}
+		}
+		return fmt.Errorf("failed to run %s: %w", exe.Name, err)
+	}
+
+	return nil
+}
@@ -2,18 +2,22 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/mattn/go-isatty"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
-	"github.com/whisller/pkit/internal/bookmark"
 	"github.com/whisller/pkit/internal/config"
+	"github.com/whisller/pkit/internal/embed"
 	"github.com/whisller/pkit/internal/index"
-	"github.com/whisller/pkit/internal/tag"
+	"github.com/whisller/pkit/internal/tokens"
 	"github.com/whisller/pkit/pkg/models"
 	"golang.org/x/term"
 )
@@ -23,19 +27,70 @@ var searchCmd = &cobra.Command{
 	Short: "Search for prompts across all subscribed sources",
 	Long: `Search for prompts using keyword search across all subscribed sources.
 
-Returns results in a table format showing ID, description, user tags, and bookmark status.
-Bookmarked prompts are shown first in the results.
-Supports filtering by source, tags, and bookmark status.
+Returns results in a table format showing ID, description, user tags, estimated token
+count, and bookmark status. Matched terms are highlighted, and when a prompt matched
+in its content only, the matching fragment is shown under the description.
+Bookmarked prompts are shown first in the results, unless another --sort order is
+chosen. Supports filtering by source, tags, and bookmark status.
+
+Sort orders (--sort, default from search.sort in config.yml, else score):
+  score      Best match first
+  usage      Most used first
+  recent     Most recently used first
+  updated    Most recently updated in the source first
+  name       Alphabetical by name
+  frecency   Best match, boosted by how often and how recently you use a prompt
+
+Query syntax:
+  word              Match in name, description and content (words are ANDed)
+  "two words"       Phrase
+  field:value       Match in one field: name, desc, content, tag, alias, notes,
+                    source, author, id, lang (e.g. lang:de)
+  field:"a phrase"  Phrase in one field
+  summar*, te?t     Wildcards
+  -term, NOT term   Exclude
+  a OR b, (a OR b)  Either term, grouping
+
+Words match within a few typos (--fuzzy, default from search.fuzzy_match in
+config.yml): one edit for words of 3-5 letters, two for longer ones. Exact
+matches rank first. --case-sensitive (or search.case_sensitive) requires words,
+phrases and wildcards to match with the same case and spelling.
+
+Prompts are analyzed in their own language (the language: of their source in
+config.yml, or detected from their text), and the query is analyzed for each
+language in the index, so "übersetzen" also finds German prompts that say
+"übersetze".
+
+--semantic also finds prompts that mean the same as the query in other words,
+like "make this shorter" finding a summarizer. It needs an embedding command in
+config.yml that reads a text on stdin and writes its vector (a JSON array) to
+stdout; prompts are embedded by 'pkit reindex':
+
+  embeddings:
+    command: "my-embedder --model nomic-embed-text"
+    weight: 0.5    # Share of the score from meaning rather than keywords
+
+Quote queries that contain - or parentheses so the shell and flag parser leave them alone.
 
 Examples:
   pkit search "code review"
+  pkit search 'name:review -tag:nsfw "code smell"'
+  pkit search '(tag:go OR tag:rust) review*'
   pkit search "summarize" --source fabric
   pkit search "python" --tag dev
   pkit search "debug" --format json
   pkit search "review" --bookmarked        # Show only bookmarked prompts
   pkit search "code" -b                    # Short flag for bookmarked
   pkit search "review" --content           # Include content preview in table
-  pkit search "review" -c --format json    # Include full content in JSON`,
+  pkit search "review" -c --format json    # Include full content in JSON
+  pkit search "review" --max-tokens 2000   # Only prompts up to ~2000 tokens
+  pkit search "review" --collapse          # One result per group of near-duplicates
+  pkit search "review" --sort frecency     # Prompts you use most often first
+  pkit search "code" --limit 20 --offset 20 # Second page of 20 results
+  pkit search "sumarize" --fuzzy           # Tolerate typos
+  pkit search "API" --case-sensitive       # Only the upper-case word
+  pkit search "make this shorter" --semantic # Match by meaning too
+  pkit search "" --facets --limit 0        # Prompt counts per source and tag`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runSearch,
 }
@@ -46,20 +101,34 @@ var (
 	searchFormat     string
 	searchLimit      int
 	searchFuzzy      bool
+	searchCase       bool
 	searchBookmarked bool
 	searchContent    bool
+	searchMaxTokens  int
+	searchCollapse   bool
+	searchSort       string
+	searchOffset     int
+	searchFacets     bool
+	searchSemantic   bool
 )
 
 func init() {
 	rootCmd.AddCommand(searchCmd)
 
 	searchCmd.Flags().StringVar(&searchSource, "source", "", "Filter by source ID")
-	searchCmd.Flags().StringSliceVar(&searchTags, "tag", []string{}, "Filter by tags (can specify multiple)")
+	searchCmd.Flags().StringSliceVar(&searchTags, "tag", []string{}, "Filter by source or user tags (can specify multiple)")
 	searchCmd.Flags().StringVar(&searchFormat, "format", "table", "Output format (table, json)")
-	searchCmd.Flags().IntVar(&searchLimit, "limit", 50, "Maximum number of results")
-	searchCmd.Flags().BoolVar(&searchFuzzy, "fuzzy", false, "Enable fuzzy matching")
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 50, "Maximum number of results (0 for all)")
+	searchCmd.Flags().IntVar(&searchOffset, "offset", 0, "Skip this many results (for paging with --limit)")
+	searchCmd.Flags().BoolVar(&searchFacets, "facets", false, "Show how many matches each source and tag has")
+	searchCmd.Flags().BoolVar(&searchFuzzy, "fuzzy", false, "Match words with typos (default from search.fuzzy_match)")
+	searchCmd.Flags().BoolVar(&searchSemantic, "semantic", false, "Also match prompts by meaning (needs embeddings.command)")
+	searchCmd.Flags().BoolVar(&searchCase, "case-sensitive", false, "Match words with the same case (default from search.case_sensitive)")
 	searchCmd.Flags().BoolVarP(&searchBookmarked, "bookmarked", "b", false, "Show only bookmarked prompts")
 	searchCmd.Flags().BoolVarP(&searchContent, "content", "c", false, "Include full prompt content in results")
+	searchCmd.Flags().IntVar(&searchMaxTokens, "max-tokens", 0, "Only show prompts with at most this many tokens")
+	searchCmd.Flags().BoolVar(&searchCollapse, "collapse", false, "Collapse near-duplicate prompts into the best-ranked one")
+	searchCmd.Flags().StringVar(&searchSort, "sort", "", "Result order: "+strings.Join(index.SortOrders, ", "))
 }
 
 func runSearch(cmd *cobra.Command, args []string) (err error) {
@@ -83,6 +152,21 @@ Then search:
   pkit search "code review"`)
 	}
 
+	sortOrder, err := resolveSort(searchSort, cfg, index.SortScore)
+	if err != nil {
+		return err
+	}
+	fuzzy, caseSensitive := cfg.Search.FuzzyMatch, cfg.Search.CaseSensitive
+	if cmd.Flags().Changed("fuzzy") {
+		fuzzy = searchFuzzy
+	}
+	if cmd.Flags().Changed("case-sensitive") {
+		caseSensitive = searchCase
+	}
+	if searchOffset < 0 || searchLimit < 0 {
+		return fmt.Errorf("--offset and --limit must not be negative")
+	}
+
 	// Get index path
 	indexBasePath, err := config.GetIndexPath()
 	if err != nil {
@@ -91,7 +175,7 @@ Then search:
 
 	// Open index
 	indexPath := filepath.Join(indexBasePath, "prompts.bleve")
-	indexer, err := index.NewIndexer(indexPath)
+	indexer, err := index.NewReader(indexPath)
 	if err != nil {
 		return fmt.Errorf("failed to open index: %w", err)
 	}
@@ -103,83 +187,72 @@ Then search:
 
 	// Build search options
 	searchOpts := index.SearchOptions{
-		Query:      query,
-		MaxResults: searchLimit,
-		SourceID:   searchSource,
-		Tags:       searchTags,
-		Fuzzy:      searchFuzzy,
+		Query:         query,
+		MaxResults:    searchLimit,
+		Offset:        searchOffset,
+		SourceID:      searchSource,
+		Tags:          searchTags,
+		Bookmarked:    searchBookmarked,
+		Fuzzy:         fuzzy,
+		CaseSensitive: caseSensitive,
+		MaxTokens:     searchMaxTokens,
+		Collapse:      searchCollapse,
+		Highlight:     true,
+		Sort:          sortOrder,
+		Facets:        searchFacets,
+	}
+	if searchSemantic {
+		searchOpts.Semantic, err = semanticOptions(cfg, indexBasePath, query)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Execute search
-	results, err := indexer.Search(searchOpts)
+	page, err := indexer.SearchPage(searchOpts)
 	if err != nil {
-		return fmt.Errorf("search failed: %w", err)
+		return searchError(query, err)
 	}
+	results := page.Results
 
-	// Handle empty results
-	if len(results) == 0 {
-		fmt.Fprintln(os.Stderr, "No results found")
+	// Handle empty results (JSON output still reports the total and facets)
+	if len(results) == 0 && searchFormat != "json" {
+		if searchFacets {
+			writeFacets(page.Facets)
+		}
+		if page.Total > 0 {
+			fmt.Fprintf(os.Stderr, "No results at offset %d (%d in total)\n", searchOffset, page.Total)
+		} else if searchBookmarked {
+			fmt.Fprintln(os.Stderr, "No bookmarked prompts found matching the search criteria")
+		} else {
+			fmt.Fprintln(os.Stderr, "No results found")
+		}
 		return nil
 	}
 
-	// Load bookmarks and tags for enriching results
-	bookmarkManager := bookmark.NewManager()
-	bookmarks, err := bookmarkManager.ListBookmarks()
-	if err != nil {
-		// Non-fatal: just log and continue without bookmark info
-		fmt.Fprintf(os.Stderr, "Warning: failed to load bookmarks: %v\n", err)
-		bookmarks = []models.Bookmark{}
-	}
-
-	tagManager := tag.NewManager()
-	allTags, err := tagManager.ListAllTags()
-	if err != nil {
-		// Non-fatal: just log and continue without tag info
-		fmt.Fprintf(os.Stderr, "Warning: failed to load tags: %v\n", err)
-		allTags = []models.PromptTags{}
-	}
-
-	// Create lookup maps
+	// Bookmarks and user tags are indexed with the prompts
 	bookmarkMap := make(map[string]bool)
-	for _, bm := range bookmarks {
-		bookmarkMap[bm.PromptID] = true
-	}
-
 	tagMap := make(map[string][]string)
-	for _, pt := range allTags {
-		tagMap[pt.PromptID] = pt.Tags
+	for _, result := range results {
+		bookmarkMap[result.Prompt.ID] = result.UserMetadata.Bookmarked
+		tagMap[result.Prompt.ID] = result.UserMetadata.UserTags
 	}
 
-	// Filter by bookmarked if flag is set
-	if searchBookmarked {
-		filteredResults := []index.SearchResult{}
-		for _, result := range results {
-			if bookmarkMap[result.Prompt.ID] {
-				filteredResults = append(filteredResults, result)
+	// Sort results: bookmarked prompts first (other orders are applied by the index)
+	if sortOrder == index.SortScore {
+		sort.SliceStable(results, func(i, j int) bool {
+			iBookmarked := bookmarkMap[results[i].Prompt.ID]
+			jBookmarked := bookmarkMap[results[j].Prompt.ID]
+
+			if iBookmarked != jBookmarked {
+				return iBookmarked // bookmarked comes first
 			}
-		}
-		results = filteredResults
 
-		// Handle empty results after filtering
-		if len(results) == 0 {
-			fmt.Fprintln(os.Stderr, "No bookmarked prompts found matching the search criteria")
-			return nil
-		}
+			// If both bookmarked or both not, sort by score
+			return results[i].Score > results[j].Score
+		})
 	}
 
-	// Sort results: bookmarked prompts first
-	sort.Slice(results, func(i, j int) bool {
-		iBookmarked := bookmarkMap[results[i].Prompt.ID]
-		jBookmarked := bookmarkMap[results[j].Prompt.ID]
-
-		if iBookmarked != jBookmarked {
-			return iBookmarked // bookmarked comes first
-		}
-
-		// If both bookmarked or both not, sort by score
-		return results[i].Score > results[j].Score
-	})
-
 	// Load content from files if requested
 	contentMap := make(map[string]string)
 	if searchContent {
@@ -206,15 +279,50 @@ Then search:
 	// Output results based on format
 	switch searchFormat {
 	case "json":
-		return outputJSON(results, bookmarkMap, tagMap, contentMap)
+		return outputJSON(page, bookmarkMap, tagMap, contentMap)
 	case "table":
-		return outputTable(results, bookmarkMap, tagMap, contentMap)
+		return outputTable(page, bookmarkMap, tagMap, contentMap, searchMarkFunc(cfg))
 	default:
 		return fmt.Errorf("unknown format: %s (supported: table, json)", searchFormat)
 	}
 }
 
-func outputTable(results []index.SearchResult, bookmarkMap map[string]bool, tagMap map[string][]string, contentMap map[string]string) error {
+// defaultSemanticWeight is the share of the score from similarity when
+// embeddings.weight is not set.
+const defaultSemanticWeight = 0.5
+
+// semanticOptions embeds the query and compares it to the stored prompt embeddings.
+func semanticOptions(cfg *models.Config, indexBasePath, query string) (*index.SemanticOptions, error) {
+	if cfg.Embeddings.Command == "" {
+		return nil, fmt.Errorf(`--semantic needs an embedding command, set embeddings.command in config.yml:
+  embeddings:
+    command: "my-embedder"`)
+	}
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("--semantic needs a query")
+	}
+
+	store, err := embed.LoadStore(embed.StorePath(indexBasePath))
+	if err != nil {
+		return nil, err
+	}
+	if len(store.Entries) == 0 || store.Command != cfg.Embeddings.Command {
+		return nil, fmt.Errorf("prompts are not embedded with embeddings.command yet, run 'pkit reindex'")
+	}
+
+	vector, err := embed.NewProvider(cfg.Embeddings.Command).Embed(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	weight := cfg.Embeddings.Weight
+	if weight == 0 {
+		weight = defaultSemanticWeight
+	}
+	return &index.SemanticOptions{Similarities: store.Similarities(vector), Weight: weight}, nil
+}
+
+func outputTable(page *index.SearchPage, bookmarkMap map[string]bool, tagMap map[string][]string, contentMap map[string]string, mark func(string) string) error {
 	// Get terminal width
 	termWidth, _, err := term.GetSize(int(os.Stdout.Fd()))
 	if err != nil || termWidth < 60 {
@@ -232,14 +340,14 @@ func outputTable(results []index.SearchResult, bookmarkMap map[string]bool, tagM
 	// Set header based on whether content is included
 	includeContent := len(contentMap) > 0
 	if includeContent {
-		table.Header("ID", "DESCRIPTION", "TAGS", "CONTENT PREVIEW")
+		table.Header("ID", "DESCRIPTION", "TAGS", "TOKENS", "CONTENT PREVIEW")
 	} else {
-		table.Header("ID", "DESCRIPTION", "TAGS")
+		table.Header("ID", "DESCRIPTION", "TAGS", "TOKENS")
 	}
 
 	// Add rows
 	hasBookmarks := false
-	for _, result := range results {
+	for _, result := range page.Results {
 		// Get tags for this prompt
 		tags := tagMap[result.Prompt.ID]
 		tagsStr := ""
@@ -254,6 +362,24 @@ func outputTable(results []index.SearchResult, bookmarkMap map[string]bool, tagM
 			hasBookmarks = true
 		}
 
+		// Append the number of collapsed near-duplicates
+		if len(result.Duplicates) > 0 {
+			id = fmt.Sprintf("%s (+%d similar)", id, len(result.Duplicates))
+		}
+
+		tokensStr := ""
+		if result.Prompt.TokenCount > 0 {
+			tokensStr = tokens.Format(result.Prompt.TokenCount)
+		}
+
+		// Highlight matches in the description, or show where the content matched
+		description := result.Prompt.Description
+		if fragment := result.BestFragment("description"); fragment != "" {
+			description = index.FormatFragment(fragment, mark)
+		} else if fragment := result.BestFragment("content"); fragment != "" {
+			description += "\n" + index.FormatFragment(fragment, mark)
+		}
+
 		// Build row data
 		if includeContent {
 			content := contentMap[result.Prompt.ID]
@@ -263,9 +389,9 @@ func outputTable(results []index.SearchResult, bookmarkMap map[string]bool, tagM
 				contentPreview = content[:80] + "..."
 			}
 			// table.Append is in-memory operation, error extremely rare
-			_ = table.Append(id, result.Prompt.Description, tagsStr, contentPreview)
+			_ = table.Append(id, description, tagsStr, tokensStr, contentPreview)
 		} else {
-			_ = table.Append(id, result.Prompt.Description, tagsStr)
+			_ = table.Append(id, description, tagsStr, tokensStr)
 		}
 	}
 
@@ -274,45 +400,92 @@ func outputTable(results []index.SearchResult, bookmarkMap map[string]bool, tagM
 		return fmt.Errorf("failed to render table: %w", err)
 	}
 
+	if searchFacets {
+		writeFacets(page.Facets)
+	}
+
 	// Print legend if there are bookmarked results
 	if hasBookmarks {
 		fmt.Fprintln(os.Stderr, "\n[*] = Bookmarked")
 	}
 
 	// Print summary
-	fmt.Fprintf(os.Stderr, "Found %d results\n", len(results))
+	if searchOffset > 0 || len(page.Results) < page.Total {
+		fmt.Fprintf(os.Stderr, "Showing %d-%d of %d results\n", searchOffset+1, searchOffset+len(page.Results), page.Total)
+	} else {
+		fmt.Fprintf(os.Stderr, "Found %d results\n", len(page.Results))
+	}
 
 	return nil
 }
 
-func outputJSON(results []index.SearchResult, bookmarkMap map[string]bool, tagMap map[string][]string, contentMap map[string]string) error {
+// writeFacets prints the number of matches per source and tag, e.g.
+// "Sources: fabric (212), awesome (180)".
+func writeFacets(facets map[string][]index.FacetCount) {
+	sections := []struct {
+		facet string
+		label string
+	}{
+		{index.FacetSources, "Sources"},
+		{index.FacetTags, "Tags"},
+		{index.FacetUserTags, "User tags"},
+	}
+
+	_, _ = fmt.Fprintln(os.Stdout)
+	for _, section := range sections {
+		counts := facets[section.facet]
+		if len(counts) == 0 {
+			continue
+		}
+		parts := make([]string, len(counts))
+		for i, count := range counts {
+			parts[i] = fmt.Sprintf("%s (%d)", count.Term, count.Count)
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "%s: %s\n", section.label, strings.Join(parts, ", "))
+	}
+}
+
+func outputJSON(page *index.SearchPage, bookmarkMap map[string]bool, tagMap map[string][]string, contentMap map[string]string) error {
 	// Convert to JSON-friendly structure
 	type jsonPrompt struct {
-		ID          string   `json:"id"`
-		SourceID    string   `json:"source_id"`
-		Name        string   `json:"name"`
-		Description string   `json:"description"`
-		Tags        []string `json:"tags"`
-		UserTags    []string `json:"user_tags"`
-		Bookmarked  bool     `json:"bookmarked"`
-		Author      string   `json:"author,omitempty"`
-		FilePath    string   `json:"file_path"`
-		Content     string   `json:"content,omitempty"`
-		Score       float64  `json:"score"`
+		ID          string     `json:"id"`
+		SourceID    string     `json:"source_id"`
+		Name        string     `json:"name"`
+		Description string     `json:"description"`
+		Tags        []string   `json:"tags"`
+		UserTags    []string   `json:"user_tags"`
+		Bookmarked  bool       `json:"bookmarked"`
+		Author      string     `json:"author,omitempty"`
+		FilePath    string     `json:"file_path"`
+		TokenCount  int        `json:"token_count"`
+		Content     string     `json:"content,omitempty"`
+		Duplicates  []string   `json:"duplicates,omitempty"`
+		UsageCount  int        `json:"usage_count,omitempty"`
+		LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+		Score       float64    `json:"score"`
+
+		// Matched fragments per field, HTML-escaped with <mark> around matches
+		Highlights map[string][]string `json:"highlights,omitempty"`
 	}
 
 	type jsonOutput struct {
-		Query   string       `json:"query"`
-		Count   int          `json:"count"`
-		Prompts []jsonPrompt `json:"prompts"`
+		Query   string                        `json:"query"`
+		Count   int                           `json:"count"`
+		Total   int                           `json:"total"`
+		Offset  int                           `json:"offset"`
+		Facets  map[string][]index.FacetCount `json:"facets,omitempty"`
+		Prompts []jsonPrompt                  `json:"prompts"`
 	}
 
 	output := jsonOutput{
-		Count:   len(results),
-		Prompts: make([]jsonPrompt, len(results)),
+		Count:   len(page.Results),
+		Total:   page.Total,
+		Offset:  searchOffset,
+		Facets:  page.Facets,
+		Prompts: make([]jsonPrompt, len(page.Results)),
 	}
 
-	for i, result := range results {
+	for i, result := range page.Results {
 		userTags := tagMap[result.Prompt.ID]
 		if userTags == nil {
 			userTags = []string{}
@@ -328,7 +501,12 @@ func outputJSON(results []index.SearchResult, bookmarkMap map[string]bool, tagMa
 			Bookmarked:  bookmarkMap[result.Prompt.ID],
 			Author:      result.Prompt.Author,
 			FilePath:    result.Prompt.FilePath,
+			TokenCount:  result.Prompt.TokenCount,
+			Duplicates:  result.Duplicates,
+			UsageCount:  result.UserMetadata.UsageCount,
+			LastUsedAt:  result.UserMetadata.LastUsedAt,
 			Score:       result.Score,
+			Highlights:  result.Highlights,
 		}
 
 		// Add content if available
@@ -341,9 +519,52 @@ func outputJSON(results []index.SearchResult, bookmarkMap map[string]bool, tagMa
 
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false) // Keep <mark> in highlights readable
 	if err := encoder.Encode(output); err != nil {
 		return fmt.Errorf("failed to encode JSON: %w", err)
 	}
 
 	return nil
 }
+
+// resolveSort picks the result order from the --sort flag, then search.sort
+// in the config, then fallback.
+func resolveSort(flag string, cfg *models.Config, fallback string) (string, error) {
+	order := flag
+	if order == "" {
+		order = cfg.Search.Sort
+	}
+	if order == "" {
+		order = fallback
+	}
+	if err := index.ValidateSort(order); err != nil {
+		return "", err
+	}
+	return order, nil
+}
+
+// searchMarkFunc returns how matched terms are marked in the table: bold yellow
+// on a colour terminal, unmarked otherwise (NO_COLOR, pipes, display.color off).
+func searchMarkFunc(cfg *models.Config) func(string) string {
+	if os.Getenv("NO_COLOR") != "" || !isatty.IsTerminal(os.Stdout.Fd()) || !cfg.Display.Color {
+		return nil
+	}
+	return func(term string) string {
+		return "\033[1;33m" + term + "\033[0m"
+	}
+}
+
+// searchError wraps a search failure. Query syntax errors are shown with the
+// query and a marker under the offending token.
+func searchError(query string, err error) error {
+	var queryErr *index.QueryError
+	if !errors.As(err, &queryErr) {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	width := utf8.RuneCountInString(queryErr.Token)
+	if width == 0 {
+		width = 1
+	}
+	return fmt.Errorf("%w\n\n  %s\n  %s%s", err, query, strings.Repeat(" ", queryErr.Pos), strings.Repeat("^", width))
+}
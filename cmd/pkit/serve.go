@@ -21,6 +21,10 @@ var serveCmd = &cobra.Command{
 The web server provides the same functionality as 'pkit find' but in a
 browser-based interface. It binds only to localhost (127.0.0.1) for security.
 
+While it runs, the server keeps the search index open and shares it with other
+pkit commands (get, search, upgrade, ...) over a Unix socket next to the index,
+so they work at the same time instead of waiting for the index to be free.
+
 Features:
   - Browse and search prompts from all subscribed sources
   - Filter by source, tags, and bookmarked status
@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/whisller/pkit/internal/bookmark"
 	"github.com/whisller/pkit/internal/display"
+	"github.com/whisller/pkit/internal/tokens"
 )
 
 var showCmd = &cobra.Command{
@@ -19,6 +20,7 @@ The show command displays:
 - Name and description
 - Tags
 - Author information
+- Estimated token count
 - Full prompt content
 
 Examples:
@@ -57,6 +59,9 @@ func runShow(cmd *cobra.Command, args []string) error {
 		fmt.Fprintf(os.Stderr, "→ Found: %s (%s)\n", prompt.Name, prompt.ID)
 	}
 
+	// Count the resolved content (includes and stacks may differ from the indexed count)
+	prompt.TokenCount = tokens.Count(prompt.Content)
+
 	// Output based on format
 	if showJSON {
 		if err := display.PrintPromptJSON(os.Stdout, prompt); err != nil {
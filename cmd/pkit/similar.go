@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/whisller/pkit/internal/alias"
+	"github.com/whisller/pkit/internal/config"
+	"github.com/whisller/pkit/internal/index"
+)
+
+var similarCmd = &cobra.Command{
+	Use:   "similar <alias|prompt-id>",
+	Short: "List prompts similar to a prompt",
+	Long: `Similar lists the prompts most like the given one ("more like this"), e.g. to
+find the counterparts of a good prompt in other sources.
+
+The prompt's most significant words (frequent in it, rare across the index) are
+searched for, so similar prompts need not be near-duplicates (see 'pkit dupes').
+
+Examples:
+  pkit similar review                       # By alias
+  pkit similar fabric:review_code           # By prompt ID
+  pkit similar review --other-sources       # Only prompts from other sources
+  pkit similar review --limit 5 --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSimilar,
+}
+
+var (
+	similarLimit        int
+	similarOtherSources bool
+	similarFormat       string
+)
+
+func init() {
+	rootCmd.AddCommand(similarCmd)
+
+	similarCmd.Flags().IntVar(&similarLimit, "limit", 10, "Maximum number of results")
+	similarCmd.Flags().BoolVar(&similarOtherSources, "other-sources", false, "Only list prompts from other sources")
+	similarCmd.Flags().StringVar(&similarFormat, "format", "table", "Output format (table, json)")
+}
+
+func runSimilar(cmd *cobra.Command, args []string) (err error) {
+	if similarFormat != "table" && similarFormat != "json" {
+		return fmt.Errorf("unknown format: %s (supported: table, json)", similarFormat)
+	}
+	if similarLimit <= 0 {
+		return fmt.Errorf("--limit must be positive")
+	}
+
+	promptID, err := resolvePromptID(args[0])
+	if err != nil {
+		return err
+	}
+
+	indexBasePath, err := config.GetIndexPath()
+	if err != nil {
+		return fmt.Errorf("failed to get index path: %w", err)
+	}
+
+	indexPath := filepath.Join(indexBasePath, "prompts.bleve")
+	indexer, err := index.NewReader(indexPath)
+	if err != nil {
+		return fmt.Errorf("failed to open index: %w", err)
+	}
+	defer func() {
+		if closeErr := indexer.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close index: %w", closeErr)
+		}
+	}()
+
+	results, err := indexer.Similar(promptID, index.SimilarOptions{
+		MaxResults:   similarLimit,
+		OtherSources: similarOtherSources,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to find similar prompts: %w", err)
+	}
+
+	if similarFormat == "json" {
+		type jsonPrompt struct {
+			ID          string  `json:"id"`
+			SourceID    string  `json:"source_id"`
+			Description string  `json:"description"`
+			Score       float64 `json:"score"`
+		}
+
+		output := struct {
+			PromptID string       `json:"prompt_id"`
+			Similar  []jsonPrompt `json:"similar"`
+		}{PromptID: promptID, Similar: []jsonPrompt{}}
+
+		for _, r := range results {
+			output.Similar = append(output.Similar, jsonPrompt{
+				ID:          r.Prompt.ID,
+				SourceID:    r.Prompt.SourceID,
+				Description: r.Prompt.Description,
+				Score:       r.Score,
+			})
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(output); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintf(os.Stderr, "No prompts similar to %s found\n", promptID)
+		return nil
+	}
+
+	for _, r := range results {
+		_, _ = fmt.Fprintf(os.Stdout, "%-40s %s\n", r.Prompt.ID, truncateText(r.Prompt.Description, 70))
+	}
+	return nil
+}
+
+// resolvePromptID returns the prompt ID an alias points at, or identifier
+// itself when it is a prompt ID.
+func resolvePromptID(identifier string) (string, error) {
+	if a, err := alias.NewManager().GetAlias(identifier); err == nil {
+		if a.IsComposition() {
+			return "", fmt.Errorf("alias '%s' stacks several prompts (%s), use one of them", identifier, a.Target())
+		}
+		return a.PromptID, nil
+	}
+
+	if !strings.Contains(identifier, ":") {
+		return "", fmt.Errorf("no alias or prompt found for: %s", identifier)
+	}
+	return identifier, nil
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 	"github.com/whisller/pkit/internal/config"
+	"github.com/whisller/pkit/internal/index"
 	"github.com/whisller/pkit/internal/source"
 )
 
@@ -18,6 +22,7 @@ var statusCmd = &cobra.Command{
 - Current commit SHA
 - Update availability
 - Prompt count (if indexed)
+- Search index schema version and the pkit version that built it
 
 Examples:
   pkit status                  # Show status of all sources
@@ -111,6 +116,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 
 	// Print summary
 	fmt.Fprintf(os.Stderr, "\nTotal sources: %d\n", len(cfg.Sources))
+	printIndexStatus()
 
 	if statusCheckUpdates {
 		fmt.Fprintln(os.Stderr, "\nUse 'pkit upgrade <source>' to update sources")
@@ -121,3 +127,45 @@ func runStatus(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// statusOpenTimeout bounds waiting for an index held by another pkit process.
+const statusOpenTimeout = time.Second
+
+// printIndexStatus prints the schema version of the search index, the pkit
+// version that built it and how many sources it has. An index in use by another process is skipped.
+func printIndexStatus() {
+	indexBasePath, err := config.GetIndexPath()
+	if err != nil {
+		return
+	}
+	indexPath := filepath.Join(indexBasePath, "prompts.bleve")
+	if _, err := os.Stat(indexPath); err != nil {
+		fmt.Fprintln(os.Stderr, "Search index: not built yet (run 'pkit reindex')")
+		return
+	}
+
+	indexer, err := index.OpenIndexer(indexPath, statusOpenTimeout)
+	var schemaErr *index.SchemaError
+	if errors.As(err, &schemaErr) {
+		fmt.Fprintf(os.Stderr, "Search index: outdated, %v\n", err)
+		return
+	}
+	if err != nil {
+		return
+	}
+	defer func() { _ = indexer.Close() }()
+
+	sourceIDs, err := indexer.SourceIDs()
+	if err != nil {
+		return
+	}
+	if len(sourceIDs) == 0 {
+		fmt.Fprintln(os.Stderr, "Search index: no source indexed yet (run 'pkit reindex')")
+		return
+	}
+	schema, build, err := indexer.Versions()
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Search index: schema version %d, built by pkit %s, %d source(s)\n", schema, build, len(sourceIDs))
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,6 +11,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/whisller/pkit/internal/config"
 	"github.com/whisller/pkit/internal/index"
+	"github.com/whisller/pkit/internal/language"
 	"github.com/whisller/pkit/internal/source"
 	"github.com/whisller/pkit/pkg/models"
 )
@@ -24,7 +26,12 @@ The source can be specified in short form (org/repo) or as a full URL.
 Examples:
   pkit subscribe fabric/patterns
   pkit subscribe https://github.com/f/awesome-chatgpt-prompts
-  pkit subscribe fabric/patterns f/awesome-chatgpt-prompts  # Multiple sources`,
+  pkit subscribe fabric/patterns f/awesome-chatgpt-prompts  # Multiple sources
+  pkit subscribe acme/prompts-de --language de               # German prompts
+
+The language of each prompt (which sets the stemming and stop words it is
+searched with) is detected from its text, unless --language sets one for the
+whole source.`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: runSubscribe,
 }
@@ -33,6 +40,7 @@ var (
 	subscribeName    string
 	subscribeID      string
 	subscribeFormat  string
+	subscribeLang    string
 	subscribeVerbose bool
 	subscribeDebug   bool
 )
@@ -43,11 +51,16 @@ func init() {
 	subscribeCmd.Flags().StringVar(&subscribeName, "name", "", "Custom display name for the source")
 	subscribeCmd.Flags().StringVar(&subscribeID, "id", "", "Custom ID for the source")
 	subscribeCmd.Flags().StringVar(&subscribeFormat, "format", "", "Force specific parser format (fabric_pattern, awesome_chatgpt, markdown)")
+	subscribeCmd.Flags().StringVar(&subscribeLang, "language", "", "Language of the prompts (e.g. de, fr, ja); detected per prompt by default")
 	subscribeCmd.Flags().BoolVarP(&subscribeVerbose, "verbose", "v", false, "Show detailed progress and git operations")
 	subscribeCmd.Flags().BoolVar(&subscribeDebug, "debug", false, "Show full trace including timing information")
 }
 
 func runSubscribe(cmd *cobra.Command, args []string) (err error) {
+	if subscribeLang != "" && !language.Supported(subscribeLang) {
+		return fmt.Errorf("unsupported language %q (supported: %s)", subscribeLang, strings.Join(language.Codes, ", "))
+	}
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
@@ -86,6 +99,9 @@ func runSubscribe(cmd *cobra.Command, args []string) (err error) {
 		}
 	}()
 
+	// Prompts are indexed with the user's tags, bookmarks and aliases
+	setIndexUserMetadata(indexer)
+
 	// Process multiple sources
 	if len(args) > 1 {
 		return subscribeMultipleSources(mgr, indexer, cfg, args)
@@ -181,6 +197,11 @@ Options:
 		src.Format = subscribeFormat
 	}
 
+	// Override language if specified
+	if subscribeLang != "" {
+		src.Language = subscribeLang
+	}
+
 	// Override ID if specified
 	if subscribeID != "" {
 		src.ID = subscribeID
@@ -196,36 +217,27 @@ Options:
 
 	if subscribeVerbose || subscribeDebug {
 		fmt.Fprintf(os.Stderr, "→ Detecting format... %s\n", src.Format)
-		fmt.Fprintln(os.Stderr, "→ Parsing prompts...")
-	}
-
-	// Parse prompts
-	p, err := source.GetParser(src.Format)
-	if err != nil {
-		return fmt.Errorf("failed to get parser: %w", err)
-	}
-
-	prompts, err := p.ParsePrompts(src)
-	if err != nil {
-		return fmt.Errorf("failed to parse prompts: %w", err)
-	}
-
-	if subscribeVerbose || subscribeDebug {
-		fmt.Fprintf(os.Stderr, "  Found %d prompts\n", len(prompts))
-		fmt.Fprintln(os.Stderr, "→ Indexing prompts...")
+		fmt.Fprintln(os.Stderr, "→ Parsing and indexing prompts...")
 	} else {
 		fmt.Fprintln(os.Stderr, "Indexing prompts...")
 	}
 
-	// Index prompts
-	if err := indexer.IndexPrompts(prompts); err != nil {
-		return fmt.Errorf("failed to index prompts: %w", err)
+	// Index prompts as they are parsed
+	var progress io.Writer
+	if subscribeVerbose || subscribeDebug {
+		progress = os.Stderr
 	}
+	var indexed collectedPrompts
+	count, err := indexSource(indexer, src, progress, embeddingCollector(cfg, &indexed))
+	if err != nil {
+		return err
+	}
+	embedIndexed(cfg, indexed.prompts, progress)
 
-	fmt.Fprintf(os.Stderr, "[%s] Indexed %d/%d prompts\n", src.ID, len(prompts), len(prompts))
+	fmt.Fprintf(os.Stderr, "[%s] Indexed %d prompts\n", src.ID, count)
 
 	// Update source metadata
-	src.PromptCount = len(prompts)
+	src.PromptCount = count
 	src.LastIndexed = time.Now()
 	src.SubscribedAt = time.Now()
 
@@ -312,43 +324,38 @@ func subscribeMultipleSources(mgr *source.Manager, indexer *index.Indexer, cfg *
 	}
 
 	// Parse and index each source
+	var indexed collectedPrompts
+	collect := embeddingCollector(cfg, &indexed)
 	for _, req := range requests {
 		src := sources[req.SourceID]
 		if src == nil {
 			fmt.Fprintf(os.Stderr, "[%s] ✗ Failed to subscribe\n", req.SourceID)
 			continue
 		}
-
-		// Parse prompts
-		p, err := source.GetParser(src.Format)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "[%s] ✗ Failed to get parser: %v\n", src.ID, err)
-			continue
+		if subscribeLang != "" {
+			src.Language = subscribeLang
 		}
 
-		prompts, err := p.ParsePrompts(src)
+		// Index prompts as they are parsed
+		count, err := indexSource(indexer, src, nil, collect)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "[%s] ✗ Failed to parse prompts: %v\n", src.ID, err)
-			continue
-		}
-
-		// Index prompts
-		if err := indexer.IndexPrompts(prompts); err != nil {
-			fmt.Fprintf(os.Stderr, "[%s] ✗ Failed to index prompts: %v\n", src.ID, err)
+			fmt.Fprintf(os.Stderr, "[%s] ✗ %v\n", src.ID, err)
 			continue
 		}
 
 		// Update source metadata
-		src.PromptCount = len(prompts)
+		src.PromptCount = count
 		src.LastIndexed = time.Now()
 		src.SubscribedAt = time.Now()
 
 		// Add source to config
 		cfg.Sources = append(cfg.Sources, *src)
 
-		fmt.Fprintf(os.Stderr, "[%s] Cloning... ✓ %d prompts\n", src.ID, len(prompts))
+		fmt.Fprintf(os.Stderr, "[%s] Cloning... ✓ %d prompts\n", src.ID, count)
 	}
 
+	embedIndexed(cfg, indexed.prompts, nil)
+
 	// Save config
 	if err := config.Save(cfg); err != nil {
 		return fmt.Errorf("failed to save config: %w", err)
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"github.com/whisller/pkit/internal/config"
+	"github.com/whisller/pkit/internal/index"
+	"github.com/whisller/pkit/internal/suggest"
+	"github.com/whisller/pkit/pkg/models"
+)
+
+var suggestCmd = &cobra.Command{
+	Use:   "suggest",
+	Short: "Suggest prompts for text piped into pkit",
+	Long: `Suggest reads text from stdin (a diff, a log, an article...) and lists the
+prompts that fit it best.
+
+The input's most significant words are searched for, together with words for
+what kind of input it is: a diff, a stack trace, a log, code (with its language)
+or prose. Prompts for reviewing code rank high for a diff, summarizers for an
+article.
+
+With --find, the suggestions open in the interactive finder, best one selected.
+
+Examples:
+  git diff | pkit suggest                   # Prompts for a diff
+  pkit suggest < article.md                 # Prompts for an article
+  git diff | pkit suggest --find --get      # Pick one and output its content
+  cat app.log | pkit suggest --format json`,
+	Args: cobra.NoArgs,
+	RunE: runSuggest,
+}
+
+var (
+	suggestLimit  int
+	suggestFormat string
+	suggestFind   bool
+	suggestGet    bool
+)
+
+// maxSuggestInput caps how much of stdin is read; the start of a long input
+// says enough about it.
+const maxSuggestInput = 1 << 20
+
+func init() {
+	rootCmd.AddCommand(suggestCmd)
+
+	suggestCmd.Flags().IntVar(&suggestLimit, "limit", 5, "Maximum number of suggestions")
+	suggestCmd.Flags().StringVar(&suggestFormat, "format", "table", "Output format (table, json)")
+	suggestCmd.Flags().BoolVar(&suggestFind, "find", false, "Choose from the suggestions in the interactive finder")
+	suggestCmd.Flags().BoolVarP(&suggestGet, "get", "g", false, "With --find, output the chosen prompt's content")
+}
+
+func runSuggest(cmd *cobra.Command, args []string) (err error) {
+	if suggestFormat != "table" && suggestFormat != "json" {
+		return fmt.Errorf("unknown format: %s (supported: table, json)", suggestFormat)
+	}
+	if suggestLimit <= 0 {
+		return fmt.Errorf("--limit must be positive")
+	}
+	if isatty.IsTerminal(os.Stdin.Fd()) {
+		return fmt.Errorf(`no input: pipe text into pkit suggest, e.g.
+  git diff | pkit suggest`)
+	}
+
+	input, err := io.ReadAll(io.LimitReader(os.Stdin, maxSuggestInput))
+	if err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+	text := string(input)
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("no input: stdin is empty")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	hints := suggest.Detect(text)
+	kind := string(hints.Kind)
+	if hints.Kind != suggest.KindCode && hints.Kind != suggest.KindProse {
+		kind = "a " + kind
+	}
+	if hints.Language != "" {
+		kind += " (" + hints.Language + ")"
+	}
+	fmt.Fprintf(os.Stderr, "→ Input looks like %s\n", kind)
+
+	indexBasePath, err := config.GetIndexPath()
+	if err != nil {
+		return fmt.Errorf("failed to get index path: %w", err)
+	}
+
+	indexPath := filepath.Join(indexBasePath, "prompts.bleve")
+	indexer, err := index.NewIndexer(indexPath)
+	if err != nil {
+		return fmt.Errorf("failed to open index: %w", err)
+	}
+	defer func() {
+		if closeErr := indexer.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close index: %w", closeErr)
+		}
+	}()
+
+	results, err := indexer.Suggest(text, hints.Terms, suggestLimit)
+	if err != nil {
+		return fmt.Errorf("failed to suggest prompts: %w", err)
+	}
+
+	if suggestFind && len(results) > 0 && isatty.IsTerminal(os.Stdout.Fd()) {
+		prompts := make([]models.Prompt, len(results))
+		for i, result := range results {
+			prompts[i] = result.Prompt
+		}
+		return runFinder(indexer, cfg, prompts, index.SortScore, suggestGet)
+	}
+
+	if suggestFormat == "json" {
+		type jsonPrompt struct {
+			ID          string  `json:"id"`
+			SourceID    string  `json:"source_id"`
+			Description string  `json:"description"`
+			Score       float64 `json:"score"`
+		}
+
+		output := struct {
+			Kind        suggest.Kind `json:"kind"`
+			Language    string       `json:"language,omitempty"`
+			Suggestions []jsonPrompt `json:"suggestions"`
+		}{Kind: hints.Kind, Language: hints.Language, Suggestions: []jsonPrompt{}}
+
+		for _, r := range results {
+			output.Suggestions = append(output.Suggestions, jsonPrompt{
+				ID:          r.Prompt.ID,
+				SourceID:    r.Prompt.SourceID,
+				Description: r.Prompt.Description,
+				Score:       r.Score,
+			})
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(output); err != nil {
+			return fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return nil
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintln(os.Stderr, "No matching prompts found")
+		return nil
+	}
+
+	for _, r := range results {
+		_, _ = fmt.Fprintf(os.Stdout, "%-40s %s\n", r.Prompt.ID, truncateText(r.Prompt.Description, 70))
+	}
+	return nil
+}
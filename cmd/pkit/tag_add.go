@@ -43,6 +43,7 @@ func runTagAdd(cmd *cobra.Command, args []string) error {
 	if err := manager.AddTags(promptID, tags); err != nil {
 		return fmt.Errorf("failed to add tags: %w", err)
 	}
+	syncIndexedMetadata(nil, promptID)
 
 	// Output to stdout - error extremely rare (stdout closed/redirected)
 	_, _ = fmt.Fprintf(os.Stdout, "Added tags to prompt '%s': %s\n", promptID, strings.Join(tags, ", "))
@@ -41,6 +41,7 @@ func runTagRemove(cmd *cobra.Command, args []string) error {
 	if err := manager.RemoveTags(promptID, tags); err != nil {
 		return fmt.Errorf("failed to remove tags: %w", err)
 	}
+	syncIndexedMetadata(nil, promptID)
 
 	// Output to stdout - error extremely rare (stdout closed/redirected)
 	if len(tags) == 0 {
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
@@ -21,6 +23,10 @@ var upgradeCmd = &cobra.Command{
 	Long: `Upgrade sources by pulling latest changes from the remote repository
 and re-indexing the prompts.
 
+Only prompts in files changed since the last upgrade are parsed and re-indexed.
+Sources that keep all prompts in one file, a forced upgrade and --full re-index
+the whole source instead.
+
 By default, upgrades all sources with available updates.
 Specify a source name to upgrade only that source.
 
@@ -28,7 +34,8 @@ Examples:
   pkit upgrade                     # Upgrade all sources (default)
   pkit upgrade fabric              # Upgrade specific source only
   pkit upgrade --force             # Force upgrade all sources even if up to date
-  pkit upgrade fabric --force      # Force upgrade specific source`,
+  pkit upgrade fabric --force      # Force upgrade specific source
+  pkit upgrade --full              # Re-index whole sources, not only changed files`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runUpgrade,
 }
@@ -36,12 +43,14 @@ Examples:
 var (
 	upgradeForce   bool
 	upgradeVerbose bool
+	upgradeFull    bool
 )
 
 func init() {
 	rootCmd.AddCommand(upgradeCmd)
 
 	upgradeCmd.Flags().BoolVar(&upgradeForce, "force", false, "Force upgrade even if no updates")
+	upgradeCmd.Flags().BoolVar(&upgradeFull, "full", false, "Re-index whole sources instead of only changed files")
 	upgradeCmd.Flags().BoolVarP(&upgradeVerbose, "verbose", "v", false, "Show detailed progress")
 }
 
@@ -85,6 +94,9 @@ func runUpgrade(cmd *cobra.Command, args []string) (err error) {
 		}
 	}()
 
+	// Prompts are indexed with the user's tags, bookmarks and aliases
+	setIndexUserMetadata(indexer)
+
 	// Determine which sources to upgrade
 	var sourcesToUpgrade []models.Source
 
@@ -220,9 +232,11 @@ func upgradeSingleSource(mgr *source.Manager, indexer *index.Indexer, cfg *model
 	}
 
 	// Re-index prompts
-	if err := reindexSourcePrompts(indexer, src); err != nil {
+	var indexed collectedPrompts
+	if err := reindexSourcePrompts(indexer, src, newSHA, embeddingCollector(cfg, &indexed)); err != nil {
 		return fmt.Errorf("failed to re-index %s: %w", src.ID, err)
 	}
+	embedIndexed(cfg, indexed.prompts, upgradeProgress())
 
 	// Update config with new commit SHA
 	for i := range cfg.Sources {
@@ -245,6 +259,8 @@ func upgradeMultipleSources(mgr *source.Manager, indexer *index.Indexer, cfg *mo
 
 	var mu sync.Mutex
 	updatedSHAs := make(map[string]string)
+	var indexed collectedPrompts
+	collect := embeddingCollector(cfg, &indexed)
 
 	g := new(errgroup.Group)
 
@@ -262,7 +278,7 @@ func upgradeMultipleSources(mgr *source.Manager, indexer *index.Indexer, cfg *mo
 			}
 
 			// Re-index prompts
-			if err := reindexSourcePrompts(indexer, &src); err != nil {
+			if err := reindexSourcePrompts(indexer, &src, newSHA, collect); err != nil {
 				return fmt.Errorf("failed to re-index %s: %w", src.ID, err)
 			}
 
@@ -278,7 +294,11 @@ func upgradeMultipleSources(mgr *source.Manager, indexer *index.Indexer, cfg *mo
 		})
 	}
 
-	if err := g.Wait(); err != nil {
+	err := g.Wait()
+
+	// Prompts of sources upgraded before a failure are embedded too
+	embedIndexed(cfg, indexed.prompts, upgradeProgress())
+	if err != nil {
 		return err
 	}
 
@@ -297,32 +317,52 @@ func upgradeMultipleSources(mgr *source.Manager, indexer *index.Indexer, cfg *mo
 	return nil
 }
 
-func reindexSourcePrompts(indexer *index.Indexer, src *models.Source) error {
-	// Get parser for this source
-	p, err := source.GetParser(src.Format)
-	if err != nil {
-		return fmt.Errorf("failed to get parser: %w", err)
+// reindexSourcePrompts re-indexes a source updated to newSHA. Only prompts in
+// files changed since the source's previous commit are parsed, unless the
+// format or a failure requires a full re-index. Every prompt indexed is handed
+// to collect when it is not nil.
+func reindexSourcePrompts(indexer *index.Indexer, src *models.Source, newSHA string, collect func(models.Prompt)) error {
+	if !upgradeFull && src.CommitSHA != "" && src.CommitSHA != newSHA {
+		changes, err := source.ChangedPrompts(src, src.CommitSHA, newSHA)
+		if err == nil {
+			if err := indexer.ApplyChanges(changes.Updated, changes.Deleted); err != nil {
+				return fmt.Errorf("failed to index changed prompts: %w", err)
+			}
+			if collect != nil {
+				for _, prompt := range changes.Updated {
+					collect(prompt)
+				}
+			}
+
+			if upgradeVerbose {
+				fmt.Fprintf(os.Stderr, "  ✓ Re-indexed %d changed and %d deleted prompts\n", len(changes.Updated), len(changes.Deleted))
+			}
+			return nil
+		}
+
+		if !errors.Is(err, source.ErrNotIncremental) {
+			fmt.Fprintf(os.Stderr, "→ Warning: re-indexing all of %s: %v\n", src.ID, err)
+		}
 	}
 
-	// Parse prompts
-	prompts, err := p.ParsePrompts(src)
+	// Replace the source's prompts as they are parsed, deleting those gone from it
+	count, err := indexSource(indexer, src, upgradeProgress(), collect)
 	if err != nil {
-		return fmt.Errorf("failed to parse prompts: %w", err)
+		return err
 	}
 
-	// Delete old prompts for this source from index
-	if err := indexer.DeleteBySource(src.ID); err != nil {
-		return fmt.Errorf("failed to delete old prompts: %w", err)
+	if upgradeVerbose {
+		fmt.Fprintf(os.Stderr, "  ✓ Re-indexed %d prompts\n", count)
 	}
 
-	// Index new prompts
-	if err := indexer.IndexPrompts(prompts); err != nil {
-		return fmt.Errorf("failed to index prompts: %w", err)
-	}
+	return nil
+}
 
+// upgradeProgress is where upgrade writes progress: stderr with --verbose,
+// nowhere otherwise.
+func upgradeProgress() io.Writer {
 	if upgradeVerbose {
-		fmt.Fprintf(os.Stderr, "  ✓ Re-indexed %d prompts\n", len(prompts))
+		return os.Stderr
 	}
-
 	return nil
 }
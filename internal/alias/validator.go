@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	"github.com/goccy/go-yaml"
+	"github.com/whisller/pkit/internal/config"
+	"github.com/whisller/pkit/internal/resolvecache"
 	"github.com/whisller/pkit/pkg/models"
 )
 
@@ -52,6 +54,12 @@ var reservedAliases = map[string]bool{
 	"status":     true,
 	"upgrade":    true,
 	"show":       true,
+	"run":        true,
+	"lint":       true,
+	"dupes":      true,
+	"similar":    true,
+	"suggest":    true,
+	"diff":       true,
 }
 
 // ValidateAliasName validates an alias name format and checks for reserved words.
@@ -168,5 +176,24 @@ func SaveAliases(aliases []models.Alias) error {
 		return fmt.Errorf("failed to save aliases file: %w", err)
 	}
 
+	updateResolveCache(aliases, path)
+
 	return nil
 }
+
+// updateResolveCache records the aliases in the resolve cache 'pkit get' reads.
+// Failing to is harmless: the cache is not trusted once the aliases file changed.
+func updateResolveCache(aliases []models.Alias, aliasesPath string) {
+	indexBasePath, err := config.GetIndexPath()
+	if err != nil {
+		return
+	}
+
+	cachePath := resolvecache.Path(indexBasePath)
+	cache, err := resolvecache.Load(cachePath)
+	if err != nil {
+		return
+	}
+	cache.SetAliases(aliases, resolvecache.Stamp(aliasesPath))
+	_ = cache.Save(cachePath)
+}
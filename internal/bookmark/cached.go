@@ -0,0 +1,116 @@
+package bookmark
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/whisller/pkit/internal/alias"
+	"github.com/whisller/pkit/internal/compose"
+	"github.com/whisller/pkit/internal/config"
+	"github.com/whisller/pkit/internal/parser"
+	"github.com/whisller/pkit/internal/resolvecache"
+	"github.com/whisller/pkit/pkg/models"
+)
+
+// errNotCached stops include expansion at a prompt missing from the resolve cache.
+var errNotCached = errors.New("not in resolve cache")
+
+// ResolveStackCached resolves identifiers like ResolveStackWithContext, but
+// from the resolve cache alone, without opening the index or loading aliases.
+// It returns false when a prompt is not cached, its file changed since it was
+// indexed or it is a composition alias; the caller then resolves the usual way.
+// Prompts carry only their ID, name and content.
+func ResolveStackCached(identifiers []string, separator string) (*models.Prompt, bool) {
+	indexBasePath, err := config.GetIndexPath()
+	if err != nil {
+		return nil, false
+	}
+	aliasesPath, err := alias.GetAliasesPath()
+	if err != nil {
+		return nil, false
+	}
+	cache, err := resolvecache.Load(resolvecache.Path(indexBasePath))
+	if err != nil {
+		return nil, false
+	}
+
+	r := &cachedResolver{cache: cache, aliasesStamp: resolvecache.Stamp(aliasesPath)}
+	prompts := make([]*models.Prompt, 0, len(identifiers))
+	for _, identifier := range identifiers {
+		prompt, ok := r.resolve(identifier, nil)
+		if !ok {
+			return nil, false
+		}
+		prompts = append(prompts, prompt)
+	}
+
+	// Usage is only tracked once everything resolved, so falling back does not
+	// count it twice
+	for _, prompt := range prompts {
+		if NewManager().IncrementUsage(prompt.ID) == nil {
+			syncUsage(nil, prompt.ID)
+		}
+	}
+
+	if len(prompts) == 1 {
+		return prompts[0], true
+	}
+	return compose.Stack(strings.Join(identifiers, "+"), prompts, separator), true
+}
+
+// cachedResolver resolves aliases and prompt IDs from the resolve cache.
+type cachedResolver struct {
+	cache        *resolvecache.Cache
+	aliasesStamp string
+}
+
+// resolve resolves an identifier like Resolver.resolve, giving up on anything
+// the cache can't answer, including include cycles, which the slow path reports.
+func (r *cachedResolver) resolve(identifier string, chain []string) (*models.Prompt, bool) {
+	for _, seen := range chain {
+		if seen == identifier {
+			return nil, false
+		}
+	}
+	chain = append(chain, identifier)
+
+	promptID, ok := r.cache.Alias(identifier, r.aliasesStamp)
+	if !ok {
+		// Alias names can't contain ':', so anything else is a composition or unknown
+		if !strings.Contains(identifier, ":") {
+			return nil, false
+		}
+		promptID = identifier
+	}
+
+	entry, ok := r.cache.Prompt(promptID)
+	if !ok {
+		return nil, false
+	}
+	content, ok := entry.Read()
+	if !ok {
+		return nil, false
+	}
+
+	if promptID != identifier {
+		chain = append(chain, promptID)
+	}
+	content, err := compose.ExpandIncludes(content, func(target string) (string, error) {
+		included, ok := r.resolve(target, chain)
+		if !ok {
+			return "", errNotCached
+		}
+		return parser.StripFrontMatter(included.Content), nil
+	})
+	if err != nil {
+		return nil, false
+	}
+
+	sourceID, _, _ := strings.Cut(promptID, ":")
+	return &models.Prompt{
+		ID:       promptID,
+		SourceID: sourceID,
+		Name:     entry.Name,
+		Content:  content,
+	}, true
+}
@@ -6,8 +6,11 @@ import (
 	"strings"
 
 	"github.com/whisller/pkit/internal/alias"
+	"github.com/whisller/pkit/internal/compose"
 	"github.com/whisller/pkit/internal/config"
 	"github.com/whisller/pkit/internal/index"
+	"github.com/whisller/pkit/internal/parser"
+	"github.com/whisller/pkit/internal/resolvecache"
 	"github.com/whisller/pkit/internal/source"
 	"github.com/whisller/pkit/pkg/models"
 )
@@ -17,6 +20,9 @@ type Resolver struct {
 	indexer   *index.Indexer
 	aliases   []models.Alias
 	bookmarks []models.Bookmark
+
+	// Resolve cache the prompts loaded are recorded in, when set
+	cache *resolvecache.Cache
 }
 
 // NewResolver creates a new prompt resolver.
@@ -30,16 +36,110 @@ func NewResolver(indexer *index.Indexer, aliases []models.Alias, bookmarks []mod
 
 // Resolve resolves an alias or prompt ID to a Prompt.
 // Resolution order:
-// 1. Check if it's an alias
+// 1. Check if it's an alias (single prompt or composition)
 // 2. Check if it's a prompt ID (source:name format)
 // 3. Return error if not found
+// Include directives in the content are expanded recursively.
 func (r *Resolver) Resolve(identifier string) (*models.Prompt, error) {
+	return r.resolve(identifier, nil, true)
+}
+
+// ResolveStack resolves several aliases or prompt IDs and concatenates them
+// into one prompt, in order, joined by separator.
+func (r *Resolver) ResolveStack(identifiers []string, separator string) (*models.Prompt, error) {
+	if len(identifiers) == 1 {
+		return r.Resolve(identifiers[0])
+	}
+
+	prompts := make([]*models.Prompt, 0, len(identifiers))
+	for _, identifier := range identifiers {
+		prompt, err := r.resolve(identifier, nil, true)
+		if err != nil {
+			return nil, err
+		}
+		prompts = append(prompts, prompt)
+	}
+
+	return compose.Stack(strings.Join(identifiers, "+"), prompts, separator), nil
+}
+
+// ResolveAt resolves an identifier that may carry a git revision of its source
+// (e.g. "fabric:summarize@HEAD~3", "review@v1.2"). Unlike Resolve it does not
+// record bookmark usage. With a revision, the prompt's content as of that revision
+// is returned, its include directives expanded with the current prompts they refer
+// to, the way Resolve expands them, so diffs against it only show changes made to
+// the prompt itself.
+func (r *Resolver) ResolveAt(spec string) (*models.Prompt, error) {
+	identifier, rev := SplitRevision(spec)
+	if rev == "" {
+		return r.resolve(identifier, nil, false)
+	}
+
+	promptID := identifier
+	for _, a := range r.aliases {
+		if a.Name == identifier {
+			if a.IsComposition() {
+				return nil, fmt.Errorf("revisions are not supported for composition alias '%s'", a.Name)
+			}
+			promptID = a.PromptID
+			break
+		}
+	}
+	if !strings.Contains(promptID, ":") {
+		return nil, fmt.Errorf("no alias or prompt found for: %s", identifier)
+	}
+
+	prompt, err := r.indexer.GetPromptByID(promptID)
+	if err != nil {
+		return nil, fmt.Errorf("prompt not found: %s", promptID)
+	}
+
+	prompt.Content, err = source.LoadPromptContentAt(prompt, rev)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := []string{identifier}
+	if promptID != identifier {
+		chain = append(chain, promptID)
+	}
+	prompt.Content, err = r.expandIncludes(prompt.Content, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	return prompt, nil
+}
+
+// SplitRevision splits "identifier@revision" into its parts.
+// The revision is empty when spec has none.
+func SplitRevision(spec string) (identifier, rev string) {
+	if i := strings.LastIndex(spec, "@"); i > 0 && i < len(spec)-1 {
+		return spec[:i], spec[i+1:]
+	}
+	return spec, ""
+}
+
+// resolve resolves an identifier, carrying the chain of identifiers currently being
+// resolved so include directives and compositions that loop back are reported as cycles.
+// Usage is only tracked for prompts requested directly, not for included ones.
+func (r *Resolver) resolve(identifier string, chain []string, trackUsage bool) (*models.Prompt, error) {
+	for _, seen := range chain {
+		if seen == identifier {
+			return nil, fmt.Errorf("include cycle: %s -> %s", strings.Join(chain, " -> "), identifier)
+		}
+	}
+	chain = append(chain, identifier)
+
 	var promptID string
 
 	// Try to resolve as alias first
 	if r.aliases != nil {
 		for _, a := range r.aliases {
 			if a.Name == identifier {
+				if a.IsComposition() {
+					return r.resolveComposition(a, chain, trackUsage)
+				}
 				promptID = a.PromptID
 				break
 			}
@@ -65,34 +165,135 @@ func (r *Resolver) Resolve(identifier string) (*models.Prompt, error) {
 	if err := source.LoadPromptContent(prompt); err != nil {
 		return nil, fmt.Errorf("failed to load prompt content: %w", err)
 	}
+	r.remember(prompt)
+
+	// Expand include directives (e.g., {{include "local:house-style"}})
+	if promptID != identifier {
+		chain = append(chain, promptID)
+	}
+	prompt.Content, err = r.expandIncludes(prompt.Content, chain)
+	if err != nil {
+		return nil, err
+	}
 
 	// Track bookmark usage if this prompt is bookmarked
-	if r.bookmarks != nil {
-		for _, bookmark := range r.bookmarks {
-			if bookmark.PromptID == promptID {
-				manager := NewManager()
-				_ = manager.IncrementUsage(promptID)
-				break
-			}
-		}
+	if trackUsage {
+		r.trackUsage(promptID)
 	}
 
 	return prompt, nil
 }
 
+// expandIncludes expands the include directives in content, resolving the
+// prompts they refer to with chain, the identifiers being resolved.
+func (r *Resolver) expandIncludes(content string, chain []string) (string, error) {
+	return compose.ExpandIncludes(content, func(target string) (string, error) {
+		included, err := r.resolve(target, chain, false)
+		if err != nil {
+			return "", err
+		}
+		return parser.StripFrontMatter(included.Content), nil
+	})
+}
+
+// remember records where a prompt just loaded is read from in the resolve
+// cache, unless it is there already.
+func (r *Resolver) remember(prompt *models.Prompt) {
+	if r.cache == nil {
+		return
+	}
+
+	hash := resolvecache.Hash([]byte(prompt.Content))
+	if entry, ok := r.cache.Prompt(prompt.ID); ok && entry.Hash == hash {
+		return
+	}
+	path, err := source.PromptFilePath(prompt)
+	if err != nil {
+		return
+	}
+	r.cache.Put(prompt.ID, resolvecache.Entry{Name: prompt.Name, Path: path, Hash: hash})
+}
+
+// resolveComposition resolves every part of a composed alias and stacks them.
+func (r *Resolver) resolveComposition(a models.Alias, chain []string, trackUsage bool) (*models.Prompt, error) {
+	separator := a.Separator
+	if separator == "" {
+		separator = compose.DefaultSeparator
+	}
+
+	prompts := make([]*models.Prompt, 0, len(a.Compose))
+	for _, part := range a.Compose {
+		prompt, err := r.resolve(part, chain, trackUsage)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve '%s' in composition '%s': %w", part, a.Name, err)
+		}
+		prompts = append(prompts, prompt)
+	}
+
+	return compose.Stack(a.Name, prompts, separator), nil
+}
+
+// trackUsage increments the usage counter if the prompt is bookmarked.
+func (r *Resolver) trackUsage(promptID string) {
+	if r.bookmarks == nil {
+		return
+	}
+
+	for _, bookmark := range r.bookmarks {
+		if bookmark.PromptID == promptID {
+			manager := NewManager()
+			if manager.IncrementUsage(promptID) == nil {
+				// Keep the indexed usage count current
+				syncUsage(r.indexer, promptID)
+			}
+			break
+		}
+	}
+}
+
 // ResolveWithContext creates a resolver with loaded aliases, bookmarks, and index.
 func ResolveWithContext(identifier string) (*models.Prompt, error) {
+	var prompt *models.Prompt
+	err := withResolver(func(resolver *Resolver) (err error) {
+		prompt, err = resolver.Resolve(identifier)
+		return err
+	})
+	return prompt, err
+}
+
+// ResolveStackWithContext is like ResolveWithContext for several identifiers stacked into one prompt.
+func ResolveStackWithContext(identifiers []string, separator string) (*models.Prompt, error) {
+	var prompt *models.Prompt
+	err := withResolver(func(resolver *Resolver) (err error) {
+		prompt, err = resolver.ResolveStack(identifiers, separator)
+		return err
+	})
+	return prompt, err
+}
+
+// ResolveAtWithContext is like ResolveWithContext for an identifier that may carry a revision.
+func ResolveAtWithContext(spec string) (*models.Prompt, error) {
+	var prompt *models.Prompt
+	err := withResolver(func(resolver *Resolver) (err error) {
+		prompt, err = resolver.ResolveAt(spec)
+		return err
+	})
+	return prompt, err
+}
+
+// withResolver opens the index, loads aliases and bookmarks, and runs fn with a resolver.
+func withResolver(fn func(resolver *Resolver) error) (err error) {
 	// Get index path
 	indexBasePath, err := config.GetIndexPath()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get index path: %w", err)
+		return fmt.Errorf("failed to get index path: %w", err)
 	}
 
 	// Open index
 	indexPath := filepath.Join(indexBasePath, "prompts.bleve")
-	indexer, err := index.NewIndexer(indexPath)
+	indexer, err := index.NewReader(indexPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open index: %w", err)
+		return fmt.Errorf("failed to open index: %w", err)
 	}
 	defer func() {
 		if closeErr := indexer.Close(); closeErr != nil && err == nil {
@@ -100,8 +301,16 @@ func ResolveWithContext(identifier string) (*models.Prompt, error) {
 		}
 	}()
 
+	// Stamp the aliases before loading them, so the cache never pairs newer
+	// aliases with an older stamp
+	aliasesStamp := ""
+	if aliasesPath, err := alias.GetAliasesPath(); err == nil {
+		aliasesStamp = resolvecache.Stamp(aliasesPath)
+	}
+
 	// Load aliases
 	aliases, err := alias.LoadAliases()
+	aliasesLoaded := err == nil
 	if err != nil {
 		// If aliases don't exist yet, that's okay
 		aliases = []models.Alias{}
@@ -116,5 +325,22 @@ func ResolveWithContext(identifier string) (*models.Prompt, error) {
 
 	// Create resolver and resolve
 	resolver := NewResolver(indexer, aliases, bookmarks)
-	return resolver.Resolve(identifier)
+
+	// What is resolved here is remembered for ResolveStackCached (best effort)
+	cachePath := resolvecache.Path(indexBasePath)
+	cache, cacheErr := resolvecache.Load(cachePath)
+	if cacheErr == nil {
+		if aliasesLoaded && !cache.AliasesCurrent(aliasesStamp) {
+			cache.SetAliases(aliases, aliasesStamp)
+		}
+		resolver.cache = cache
+	}
+
+	if err := fn(resolver); err != nil {
+		return err
+	}
+	if cacheErr == nil {
+		_ = cache.Save(cachePath)
+	}
+	return nil
 }
@@ -0,0 +1,239 @@
+package bookmark
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/whisller/pkit/internal/alias"
+	"github.com/whisller/pkit/internal/config"
+	"github.com/whisller/pkit/internal/index"
+	"github.com/whisller/pkit/internal/source"
+	"github.com/whisller/pkit/internal/tag"
+	"github.com/whisller/pkit/pkg/models"
+)
+
+// syncOpenTimeout bounds the wait for an index held open by another process.
+const syncOpenTimeout = time.Second
+
+// pendingUsageFile lists, one prompt ID per line, bookmarks used while the index
+// could not be written (it was open read-only or not opened at all). The next
+// SyncUserMetadata indexes their usage.
+const pendingUsageFile = "usage.pending"
+
+// LoadUserMetadata loads tags, bookmarks and aliases and combines them per prompt ID.
+func LoadUserMetadata() (map[string]models.UserMetadata, error) {
+	tags, err := tag.LoadTags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tags: %w", err)
+	}
+
+	bookmarks, err := LoadBookmarks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bookmarks: %w", err)
+	}
+
+	aliases, err := alias.LoadAliases()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aliases: %w", err)
+	}
+
+	return BuildUserMetadata(tags, bookmarks, aliases), nil
+}
+
+// BuildUserMetadata combines tags, bookmarks and aliases per prompt ID.
+// An alias is attached to its prompt, or to every prompt of a composition.
+func BuildUserMetadata(tags []models.PromptTags, bookmarks []models.Bookmark, aliases []models.Alias) map[string]models.UserMetadata {
+	meta := make(map[string]models.UserMetadata)
+
+	for _, pt := range tags {
+		m := meta[pt.PromptID]
+		m.UserTags = append(m.UserTags, pt.Tags...)
+		sort.Strings(m.UserTags)
+		meta[pt.PromptID] = m
+	}
+
+	for _, bm := range bookmarks {
+		m := meta[bm.PromptID]
+		m.Bookmarked = true
+		m.Notes = bm.Notes
+		m.UsageCount = bm.UsageCount
+		m.LastUsedAt = bm.LastUsedAt
+		meta[bm.PromptID] = m
+	}
+
+	byName := make(map[string]models.Alias, len(aliases))
+	for _, a := range aliases {
+		byName[a.Name] = a
+	}
+	for _, a := range aliases {
+		for _, promptID := range aliasTargets(a, byName, map[string]bool{}) {
+			m := meta[promptID]
+			m.Aliases = append(m.Aliases, a.Name)
+			meta[promptID] = m
+		}
+	}
+
+	return meta
+}
+
+// aliasTargets returns the prompt IDs an alias points at, following aliases
+// inside compositions.
+func aliasTargets(a models.Alias, byName map[string]models.Alias, seen map[string]bool) []string {
+	if seen[a.Name] {
+		return nil
+	}
+	seen[a.Name] = true
+
+	if !a.IsComposition() {
+		return []string{a.PromptID}
+	}
+
+	var ids []string
+	for _, entry := range a.Compose {
+		if nested, ok := byName[entry]; ok {
+			ids = append(ids, aliasTargets(nested, byName, seen)...)
+		} else {
+			ids = append(ids, entry)
+		}
+	}
+	return ids
+}
+
+// SyncUserMetadata rewrites the index documents of the given prompts with their
+// current tags, bookmark and aliases, along with those of bookmarks whose usage
+// is pending. Prompts that are not indexed are skipped.
+func SyncUserMetadata(indexer *index.Indexer, promptIDs ...string) error {
+	pending := pendingUsage()
+	promptIDs = append(promptIDs, pending...)
+	if len(promptIDs) == 0 {
+		return nil
+	}
+
+	meta, err := LoadUserMetadata()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(promptIDs))
+	for _, promptID := range promptIDs {
+		if seen[promptID] {
+			continue
+		}
+		seen[promptID] = true
+
+		prompt, err := indexer.GetPromptByID(promptID)
+		if err != nil {
+			// Not indexed (e.g. a bookmark of a prompt removed upstream)
+			continue
+		}
+
+		// Search results don't carry content, so it is re-read to index it again
+		if err := source.LoadPromptContent(prompt); err != nil {
+			return fmt.Errorf("failed to load content of %s: %w", promptID, err)
+		}
+
+		if err := indexer.IndexPromptWithMetadata(*prompt, meta[promptID]); err != nil {
+			return fmt.Errorf("failed to index %s: %w", promptID, err)
+		}
+	}
+
+	if len(pending) > 0 {
+		clearPendingUsage()
+	}
+	return nil
+}
+
+// syncUsage indexes the usage of a bookmark that was just used, through indexer
+// or, when it is nil, through the process serving the index. When the index
+// can't be written now the prompt is left pending.
+func syncUsage(indexer *index.Indexer, promptID string) {
+	indexBasePath, err := config.GetIndexPath()
+	if err != nil {
+		return
+	}
+
+	synced := false
+	switch {
+	case indexer != nil:
+		synced = SyncUserMetadata(indexer, promptID) == nil
+	case index.Serving(filepath.Join(indexBasePath, "prompts.bleve")):
+		synced = SyncUserMetadataWithContext(promptID) == nil
+	}
+	if synced {
+		return
+	}
+
+	// Left for the next sync (best effort, like the usage counter itself)
+	file, err := os.OpenFile(filepath.Join(indexBasePath, pendingUsageFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintln(file, promptID)
+	_ = file.Close()
+}
+
+// pendingUsage returns the prompts in the pending usage file.
+func pendingUsage() []string {
+	indexBasePath, err := config.GetIndexPath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(indexBasePath, pendingUsageFile))
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(data))
+}
+
+// clearPendingUsage empties the pending usage file.
+func clearPendingUsage() {
+	indexBasePath, err := config.GetIndexPath()
+	if err != nil {
+		return
+	}
+	_ = os.Remove(filepath.Join(indexBasePath, pendingUsageFile))
+}
+
+// SyncUserMetadataWithContext opens the index and runs SyncUserMetadata.
+// It fails instead of waiting when another process holds the index open.
+func SyncUserMetadataWithContext(promptIDs ...string) (err error) {
+	if len(promptIDs) == 0 {
+		return nil
+	}
+
+	indexBasePath, err := config.GetIndexPath()
+	if err != nil {
+		return fmt.Errorf("failed to get index path: %w", err)
+	}
+
+	indexer, err := index.OpenIndexer(filepath.Join(indexBasePath, "prompts.bleve"), syncOpenTimeout)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := indexer.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close index: %w", closeErr)
+		}
+	}()
+
+	return SyncUserMetadata(indexer, promptIDs...)
+}
+
+// AliasPromptIDs returns the prompt IDs an alias points at, for syncing after
+// the alias changes.
+func AliasPromptIDs(a models.Alias) []string {
+	aliases, err := alias.LoadAliases()
+	if err != nil {
+		aliases = nil
+	}
+
+	byName := make(map[string]models.Alias, len(aliases))
+	for _, other := range aliases {
+		byName[other.Name] = other
+	}
+	return aliasTargets(a, byName, map[string]bool{})
+}
@@ -0,0 +1,294 @@
+package compose
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxAttachmentSize is the default per-attachment size limit (256 KiB).
+const DefaultMaxAttachmentSize = 256 * 1024
+
+// binarySniffLen is how many leading bytes are inspected for binary detection (same as git).
+const binarySniffLen = 8000
+
+// Attachment is a file or command output appended to a prompt.
+type Attachment struct {
+	// Label shown above the fenced block (file path or command)
+	Label string
+
+	// Kind of attachment: "file" or "command"
+	Kind string
+
+	// Language hint for the fence info string (may be empty)
+	Language string
+
+	// Attachment text
+	Content string
+
+	// Whether Content was cut down to the size limit
+	Truncated bool
+}
+
+// AttachOptions controls how attachments are collected.
+type AttachOptions struct {
+	// Maximum size of a single attachment in bytes (0 disables the limit)
+	MaxSize int64
+
+	// Apply .gitignore rules when expanding globs
+	RespectIgnore bool
+
+	// Include files that look binary instead of skipping them
+	AllowBinary bool
+}
+
+// ErrBinary is returned for attachments that look like binary data.
+var ErrBinary = errors.New("binary content")
+
+// CollectFiles expands the given patterns and loads each matching file once.
+// Binary files are skipped (and reported through skipped) unless AllowBinary is set.
+func CollectFiles(patterns []string, opts AttachOptions) (attachments []Attachment, skipped []string, err error) {
+	seen := make(map[string]bool)
+
+	for _, pattern := range patterns {
+		paths, err := ExpandGlob(pattern, opts.RespectIgnore)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(paths) == 0 {
+			return nil, nil, fmt.Errorf("no files match %s", pattern)
+		}
+
+		for _, path := range paths {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			attachment, err := LoadFile(path, opts)
+			if errors.Is(err, ErrBinary) {
+				skipped = append(skipped, path)
+				continue
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+
+			attachments = append(attachments, *attachment)
+		}
+	}
+
+	return attachments, skipped, nil
+}
+
+// LoadFile reads a single file as an attachment.
+func LoadFile(path string, opts AttachOptions) (*Attachment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attachment %s: %w", path, err)
+	}
+
+	if !opts.AllowBinary && isBinary(data) {
+		return nil, ErrBinary
+	}
+
+	content, truncated := truncateBytes(data, opts.MaxSize)
+
+	return &Attachment{
+		Label:     filepath.ToSlash(path),
+		Kind:      "file",
+		Language:  languageForPath(path),
+		Content:   content,
+		Truncated: truncated,
+	}, nil
+}
+
+// RunCommand runs a shell command and captures its stdout as an attachment.
+// The command's stderr is passed through to the given writer.
+func RunCommand(command string, stderr io.Writer, opts AttachOptions) (*Attachment, error) {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stderr = stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("attachment command %q failed: %w", command, err)
+	}
+
+	if !opts.AllowBinary && isBinary(output) {
+		return nil, fmt.Errorf("attachment command %q produced binary output", command)
+	}
+
+	content, truncated := truncateBytes(output, opts.MaxSize)
+
+	return &Attachment{
+		Label:     command,
+		Kind:      "command",
+		Language:  languageForCommand(command),
+		Content:   content,
+		Truncated: truncated,
+	}, nil
+}
+
+// WithAttachments appends each attachment to the content as a labelled fenced block.
+func WithAttachments(content string, attachments []Attachment) string {
+	if len(attachments) == 0 {
+		return content
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(content, "\n"))
+
+	for _, attachment := range attachments {
+		label := "File"
+		if attachment.Kind == "command" {
+			label = "Command"
+		}
+
+		body := strings.TrimRight(attachment.Content, "\n")
+		fence := fenceFor(body)
+
+		fmt.Fprintf(&b, "\n\n%s: %s\n", label, attachment.Label)
+		b.WriteString(fence + attachment.Language + "\n")
+		if body != "" {
+			b.WriteString(body + "\n")
+		}
+		if attachment.Truncated {
+			b.WriteString("[... truncated ...]\n")
+		}
+		b.WriteString(fence)
+	}
+
+	b.WriteString("\n")
+	return b.String()
+}
+
+// ParseSize parses sizes such as "512", "256KB", "1MB" or "2m" into bytes.
+func ParseSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	s = strings.TrimSuffix(s, "B")
+
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier = 1024
+		s = strings.TrimSuffix(s, "K")
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1024 * 1024
+		s = strings.TrimSuffix(s, "M")
+	case strings.HasSuffix(s, "G"):
+		multiplier = 1024 * 1024 * 1024
+		s = strings.TrimSuffix(s, "G")
+	}
+
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (examples: 512, 256KB, 1MB)", value)
+	}
+
+	return n * multiplier, nil
+}
+
+// isBinary reports whether data looks binary: a NUL byte in the leading bytes
+// (git's heuristic) or content that is not valid UTF-8.
+func isBinary(data []byte) bool {
+	sniff := data
+	if len(sniff) > binarySniffLen {
+		sniff = sniff[:binarySniffLen]
+	}
+
+	if bytes.IndexByte(sniff, 0) >= 0 {
+		return true
+	}
+
+	// Drop a multi-byte rune cut in half at the sniff boundary before validating
+	if len(data) > binarySniffLen {
+		for i := 0; i < utf8.UTFMax-1 && !utf8.Valid(sniff); i++ {
+			sniff = sniff[:len(sniff)-1]
+		}
+	}
+
+	return !utf8.Valid(sniff)
+}
+
+// truncateBytes cuts data to at most maxSize bytes without splitting a UTF-8 rune.
+func truncateBytes(data []byte, maxSize int64) (string, bool) {
+	if maxSize <= 0 || int64(len(data)) <= maxSize {
+		return string(data), false
+	}
+
+	cut := data[:maxSize]
+	for len(cut) > 0 && !utf8.Valid(cut) {
+		cut = cut[:len(cut)-1]
+	}
+
+	return string(cut), true
+}
+
+// fenceFor returns a backtick fence longer than any backtick run inside body.
+func fenceFor(body string) string {
+	longest, run := 0, 0
+	for _, r := range body {
+		if r == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+
+	if longest < 3 {
+		return "```"
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+// languageForPath maps a file extension to a fence language hint.
+func languageForPath(path string) string {
+	base := strings.ToLower(filepath.Base(path))
+	switch base {
+	case "dockerfile":
+		return "dockerfile"
+	case "makefile":
+		return "makefile"
+	}
+
+	languages := map[string]string{
+		".go": "go", ".py": "python", ".js": "javascript", ".jsx": "jsx",
+		".ts": "typescript", ".tsx": "tsx", ".rb": "ruby", ".rs": "rust",
+		".java": "java", ".kt": "kotlin", ".swift": "swift", ".c": "c",
+		".h": "c", ".cc": "cpp", ".cpp": "cpp", ".hpp": "cpp", ".cs": "csharp",
+		".php": "php", ".sh": "bash", ".bash": "bash", ".zsh": "zsh",
+		".sql": "sql", ".html": "html", ".css": "css", ".scss": "scss",
+		".json": "json", ".yaml": "yaml", ".yml": "yaml", ".toml": "toml",
+		".xml": "xml", ".md": "markdown", ".diff": "diff", ".patch": "diff",
+		".lua": "lua", ".ex": "elixir", ".exs": "elixir", ".scala": "scala",
+	}
+
+	return languages[strings.ToLower(filepath.Ext(path))]
+}
+
+// languageForCommand guesses a fence language hint from a command line.
+func languageForCommand(command string) string {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	switch {
+	case fields[0] == "diff":
+		return "diff"
+	case fields[0] == "git" && len(fields) > 1 && (fields[1] == "diff" || fields[1] == "show" || fields[1] == "format-patch"):
+		return "diff"
+	}
+
+	return ""
+}
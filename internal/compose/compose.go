@@ -0,0 +1,4 @@
+// Package compose assembles the document emitted by get from a prompt and the
+// extra material passed alongside it (piped input, attachments, included prompts,
+// stacked prompts).
+package compose
@@ -0,0 +1,225 @@
+package compose
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// hasGlobMeta reports whether a path contains glob metacharacters.
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// ExpandGlob expands an attachment pattern into a sorted list of file paths.
+// Patterns support *, ?, [...] within a path segment and ** across segments
+// (e.g. "src/**/*.go"). A plain directory expands to every file below it.
+// When respectIgnore is true, files and directories matched by .gitignore
+// (and .git/info/exclude) are skipped during expansion. Paths named explicitly
+// without any glob characters are always returned as-is.
+func ExpandGlob(pattern string, respectIgnore bool) ([]string, error) {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+
+	if !hasGlobMeta(pattern) {
+		info, err := os.Stat(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("attachment not found: %s", pattern)
+		}
+		if !info.IsDir() {
+			return []string{filepath.FromSlash(pattern)}, nil
+		}
+		// Directory: attach everything below it
+		pattern = strings.TrimSuffix(pattern, "/") + "/**"
+	}
+
+	// Split into the literal base directory and the glob part
+	segments := strings.Split(pattern, "/")
+	baseEnd := 0
+	for baseEnd < len(segments) && !hasGlobMeta(segments[baseEnd]) {
+		baseEnd++
+	}
+	base := strings.Join(segments[:baseEnd], "/")
+	if base == "" {
+		base = "."
+		if strings.HasPrefix(pattern, "/") {
+			base = "/"
+		}
+	}
+	globSegments := segments[baseEnd:]
+
+	var ignore *ignoreMatcher
+	if respectIgnore {
+		var err error
+		ignore, err = newIgnoreMatcher(base)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var matches []string
+	err := filepath.WalkDir(filepath.FromSlash(base), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, relErr := filepath.Rel(filepath.FromSlash(base), path)
+		if relErr != nil || rel == "." {
+			if d.IsDir() && ignore != nil {
+				ignore.enter(path)
+			}
+			return nil
+		}
+		relSegments := strings.Split(filepath.ToSlash(rel), "/")
+
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if ignore != nil {
+				if ignore.match(path, true) {
+					return filepath.SkipDir
+				}
+				ignore.enter(path)
+			}
+			return nil
+		}
+
+		if ignore != nil && ignore.match(path, false) {
+			return nil
+		}
+
+		if matchSegments(globSegments, relSegments) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand %s: %w", pattern, err)
+	}
+
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// matchSegments matches path segments against glob segments, where "**"
+// matches zero or more whole segments.
+func matchSegments(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+
+	if pattern[0] == "**" {
+		// Try consuming zero, one, two... path segments
+		for i := 0; i <= len(path); i++ {
+			if matchSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(path) == 0 {
+		return false
+	}
+
+	ok, err := filepath.Match(pattern[0], path[0])
+	if err != nil || !ok {
+		return false
+	}
+
+	return matchSegments(pattern[1:], path[1:])
+}
+
+// ignoreMatcher tracks .gitignore patterns for the directories visited during a walk.
+type ignoreMatcher struct {
+	root     string
+	patterns []gitignore.Pattern
+}
+
+// newIgnoreMatcher creates a matcher rooted at the enclosing git repository of dir.
+// Ignore files between the repository root and dir are loaded up front; files below
+// dir are loaded as the walk enters each directory. Outside a repository, dir itself
+// is used as the root.
+func newIgnoreMatcher(dir string) (*ignoreMatcher, error) {
+	absDir, err := filepath.Abs(filepath.FromSlash(dir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+
+	root := absDir
+	for candidate := absDir; ; candidate = filepath.Dir(candidate) {
+		if _, err := os.Stat(filepath.Join(candidate, ".git")); err == nil {
+			root = candidate
+			break
+		}
+		if filepath.Dir(candidate) == candidate {
+			break
+		}
+	}
+
+	m := &ignoreMatcher{root: root}
+	m.load(filepath.Join(root, ".git", "info", "exclude"), nil)
+
+	// Load ignore files of ancestor directories (root down to, but excluding, dir)
+	rel, _ := filepath.Rel(root, absDir)
+	current := root
+	if rel != "." {
+		for _, segment := range strings.Split(filepath.ToSlash(rel), "/") {
+			m.load(filepath.Join(current, ".gitignore"), m.domain(current))
+			current = filepath.Join(current, segment)
+		}
+	}
+
+	return m, nil
+}
+
+// enter loads the .gitignore of a directory reached during the walk.
+func (m *ignoreMatcher) enter(dir string) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return
+	}
+	m.load(filepath.Join(absDir, ".gitignore"), m.domain(absDir))
+}
+
+// match reports whether path is ignored.
+func (m *ignoreMatcher) match(path string, isDir bool) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	return gitignore.NewMatcher(m.patterns).Match(m.domain(absPath), isDir)
+}
+
+// domain converts an absolute path to its segments relative to the repository root.
+func (m *ignoreMatcher) domain(absPath string) []string {
+	rel, err := filepath.Rel(m.root, absPath)
+	if err != nil || rel == "." {
+		return nil
+	}
+	return strings.Split(filepath.ToSlash(rel), "/")
+}
+
+// load appends the patterns of a single ignore file. Missing files are ignored.
+func (m *ignoreMatcher) load(path string, domain []string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		m.patterns = append(m.patterns, gitignore.ParsePattern(line, domain))
+	}
+}
@@ -0,0 +1,100 @@
+package compose
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/whisller/pkit/pkg/models"
+)
+
+// DefaultSeparator is placed between stacked prompts.
+const DefaultSeparator = "\n\n"
+
+// includePattern matches include directives such as {{include "local:house-style"}}.
+var includePattern = regexp.MustCompile(`\{\{\s*include\s+"([^"]*)"\s*\}\}`)
+
+// Include is an include directive found in prompt content.
+type Include struct {
+	// Prompt ID or alias being included
+	Target string
+
+	// Byte offset of the directive within the content
+	Offset int
+
+	// Full directive text (e.g., {{include "local:house-style"}})
+	Directive string
+}
+
+// FindIncludes returns the include directives in content, in order of appearance.
+func FindIncludes(content string) []Include {
+	var includes []Include
+	for _, loc := range includePattern.FindAllStringSubmatchIndex(content, -1) {
+		includes = append(includes, Include{
+			Target:    content[loc[2]:loc[3]],
+			Offset:    loc[0],
+			Directive: content[loc[0]:loc[1]],
+		})
+	}
+	return includes
+}
+
+// ExpandIncludes replaces every include directive with the content returned by resolve.
+// Recursion and cycle detection are the caller's responsibility: resolve is expected to
+// return already-expanded content.
+func ExpandIncludes(content string, resolve func(target string) (string, error)) (string, error) {
+	var expandErr error
+
+	expanded := includePattern.ReplaceAllStringFunc(content, func(directive string) string {
+		if expandErr != nil {
+			return directive
+		}
+
+		target := includePattern.FindStringSubmatch(directive)[1]
+		included, err := resolve(target)
+		if err != nil {
+			expandErr = fmt.Errorf("failed to include %q: %w", target, err)
+			return directive
+		}
+
+		return strings.TrimRight(included, "\n")
+	})
+
+	if expandErr != nil {
+		return "", expandErr
+	}
+
+	return expanded, nil
+}
+
+// Stack concatenates several prompts into one synthetic prompt named name.
+// Contents are joined with separator; tags are merged without duplicates.
+func Stack(name string, prompts []*models.Prompt, separator string) *models.Prompt {
+	ids := make([]string, 0, len(prompts))
+	contents := make([]string, 0, len(prompts))
+	seenTags := make(map[string]bool)
+	var tags []string
+
+	for _, prompt := range prompts {
+		ids = append(ids, prompt.ID)
+		contents = append(contents, strings.TrimRight(prompt.Content, "\n"))
+
+		for _, tag := range prompt.Tags {
+			if !seenTags[tag] {
+				seenTags[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	return &models.Prompt{
+		ID:          name,
+		Name:        name,
+		Content:     strings.Join(contents, separator) + "\n",
+		Description: fmt.Sprintf("Composition of %s", strings.Join(ids, ", ")),
+		Tags:        tags,
+		Metadata: map[string]interface{}{
+			"compose": ids,
+		},
+	}
+}
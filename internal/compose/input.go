@@ -0,0 +1,53 @@
+package compose
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// InputPlaceholder marks the position in a prompt where input is substituted.
+const InputPlaceholder = "{{input}}"
+
+// DefaultDelimiter separates the prompt from appended input.
+const DefaultDelimiter = "\n\n---\n\n"
+
+// escapeReplacer expands the escape sequences accepted in delimiters given on the command line.
+var escapeReplacer = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\\`, `\`)
+
+// ReadInput reads the input document from a file.
+// A path of "-" reads from stdin instead.
+func ReadInput(path string, stdin io.Reader) (string, error) {
+	if path == "-" {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read stdin: %w", err)
+		}
+		return string(data), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// WithInput combines prompt content with an input document.
+// If the content contains InputPlaceholder, every occurrence is replaced with the input.
+// Otherwise the input is appended after the delimiter.
+func WithInput(content, input, delimiter string) string {
+	if strings.Contains(content, InputPlaceholder) {
+		return strings.ReplaceAll(content, InputPlaceholder, input)
+	}
+
+	return strings.TrimRight(content, "\n") + delimiter + input
+}
+
+// UnescapeDelimiter expands \n, \t and \\ escape sequences so delimiters
+// can be passed as plain shell arguments (e.g. --delimiter '\n\n===\n\n').
+func UnescapeDelimiter(delimiter string) string {
+	return escapeReplacer.Replace(delimiter)
+}
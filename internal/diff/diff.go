@@ -0,0 +1,241 @@
+// Package diff computes line- and word-level differences between prompt texts
+// using the linear-space variant of Myers' O(ND) algorithm, and renders them as
+// unified, word or side-by-side diffs.
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Op is the kind of an edit.
+type Op int
+
+const (
+	// Equal marks text present in both inputs
+	Equal Op = iota
+
+	// Delete marks text only present in the first input
+	Delete
+
+	// Insert marks text only present in the second input
+	Insert
+)
+
+// Edit is one element of an edit script.
+type Edit struct {
+	Op   Op
+	Text string
+}
+
+// maxTokens bounds the tokens of both inputs, after their common prefix and
+// suffix, that Diff compares token by token. Diffing takes time proportional
+// to their number times the number of edits, so longer inputs have their
+// differing middle replaced as a whole instead.
+const maxTokens = 20000
+
+// Diff returns a shortest edit script turning a into b, using the linear-space
+// variant of Myers' algorithm. When more than maxTokens tokens differ, the
+// script deletes the differing part of a and inserts that of b.
+func Diff(a, b []string) []Edit {
+	var d differ
+	d.diff(a, b)
+	return d.edits
+}
+
+// differ collects the edit script built by diff.
+type differ struct {
+	edits []Edit
+}
+
+// add appends an edit for each of texts.
+func (d *differ) add(op Op, texts []string) {
+	for _, text := range texts {
+		d.edits = append(d.edits, Edit{Op: op, Text: text})
+	}
+}
+
+// diff appends the edit script turning a into b.
+func (d *differ) diff(a, b []string) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	d.add(Equal, a[:prefix])
+	a, b = a[prefix:], b[prefix:]
+
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		d.add(Insert, b)
+	case len(b) == 0:
+		d.add(Delete, a)
+	case len(a)+len(b) > maxTokens:
+		d.add(Delete, a)
+		d.add(Insert, b)
+	default:
+		if x, y, ok := middleSnake(a, b); ok {
+			d.diff(a[:x], b[:y])
+			d.diff(a[x:], b[y:])
+		} else {
+			d.add(Delete, a)
+			d.add(Insert, b)
+		}
+	}
+
+	d.add(Equal, common)
+}
+
+// middleSnake finds a point (x, y) on a shortest edit path from a to b, by
+// searching from both ends at once until the paths meet, keeping only the
+// furthest x reached on each diagonal. a and b must not be empty and must
+// differ in their first and last tokens, so that the point splits the problem
+// into two smaller ones. It returns false when a and b have nothing in common.
+func middleSnake(a, b []string) (int, int, bool) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	length := 2*maxD + 2
+
+	// forward[offset+k] is the furthest x reached from the start on diagonal
+	// k = x - y, backward[offset+k] the furthest distance from the end on
+	// diagonal k of the reversed inputs; -1 where none was reached yet
+	forward := make([]int, length)
+	backward := make([]int, length)
+	for i := range forward {
+		forward[i] = -1
+		backward[i] = -1
+	}
+	forward[offset+1] = 0
+	backward[offset+1] = 0
+
+	// With an odd difference in length the paths meet on a forward step,
+	// otherwise on a backward one
+	delta := n - m
+	front := delta%2 != 0
+
+	// Diagonals that ran off the edit graph are skipped from then on
+	var startF, endF, startB, endB int
+	for d := 0; d < maxD; d++ {
+		for k := -d + startF; k <= d-endF; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1] // move down (insert)
+			} else {
+				x = forward[offset+k-1] + 1 // move right (delete)
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+
+			switch {
+			case x > n:
+				endF += 2
+			case y > m:
+				startF += 2
+			case front:
+				if kb := offset + delta - k; kb >= 0 && kb < length && backward[kb] != -1 && x >= n-backward[kb] {
+					return x, y, true
+				}
+			}
+		}
+
+		for k := -d + startB; k <= d-endB; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+
+			switch {
+			case x > n:
+				endB += 2
+			case y > m:
+				startB += 2
+			case !front:
+				if kf := offset + delta - k; kf >= 0 && kf < length && forward[kf] != -1 && forward[kf] >= n-x {
+					fx := forward[kf]
+					return fx, fx - (kf - offset), true
+				}
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// Lines diffs two texts line by line. Edit texts have no trailing newline.
+func Lines(a, b string) []Edit {
+	return Diff(splitLines(a), splitLines(b))
+}
+
+// Words diffs two texts word by word. Whitespace and punctuation are kept as
+// separate tokens, so concatenating edit texts reproduces the inputs.
+func Words(a, b string) []Edit {
+	return Diff(splitWords(a), splitWords(b))
+}
+
+// splitLines splits text into lines, ignoring a final newline.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// splitWords splits text into runs of word characters, runs of whitespace,
+// and single punctuation characters.
+func splitWords(text string) []string {
+	var tokens []string
+	start := 0
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		default:
+			return 3
+		}
+	}
+
+	prev := 0
+	for i, r := range text {
+		c := class(r)
+		if i > start && (c != prev || c == 3) {
+			tokens = append(tokens, text[start:i])
+			start = i
+		}
+		prev = c
+	}
+	if start < len(text) {
+		tokens = append(tokens, text[start:])
+	}
+	return tokens
+}
+
+// Changed reports whether an edit script contains any change.
+func Changed(edits []Edit) bool {
+	for _, e := range edits {
+		if e.Op != Equal {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package diff
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestDiffShortest(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tokens := func() []string {
+		s := make([]string, rng.Intn(30))
+		for i := range s {
+			s[i] = string(rune('a' + rng.Intn(4)))
+		}
+		return s
+	}
+
+	for n := 0; n < 2000; n++ {
+		a, b := tokens(), tokens()
+		edits := Diff(a, b)
+
+		var gotA, gotB []string
+		changes := 0
+		for _, e := range edits {
+			if e.Op != Insert {
+				gotA = append(gotA, e.Text)
+			}
+			if e.Op != Delete {
+				gotB = append(gotB, e.Text)
+			}
+			if e.Op != Equal {
+				changes++
+			}
+		}
+		if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+			t.Fatalf("Diff(%v, %v) = %v, does not turn a into b", a, b, edits)
+		}
+		if want := len(a) + len(b) - 2*lcs(a, b); changes != want {
+			t.Fatalf("Diff(%v, %v) has %d changes, want %d", a, b, changes, want)
+		}
+	}
+}
+
+func TestDiffLargeInput(t *testing.T) {
+	a := make([]string, maxTokens)
+	b := make([]string, maxTokens)
+	for i := range a {
+		a[i] = "a"
+		b[i] = "b"
+	}
+	a[0], b[0] = "same", "same"
+
+	edits := Diff(a, b)
+	if len(edits) != 1+2*(maxTokens-1) || edits[0].Op != Equal || edits[1].Op != Delete || edits[len(edits)-1].Op != Insert {
+		t.Errorf("Diff() of inputs over maxTokens did not replace their differing part")
+	}
+}
+
+// lcs returns the length of the longest common subsequence of a and b.
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	for i := range a {
+		cur := make([]int, len(b)+1)
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] > cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,249 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ANSI colour codes used when colour output is enabled.
+const (
+	colorReset = "\033[0m"
+	colorBold  = "\033[1m"
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorCyan  = "\033[36m"
+)
+
+// Hunk is a group of nearby line changes with surrounding context.
+type Hunk struct {
+	// 1-based first line and number of lines in each input
+	AStart, ALines int
+	BStart, BLines int
+
+	// Edits in the hunk, including context lines
+	Edits []Edit
+}
+
+// Hunks groups a line edit script into hunks with up to context unchanged lines
+// around each change.
+func Hunks(edits []Edit, context int) []Hunk {
+	if context < 0 {
+		context = 0
+	}
+
+	// Line positions before each edit
+	aPos := make([]int, len(edits)+1)
+	bPos := make([]int, len(edits)+1)
+	for i, e := range edits {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		if e.Op != Insert {
+			aPos[i+1]++
+		}
+		if e.Op != Delete {
+			bPos[i+1]++
+		}
+	}
+
+	var hunks []Hunk
+	i := 0
+	for i < len(edits) {
+		// Skip to the next change
+		for i < len(edits) && edits[i].Op == Equal {
+			i++
+		}
+		if i == len(edits) {
+			break
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+
+		// Extend over changes separated by short runs of unchanged lines
+		end := i
+		for {
+			for end < len(edits) && edits[end].Op != Equal {
+				end++
+			}
+			run := 0
+			for end+run < len(edits) && edits[end+run].Op == Equal {
+				run++
+			}
+			if end+run == len(edits) || run > 2*context {
+				if run > context {
+					run = context
+				}
+				end += run
+				break
+			}
+			end += run
+		}
+
+		hunk := Hunk{
+			AStart: aPos[start] + 1,
+			ALines: aPos[end] - aPos[start],
+			BStart: bPos[start] + 1,
+			BLines: bPos[end] - bPos[start],
+			Edits:  edits[start:end],
+		}
+		// Empty ranges point at the line before them
+		if hunk.ALines == 0 {
+			hunk.AStart--
+		}
+		if hunk.BLines == 0 {
+			hunk.BStart--
+		}
+
+		hunks = append(hunks, hunk)
+		i = end
+	}
+
+	return hunks
+}
+
+// WriteUnified writes a line edit script as a unified diff.
+func WriteUnified(w io.Writer, aName, bName string, edits []Edit, context int, color bool) error {
+	paint := painter(color)
+
+	if _, err := fmt.Fprintf(w, "%s\n%s\n", paint(colorBold, "--- "+aName), paint(colorBold, "+++ "+bName)); err != nil {
+		return err
+	}
+
+	for _, h := range Hunks(edits, context) {
+		header := fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.AStart, h.ALines, h.BStart, h.BLines)
+		if _, err := fmt.Fprintln(w, paint(colorCyan, header)); err != nil {
+			return err
+		}
+
+		for _, e := range h.Edits {
+			var line string
+			switch e.Op {
+			case Delete:
+				line = paint(colorRed, "-"+e.Text)
+			case Insert:
+				line = paint(colorGreen, "+"+e.Text)
+			default:
+				line = " " + e.Text
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// WriteWords writes a word edit script inline. Without colour, deletions are
+// marked [-like this-] and insertions {+like this+} (as in git --word-diff).
+func WriteWords(w io.Writer, edits []Edit, color bool) error {
+	var b strings.Builder
+	for i := 0; i < len(edits); {
+		op := edits[i].Op
+
+		// Merge consecutive edits of the same kind
+		var text strings.Builder
+		for i < len(edits) && edits[i].Op == op {
+			text.WriteString(edits[i].Text)
+			i++
+		}
+
+		switch {
+		case op == Equal:
+			b.WriteString(text.String())
+		case color && op == Delete:
+			b.WriteString(colorRed + text.String() + colorReset)
+		case color && op == Insert:
+			b.WriteString(colorGreen + text.String() + colorReset)
+		case op == Delete:
+			b.WriteString("[-" + text.String() + "-]")
+		default:
+			b.WriteString("{+" + text.String() + "+}")
+		}
+	}
+
+	out := b.String()
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	_, err := io.WriteString(w, out)
+	return err
+}
+
+// painter returns a function that wraps text in an ANSI colour when enabled.
+func painter(enabled bool) func(code, text string) string {
+	return func(code, text string) string {
+		if !enabled {
+			return text
+		}
+		return code + text + colorReset
+	}
+}
+
+// RowKind describes a row of a side-by-side diff.
+type RowKind string
+
+const (
+	RowEqual  RowKind = "equal"
+	RowChange RowKind = "change"
+	RowDelete RowKind = "delete"
+	RowInsert RowKind = "insert"
+)
+
+// Row is one line of a side-by-side diff. Line numbers are 0 when the side is empty.
+type Row struct {
+	Kind      RowKind
+	Left      string
+	Right     string
+	LeftLine  int
+	RightLine int
+}
+
+// SideBySide lays out a line edit script as rows, pairing deleted lines with
+// the inserted lines that replace them.
+func SideBySide(edits []Edit) []Row {
+	var rows []Row
+	leftLine, rightLine := 0, 0
+
+	for i := 0; i < len(edits); {
+		if edits[i].Op == Equal {
+			leftLine++
+			rightLine++
+			rows = append(rows, Row{Kind: RowEqual, Left: edits[i].Text, Right: edits[i].Text, LeftLine: leftLine, RightLine: rightLine})
+			i++
+			continue
+		}
+
+		// Collect a block of deletions followed by insertions
+		var deleted, inserted []string
+		for i < len(edits) && edits[i].Op == Delete {
+			deleted = append(deleted, edits[i].Text)
+			i++
+		}
+		for i < len(edits) && edits[i].Op == Insert {
+			inserted = append(inserted, edits[i].Text)
+			i++
+		}
+
+		for j := 0; j < len(deleted) || j < len(inserted); j++ {
+			row := Row{Kind: RowChange}
+			if j < len(deleted) {
+				leftLine++
+				row.Left, row.LeftLine = deleted[j], leftLine
+			} else {
+				row.Kind = RowInsert
+			}
+			if j < len(inserted) {
+				rightLine++
+				row.Right, row.RightLine = inserted[j], rightLine
+			} else {
+				row.Kind = RowDelete
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
@@ -18,6 +18,7 @@ type PromptJSON struct {
 	Author      string   `json:"author,omitempty"`
 	Version     string   `json:"version,omitempty"`
 	FilePath    string   `json:"file_path,omitempty"`
+	TokenCount  int      `json:"token_count,omitempty"`
 }
 
 // PrintPromptJSON outputs the prompt as formatted JSON.
@@ -32,6 +33,7 @@ func PrintPromptJSON(w io.Writer, prompt *models.Prompt) error {
 		Author:      prompt.Author,
 		Version:     prompt.Version,
 		FilePath:    prompt.FilePath,
+		TokenCount:  prompt.TokenCount,
 	}
 
 	encoder := json.NewEncoder(w)
@@ -0,0 +1,110 @@
+package display
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Chat request formats supported by PrintChatRequest.
+const (
+	FormatOpenAI    = "openai"
+	FormatAnthropic = "anthropic"
+	FormatMessages  = "messages"
+)
+
+// ErrNoUserMessage is returned for an Anthropic request without a user
+// message, which the messages API rejects.
+var ErrNoUserMessage = errors.New("an anthropic request needs a user message")
+
+// defaultAnthropicMaxTokens is used when no max_tokens is given (the Anthropic API requires it).
+const defaultAnthropicMaxTokens = 4096
+
+// ChatMessage is a single message of a chat request.
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatParams holds model parameters for a chat request.
+// Nil/zero values are omitted from the output.
+type ChatParams struct {
+	Model       string
+	Temperature *float64
+	TopP        *float64
+	MaxTokens   int
+}
+
+// openAIRequest is the body of an OpenAI chat completions request.
+type openAIRequest struct {
+	Model       string        `json:"model,omitempty"`
+	Messages    []ChatMessage `json:"messages"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	TopP        *float64      `json:"top_p,omitempty"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+}
+
+// anthropicRequest is the body of an Anthropic messages request.
+type anthropicRequest struct {
+	Model       string        `json:"model,omitempty"`
+	MaxTokens   int           `json:"max_tokens"`
+	System      string        `json:"system,omitempty"`
+	Messages    []ChatMessage `json:"messages"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	TopP        *float64      `json:"top_p,omitempty"`
+}
+
+// IsChatFormat reports whether format is one of the chat request formats.
+func IsChatFormat(format string) bool {
+	return format == FormatOpenAI || format == FormatAnthropic || format == FormatMessages
+}
+
+// PrintChatRequest outputs a request skeleton with the prompt as the system
+// message and user as the user message. An empty user message is left out;
+// the Anthropic format, which needs one, returns ErrNoUserMessage instead.
+func PrintChatRequest(w io.Writer, format, system, user string, params ChatParams) error {
+	var output interface{}
+
+	messages := []ChatMessage{{Role: "system", Content: system}}
+	if user != "" {
+		messages = append(messages, ChatMessage{Role: "user", Content: user})
+	}
+
+	switch format {
+	case FormatOpenAI:
+		output = openAIRequest{
+			Model:       params.Model,
+			Messages:    messages,
+			Temperature: params.Temperature,
+			TopP:        params.TopP,
+			MaxTokens:   params.MaxTokens,
+		}
+	case FormatAnthropic:
+		if user == "" {
+			return ErrNoUserMessage
+		}
+		maxTokens := params.MaxTokens
+		if maxTokens == 0 {
+			maxTokens = defaultAnthropicMaxTokens
+		}
+		output = anthropicRequest{
+			Model:     params.Model,
+			MaxTokens: maxTokens,
+			System:    system,
+			Messages: []ChatMessage{
+				{Role: "user", Content: user},
+			},
+			Temperature: params.Temperature,
+			TopP:        params.TopP,
+		}
+	case FormatMessages:
+		output = messages
+	default:
+		return fmt.Errorf("unknown chat format: %s", format)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
@@ -35,6 +35,10 @@ func PrintPromptWithMetadata(w io.Writer, prompt *models.Prompt) error {
 		_, _ = fmt.Fprintf(w, "Author: %s\n", prompt.Author)
 	}
 
+	if prompt.TokenCount > 0 {
+		_, _ = fmt.Fprintf(w, "Tokens: ~%d\n", prompt.TokenCount)
+	}
+
 	_, _ = fmt.Fprintln(w, "\n--- Content ---")
 	_, _ = fmt.Fprintln(w, prompt.Content)
 
@@ -0,0 +1,126 @@
+// Package embed computes and stores prompt embeddings for semantic search.
+//
+// Embeddings come from a provider command configured in config.yml: any local
+// program that reads a text on stdin and writes its vector to stdout, such as a
+// script calling a local model runner.
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// embedTimeout bounds a single provider run; local models can be slow to load.
+const embedTimeout = 2 * time.Minute
+
+// Provider computes embeddings by running a shell command.
+type Provider struct {
+	Command string
+}
+
+// NewProvider creates a provider running command through sh.
+func NewProvider(command string) *Provider {
+	return &Provider{Command: command}
+}
+
+// Embed runs the provider command with text on stdin and parses the vector it
+// writes to stdout.
+func (p *Provider) Embed(text string) ([]float32, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), embedTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", p.Command)
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("embedding command %q failed: %w: %s", p.Command, err, msg)
+		}
+		return nil, fmt.Errorf("embedding command %q failed: %w", p.Command, err)
+	}
+
+	vector, err := ParseVector(output)
+	if err != nil {
+		return nil, fmt.Errorf("embedding command %q: %w", p.Command, err)
+	}
+	return vector, nil
+}
+
+// ParseVector reads a vector written by a provider: a JSON array of numbers,
+// a JSON object with an "embedding" array or an "embeddings" array holding one
+// vector (as returned by common local model servers), or numbers separated by
+// whitespace or commas.
+func ParseVector(output []byte) ([]float32, error) {
+	output = bytes.TrimSpace(output)
+	if len(output) == 0 {
+		return nil, fmt.Errorf("no vector in output")
+	}
+
+	var vector []float32
+	switch output[0] {
+	case '[':
+		if err := json.Unmarshal(output, &vector); err != nil {
+			return nil, fmt.Errorf("invalid vector: %w", err)
+		}
+
+	case '{':
+		var response struct {
+			Embedding  []float32   `json:"embedding"`
+			Embeddings [][]float32 `json:"embeddings"`
+		}
+		if err := json.Unmarshal(output, &response); err != nil {
+			return nil, fmt.Errorf("invalid vector: %w", err)
+		}
+		vector = response.Embedding
+		if len(vector) == 0 && len(response.Embeddings) > 0 {
+			vector = response.Embeddings[0]
+		}
+
+	default:
+		fields := strings.FieldsFunc(string(output), func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		})
+		vector = make([]float32, 0, len(fields))
+		for _, field := range fields {
+			value, err := strconv.ParseFloat(field, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid vector value %q", field)
+			}
+			vector = append(vector, float32(value))
+		}
+	}
+
+	if len(vector) == 0 {
+		return nil, fmt.Errorf("no vector in output")
+	}
+	return vector, nil
+}
+
+// Cosine returns the cosine similarity of two vectors (-1 to 1), or 0 when
+// their lengths differ or either is all zeros.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,37 @@
+package embed
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseVector(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    []float32
+		wantErr bool
+	}{
+		{name: "json array", output: "[0.5, -1, 2e-1]\n", want: []float32{0.5, -1, 0.2}},
+		{name: "embedding object", output: `{"embedding": [1, 2]}`, want: []float32{1, 2}},
+		{name: "embeddings object", output: `{"model": "m", "embeddings": [[3, 4]]}`, want: []float32{3, 4}},
+		{name: "whitespace separated", output: "1 2\n3", want: []float32{1, 2, 3}},
+		{name: "comma separated", output: "1,2, 3", want: []float32{1, 2, 3}},
+		{name: "empty", output: "  \n", wantErr: true},
+		{name: "empty array", output: "[]", wantErr: true},
+		{name: "not a number", output: "1 two", wantErr: true},
+		{name: "object without vector", output: `{"error": "no model"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseVector([]byte(tt.output))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseVector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseVector() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package embed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/whisller/pkit/pkg/models"
+)
+
+// StoreFile is the name of the embeddings file, kept next to the search index.
+const StoreFile = "embeddings.json"
+
+// maxTextLength caps the runes of a prompt that are embedded; embedding models
+// only read so much and the start of a prompt says what it is for.
+const maxTextLength = 8000
+
+// Store holds prompt embeddings and the text hashes they were computed from,
+// so only prompts whose text changed are embedded again.
+type Store struct {
+	// Provider command the vectors came from; changing it invalidates them all
+	Command string `json:"command"`
+
+	// Embeddings by prompt ID
+	Entries map[string]Entry `json:"entries"`
+}
+
+// Entry is the embedding of one prompt.
+type Entry struct {
+	// SHA-256 of the embedded text
+	Hash string `json:"hash"`
+
+	Vector []float32 `json:"vector"`
+}
+
+// StorePath returns the embeddings file path for an index directory.
+func StorePath(indexBasePath string) string {
+	return filepath.Join(indexBasePath, StoreFile)
+}
+
+// LoadStore reads the embeddings file, returning an empty store when there is none.
+func LoadStore(path string) (*Store, error) {
+	store := &Store{Entries: make(map[string]Entry)}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embeddings: %w", err)
+	}
+
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("failed to parse embeddings %s: %w", path, err)
+	}
+	if store.Entries == nil {
+		store.Entries = make(map[string]Entry)
+	}
+	return store, nil
+}
+
+// Save writes the store, replacing the file atomically.
+func (s *Store) Save(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode embeddings: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write embeddings: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write embeddings: %w", err)
+	}
+	return nil
+}
+
+// Stale returns the prompts whose text changed since they were embedded by
+// provider, or that were never embedded.
+func (s *Store) Stale(prompts []models.Prompt, provider *Provider) []models.Prompt {
+	if s.Command != provider.Command {
+		return prompts
+	}
+
+	var stale []models.Prompt
+	for _, prompt := range prompts {
+		entry, ok := s.Entries[prompt.ID]
+		if !ok || entry.Hash != hashText(Text(prompt)) {
+			stale = append(stale, prompt)
+		}
+	}
+	return stale
+}
+
+// Update embeds the stale prompts with provider, calling progress after each.
+// Vectors from another provider command are dropped first. Entries already
+// embedded are kept when a later prompt fails, so a rerun picks up from there.
+func (s *Store) Update(prompts []models.Prompt, provider *Provider, progress func(done, total int)) error {
+	stale := s.Stale(prompts, provider)
+	if s.Command != provider.Command {
+		s.Command = provider.Command
+		s.Entries = make(map[string]Entry)
+	}
+
+	for n, prompt := range stale {
+		text := Text(prompt)
+		vector, err := provider.Embed(text)
+		if err != nil {
+			return fmt.Errorf("failed to embed %s: %w", prompt.ID, err)
+		}
+		s.Entries[prompt.ID] = Entry{Hash: hashText(text), Vector: vector}
+
+		if progress != nil {
+			progress(n+1, len(stale))
+		}
+	}
+	return nil
+}
+
+// Prune drops the embeddings of prompts not in keep.
+func (s *Store) Prune(keep map[string]bool) {
+	for id := range s.Entries {
+		if !keep[id] {
+			delete(s.Entries, id)
+		}
+	}
+}
+
+// Similarities returns the cosine similarity of vector to every stored prompt.
+func (s *Store) Similarities(vector []float32) map[string]float64 {
+	similarities := make(map[string]float64, len(s.Entries))
+	for id, entry := range s.Entries {
+		similarities[id] = Cosine(vector, entry.Vector)
+	}
+	return similarities
+}
+
+// Text is what gets embedded for a prompt: its name, description and content.
+func Text(prompt models.Prompt) string {
+	text := prompt.Name + "\n" + prompt.Description + "\n" + prompt.Content
+	if runes := []rune(text); len(runes) > maxTextLength {
+		text = string(runes[:maxTextLength])
+	}
+	return text
+}
+
+func hashText(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
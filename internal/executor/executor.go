@@ -0,0 +1,157 @@
+// Package executor runs execution tools (claude, llm, mods, fabric...) with a resolved prompt.
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/whisller/pkit/internal/compose"
+	"github.com/whisller/pkit/pkg/models"
+)
+
+// Placeholders recognised in executor argument templates.
+const (
+	placeholderPrompt     = "{{prompt}}"
+	placeholderPromptFile = "{{prompt_file}}"
+	placeholderArgs       = "{{args}}"
+)
+
+// detectionOrder is the order in which built-in executors are looked up in PATH
+// when neither --with nor default_executor is set.
+var detectionOrder = []string{"claude", "llm", "mods", "fabric"}
+
+// Executor is a named executor profile.
+type Executor struct {
+	Name   string
+	Config models.ExecutorConfig
+}
+
+// Profiles returns the built-in profiles merged with the ones defined in config.
+func Profiles(cfg *models.Config) map[string]models.ExecutorConfig {
+	profiles := models.DefaultExecutors()
+	for name, profile := range cfg.Executors {
+		profiles[name] = profile
+	}
+	return profiles
+}
+
+// Lookup returns the executor with the given name.
+// An empty name selects the configured default executor, or the first
+// built-in tool found in PATH.
+func Lookup(cfg *models.Config, name string) (*Executor, error) {
+	profiles := Profiles(cfg)
+
+	if name == "" {
+		name = cfg.DefaultExecutor
+	}
+
+	if name == "" {
+		for _, candidate := range detectionOrder {
+			if _, err := exec.LookPath(profiles[candidate].Command); err == nil {
+				name = candidate
+				break
+			}
+		}
+		if name == "" {
+			return nil, fmt.Errorf("no executor found in PATH (tried %s); use --with or set default_executor", strings.Join(detectionOrder, ", "))
+		}
+	}
+
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown executor '%s' (available: %s)", name, strings.Join(Names(cfg), ", "))
+	}
+
+	return &Executor{Name: name, Config: profile}, nil
+}
+
+// Names returns the sorted names of all available executors.
+func Names(cfg *models.Config) []string {
+	profiles := Profiles(cfg)
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Command builds the command for running prompt with the given input and extra args.
+// The returned cleanup function removes temporary files and must always be called.
+func (e *Executor) Command(ctx context.Context, prompt string, input io.Reader, extra []string) (*exec.Cmd, func(), error) {
+	cleanup := func() {}
+
+	var promptFile string
+	args := make([]string, 0, len(e.Config.Args)+len(extra))
+	argsPlaced := false
+
+	for _, arg := range e.Config.Args {
+		switch {
+		case arg == placeholderArgs:
+			args = append(args, extra...)
+			argsPlaced = true
+		case strings.Contains(arg, placeholderPromptFile):
+			if promptFile == "" {
+				f, err := os.CreateTemp("", "pkit-prompt-*.md")
+				if err != nil {
+					return nil, cleanup, fmt.Errorf("failed to create prompt file: %w", err)
+				}
+				promptFile = f.Name()
+				cleanup = func() { _ = os.Remove(promptFile) }
+
+				_, writeErr := f.WriteString(prompt)
+				closeErr := f.Close()
+				if writeErr != nil || closeErr != nil {
+					return nil, cleanup, fmt.Errorf("failed to write prompt file: %v", firstError(writeErr, closeErr))
+				}
+			}
+			args = append(args, strings.ReplaceAll(arg, placeholderPromptFile, promptFile))
+		default:
+			args = append(args, strings.ReplaceAll(arg, placeholderPrompt, prompt))
+		}
+	}
+
+	if !argsPlaced {
+		args = append(args, extra...)
+	}
+
+	cmd := exec.CommandContext(ctx, e.Config.Command, args...)
+
+	switch e.Config.Stdin {
+	case "", "input":
+		cmd.Stdin = input
+	case "prompt":
+		cmd.Stdin = strings.NewReader(prompt)
+	case "combined":
+		var data []byte
+		if input != nil {
+			var err error
+			data, err = io.ReadAll(input)
+			if err != nil {
+				return nil, cleanup, fmt.Errorf("failed to read input: %w", err)
+			}
+		}
+		cmd.Stdin = strings.NewReader(compose.WithInput(prompt, string(data), compose.DefaultDelimiter))
+	case "none":
+		cmd.Stdin = nil
+	default:
+		return nil, cleanup, fmt.Errorf("executor '%s': invalid stdin mode '%s'", e.Name, e.Config.Stdin)
+	}
+
+	return cmd, cleanup, nil
+}
+
+// firstError returns the first non-nil error.
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
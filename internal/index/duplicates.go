@@ -0,0 +1,154 @@
+package index
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/whisller/pkit/internal/similarity"
+	"github.com/whisller/pkit/pkg/models"
+)
+
+// DuplicateCluster is a group of near-identical prompts.
+type DuplicateCluster struct {
+	// Prompts in the cluster
+	Prompts []models.Prompt
+
+	// Lowest estimated similarity between a prompt and the first one (0-1)
+	Similarity float64
+}
+
+// DuplicateClusters groups all indexed prompts into clusters of near-duplicates
+// (estimated similarity >= threshold), largest clusters first.
+func (i *Indexer) DuplicateClusters(threshold float64) ([]DuplicateCluster, error) {
+	if i.remote != nil {
+		var clusters []DuplicateCluster
+		err := i.remote.call("duplicate-clusters", duplicatesRequest{Threshold: threshold}, &clusters)
+		return clusters, err
+	}
+
+	count, err := i.docCount()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count documents: %w", err)
+	}
+
+	searchReq := bleve.NewSearchRequest(bleve.NewMatchAllQuery())
+	searchReq.Size = int(count)
+	searchReq.Fields = resultFields
+	searchReq.SortBy([]string{"_id"})
+
+	searchResults, err := i.searchAll(searchReq)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+
+	prompts := make([]models.Prompt, 0, len(searchResults.Hits))
+	fingerprints := make([]string, 0, len(searchResults.Hits))
+	for _, hit := range searchResults.Hits {
+		prompt, err := i.hitToPrompt(hit)
+		if err != nil {
+			continue
+		}
+		prompts = append(prompts, prompt)
+		fingerprints = append(fingerprints, prompt.Fingerprint)
+	}
+
+	var clusters []DuplicateCluster
+	for _, members := range similarity.Cluster(fingerprints, threshold) {
+		cluster := DuplicateCluster{Similarity: 1}
+		first, _ := similarity.Parse(fingerprints[members[0]])
+		for _, idx := range members {
+			cluster.Prompts = append(cluster.Prompts, prompts[idx])
+			sig, _ := similarity.Parse(fingerprints[idx])
+			if s := first.Similarity(sig); s < cluster.Similarity {
+				cluster.Similarity = s
+			}
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	return clusters, nil
+}
+
+// DuplicatesOf returns the near-duplicates of a prompt, most similar first.
+// Candidates are looked up through the indexed LSH bands; Score is the estimated similarity.
+func (i *Indexer) DuplicatesOf(promptID string, threshold float64) ([]SearchResult, error) {
+	if i.remote != nil {
+		var results []SearchResult
+		err := i.remote.call("duplicates-of", duplicatesRequest{ID: promptID, Threshold: threshold}, &results)
+		return results, err
+	}
+
+	prompt, err := i.GetPromptByID(promptID)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := similarity.Parse(prompt.Fingerprint)
+	if err != nil {
+		return nil, fmt.Errorf("prompt %s has no fingerprint (run 'pkit reindex')", promptID)
+	}
+
+	bands := sig.Bands()
+	bandQueries := make([]query.Query, 0, len(bands))
+	for _, band := range bands {
+		q := bleve.NewTermQuery(band)
+		q.SetField("minhash_bands")
+		bandQueries = append(bandQueries, q)
+	}
+
+	searchReq := bleve.NewSearchRequest(bleve.NewDisjunctionQuery(bandQueries...))
+	searchReq.Size = 1000
+	searchReq.Fields = resultFields
+
+	searchResults, err := i.searchAll(searchReq)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+
+	var results []SearchResult
+	for _, hit := range searchResults.Hits {
+		if hit.ID == promptID {
+			continue
+		}
+		candidate, err := i.hitToPrompt(hit)
+		if err != nil {
+			continue
+		}
+		other, err := similarity.Parse(candidate.Fingerprint)
+		if err != nil {
+			continue
+		}
+		if s := sig.Similarity(other); s >= threshold {
+			results = append(results, SearchResult{Prompt: candidate, Score: s})
+		}
+	}
+
+	sort.SliceStable(results, func(a, b int) bool {
+		return results[a].Score > results[b].Score
+	})
+
+	return results, nil
+}
+
+// CollapseDuplicates keeps the best-ranked result of every group of near-duplicates,
+// recording the IDs of the others in its Duplicates field. Order is preserved.
+func CollapseDuplicates(results []SearchResult, threshold float64) []SearchResult {
+	fingerprints := make([]string, len(results))
+	for idx, r := range results {
+		fingerprints[idx] = r.Prompt.Fingerprint
+	}
+
+	keep, duplicates := similarity.Collapse(fingerprints, threshold)
+
+	collapsed := make([]SearchResult, 0, len(keep))
+	for _, idx := range keep {
+		result := results[idx]
+		for _, d := range duplicates[idx] {
+			result.Duplicates = append(result.Duplicates, results[d].Prompt.ID)
+		}
+		collapsed = append(collapsed, result)
+	}
+	return collapsed
+}
@@ -0,0 +1,106 @@
+package index
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2/registry"
+	"github.com/blevesearch/bleve/v2/search/highlight"
+	htmlFormatter "github.com/blevesearch/bleve/v2/search/highlight/format/html"
+	simpleFragmenter "github.com/blevesearch/bleve/v2/search/highlight/fragmenter/simple"
+	simpleHighlighter "github.com/blevesearch/bleve/v2/search/highlight/highlighter/simple"
+)
+
+// Markers around matched terms in SearchResult.Highlights fragments.
+const (
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+)
+
+// snippetHighlighter is bleve's html highlighter with fragments short enough
+// to show in a table cell or list line.
+const snippetHighlighter = "pkit-snippet"
+
+// snippetSize is the fragment length in characters.
+const snippetSize = 100
+
+func init() {
+	err := registry.RegisterHighlighter(snippetHighlighter, func(config map[string]interface{}, cache *registry.Cache) (highlight.Highlighter, error) {
+		return simpleHighlighter.NewHighlighter(
+			simpleFragmenter.NewFragmenter(snippetSize),
+			htmlFormatter.NewFragmentFormatter(HighlightStart, HighlightEnd),
+			simpleHighlighter.DefaultSeparator,
+		), nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+var spaceRun = regexp.MustCompile(`\s+`)
+
+// FormatFragment turns a highlighted fragment into plain text on one line,
+// passing each matched term through mark. A nil mark leaves matches unmarked.
+func FormatFragment(fragment string, mark func(string) string) string {
+	var b strings.Builder
+	rest := fragment
+	for {
+		start := strings.Index(rest, HighlightStart)
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], HighlightEnd)
+		if end < 0 {
+			break
+		}
+		end += start
+
+		b.WriteString(plainText(rest[:start]))
+		matched := plainText(rest[start+len(HighlightStart) : end])
+		if mark != nil {
+			matched = mark(matched)
+		}
+		b.WriteString(matched)
+
+		rest = rest[end+len(HighlightEnd):]
+	}
+	b.WriteString(plainText(rest))
+
+	return strings.TrimSpace(b.String())
+}
+
+// plainText unescapes fragment text and collapses whitespace runs, including
+// newlines, into single spaces.
+func plainText(s string) string {
+	return spaceRun.ReplaceAllString(html.UnescapeString(s), " ")
+}
+
+// matchedFragments drops the fragments the highlighter returns for fields that
+// didn't match, which hold no highlighted terms.
+func matchedFragments(fragments map[string][]string) map[string][]string {
+	var matched map[string][]string
+	for field, values := range fragments {
+		for _, fragment := range values {
+			if !strings.Contains(fragment, HighlightStart) {
+				continue
+			}
+			if matched == nil {
+				matched = make(map[string][]string)
+			}
+			matched[field] = append(matched[field], fragment)
+		}
+	}
+	return matched
+}
+
+// BestFragment returns the first matched fragment of the first field in fields
+// that has one, or "" when none of them matched.
+func (r SearchResult) BestFragment(fields ...string) string {
+	for _, field := range fields {
+		if fragments := r.Highlights[field]; len(fragments) > 0 {
+			return fragments[0]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,60 @@
+package index
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatFragment(t *testing.T) {
+	upper := strings.ToUpper
+
+	tests := []struct {
+		name     string
+		fragment string
+		mark     func(string) string
+		want     string
+	}{
+		{
+			name:     "no matches",
+			fragment: "plain text",
+			want:     "plain text",
+		},
+		{
+			name:     "unmarked matches",
+			fragment: "a <mark>code</mark> review",
+			want:     "a code review",
+		},
+		{
+			name:     "marked matches",
+			fragment: "<mark>code</mark> and <mark>review</mark>",
+			mark:     upper,
+			want:     "CODE and REVIEW",
+		},
+		{
+			name:     "escaped html",
+			fragment: "&lt;b&gt; &amp; <mark>tags</mark>",
+			mark:     upper,
+			want:     "<b> & TAGS",
+		},
+		{
+			name:     "newlines collapsed",
+			fragment: "…first line\n\n  <mark>second</mark>\tline…",
+			want:     "…first line second line…",
+		},
+		{
+			name:     "unterminated marker",
+			fragment: "a <mark>b",
+			mark:     upper,
+			want:     "a <mark>b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FormatFragment(tt.fragment, tt.mark)
+			if got != tt.want {
+				t.Errorf("FormatFragment(%q) = %q, want %q", tt.fragment, got, tt.want)
+			}
+		})
+	}
+}
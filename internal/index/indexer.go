@@ -1,55 +1,204 @@
 package index
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/single"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
 	"github.com/blevesearch/bleve/v2/mapping"
+	indexapi "github.com/blevesearch/bleve_index_api"
+	"github.com/whisller/pkit/internal/parser"
+	"github.com/whisller/pkit/internal/similarity"
+	"github.com/whisller/pkit/internal/tokens"
 	"github.com/whisller/pkit/pkg/models"
 )
 
-// Indexer handles creating and maintaining the bleve search index.
+// Indexer handles creating and maintaining the bleve search index: an index
+// per source, searched together through an alias.
 type Indexer struct {
-	index bleve.Index
+	index bleve.IndexAlias
 	path  string
+
+	// Index of each source by source ID, guarded by mu
+	sources map[string]bleve.Index
+	mu      sync.RWMutex
+
+	// Empty index in the alias while no source has an index
+	empty bleve.Index
+
+	// User metadata written with prompts indexed by IndexPrompt and IndexPrompts
+	userMeta map[string]models.UserMetadata
+
+	// Set when another process serves the index; calls are forwarded to it
+	remote *remoteIndex
+
+	// Set for indexers opened by NewReader without a serving process
+	readOnly bool
+}
+
+// ErrReadOnly is returned when writing through an indexer opened read-only.
+var ErrReadOnly = errors.New("index is open read-only")
+
+// document is what gets indexed for a prompt: the prompt itself plus fields
+// that only exist for searching.
+type document struct {
+	models.Prompt
+	models.UserMetadata
+
+	// LSH band keys of the fingerprint, to look up near-duplicate candidates
+	MinHashBands []string `json:"minhash_bands,omitempty"`
+}
+
+// lockTimeout bounds waiting for another process to release the index, such
+// as 'pkit upgrade' writing to it.
+const lockTimeout = 30 * time.Second
+
+// Changed, when set, is called after prompts were indexed or deleted by this
+// process, with the prompts indexed and the IDs deleted. It lets caches derived
+// from the index (such as the resolve cache of 'pkit get') follow it. Calls are
+// never concurrent.
+var Changed func(indexPath string, prompts []models.Prompt, deletedIDs []string)
+
+// changedMu serializes calls to Changed by sources indexed in parallel.
+var changedMu sync.Mutex
+
+// notifyChanged calls Changed when it is set.
+func notifyChanged(indexPath string, prompts []models.Prompt, deletedIDs []string) {
+	if Changed == nil {
+		return
+	}
+	changedMu.Lock()
+	defer changedMu.Unlock()
+	Changed(indexPath, prompts, deletedIDs)
 }
 
 // NewIndexer creates a new indexer instance.
 // If the index doesn't exist, it creates a new one.
-// If it exists, it opens the existing index.
+// If it exists, it opens the existing index. An index built with another schema
+// version is rebuilt by Rebuild first, or reported as a *SchemaError when
+// Rebuild is not set. When another process serves the index (see ServeSocket),
+// the indexer forwards its calls there instead of opening it.
 func NewIndexer(indexPath string) (*Indexer, error) {
-	var index bleve.Index
+	if remote := connect(indexPath); remote != nil {
+		return &Indexer{path: indexPath, remote: remote}, nil
+	}
+	return openWithRebuild(indexPath, OpenOrCreate)
+}
 
-	var err error
+// NewReader opens an index for searching like NewIndexer, but read-only when
+// no process serves it, so that any number of readers can have it open at
+// once. Writing through a read-only indexer fails with ErrReadOnly.
+func NewReader(indexPath string) (*Indexer, error) {
+	if remote := connect(indexPath); remote != nil {
+		return &Indexer{path: indexPath, remote: remote}, nil
+	}
+	return openWithRebuild(indexPath, openReadOnly)
+}
 
-	// Check if index already exists
-	if _, statErr := os.Stat(indexPath); os.IsNotExist(statErr) {
-		// Create new index
-		indexMapping := buildIndexMapping()
-		index, err = bleve.New(indexPath, indexMapping)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create index: %w", err)
-		}
-	} else {
-		// Open existing index
-		index, err = bleve.Open(indexPath)
-		if err != nil {
-			return nil, fmt.Errorf("failed to open index: %w", err)
+// openWithRebuild opens an index with open, rebuilding it first when it was
+// built with another schema version and Rebuild is set.
+func openWithRebuild(indexPath string, open func(string) (*Indexer, error)) (*Indexer, error) {
+	indexer, err := open(indexPath)
+
+	var schemaErr *SchemaError
+	if errors.As(err, &schemaErr) && Rebuild != nil {
+		if err := Rebuild(indexPath, schemaErr); err != nil {
+			return nil, err
 		}
+		return open(indexPath)
 	}
 
-	return &Indexer{
-		index: index,
-		path:  indexPath,
-	}, nil
+	return indexer, err
+}
+
+// OpenOrCreate opens or creates an index like NewIndexer, but always in this
+// process and without rebuilding it: an index built with another schema
+// version is reported as a *SchemaError.
+func OpenOrCreate(indexPath string) (*Indexer, error) {
+	return openIndex(indexPath, map[string]interface{}{
+		"bolt_timeout": lockTimeout.String(),
+	}, false)
 }
 
+// openReadOnly opens an existing index read-only, creating it when missing.
+func openReadOnly(indexPath string) (*Indexer, error) {
+	return openIndex(indexPath, map[string]interface{}{
+		"read_only":    true,
+		"bolt_timeout": lockTimeout.String(),
+	}, true)
+}
+
+// OpenIndexer opens an existing index like NewIndexer, but gives up after
+// timeout when another process holds the index open without serving it. It
+// never rebuilds the index.
+func OpenIndexer(indexPath string, timeout time.Duration) (*Indexer, error) {
+	if remote := connect(indexPath); remote != nil {
+		return &Indexer{path: indexPath, remote: remote}, nil
+	}
+
+	if _, err := os.Stat(indexPath); err != nil {
+		return nil, fmt.Errorf("failed to open index: %w", err)
+	}
+	return openIndex(indexPath, map[string]interface{}{
+		"bolt_timeout": timeout.String(),
+	}, false)
+}
+
+// sortAnalyzer is the analyzer of fields used only for sorting.
+const sortAnalyzer = "sort"
+
 // buildIndexMapping creates the bleve index mapping with field boosting.
-func buildIndexMapping() mapping.IndexMapping {
+// Changes to it must bump SchemaVersion.
+func buildIndexMapping() (mapping.IndexMapping, error) {
 	indexMapping := bleve.NewIndexMapping()
 
+	// BM25 term statistics are combined across the indexes of all sources, so
+	// scores compare between sources (see Indexer.search)
+	indexMapping.ScoringModel = indexapi.BM25Scoring
+
+	// Keeps a field as one lowercased term, so it sorts case-insensitively
+	if err := indexMapping.AddCustomAnalyzer(sortAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     single.Name,
+		"token_filters": []string{lowercase.Name},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to add %s analyzer: %w", sortAnalyzer, err)
+	}
+
+	// Stems words like the en analyzer but keeps their case, for case-sensitive search
+	if err := indexMapping.AddCustomAnalyzer(exactAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{en.PossessiveName, en.SnowballStemmerName},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to add %s analyzer: %w", exactAnalyzer, err)
+	}
+
+	// Text fields are analyzed in the language of each prompt, which picks
+	// the document mapping (see document.BleveType)
+	indexMapping.DefaultMapping = promptMapping(queryAnalyzer)
+	for _, analyzer := range textAnalyzers() {
+		if analyzer != queryAnalyzer {
+			indexMapping.AddDocumentMapping(analyzer, promptMapping(analyzer))
+		}
+	}
+
+	return indexMapping, nil
+}
+
+// promptMapping is the document mapping of prompts whose text fields are
+// analyzed with analyzer.
+func promptMapping(analyzer string) *mapping.DocumentMapping {
 	// Document mapping for Prompt
 	docMapping := bleve.NewDocumentMapping()
 
@@ -67,17 +216,25 @@ func buildIndexMapping() mapping.IndexMapping {
 
 	// Name field (text, stored)
 	nameField := bleve.NewTextFieldMapping()
-	nameField.Analyzer = "en"
+	nameField.Analyzer = analyzer
 	nameField.Store = true
 	nameField.IncludeInAll = true
-	docMapping.AddFieldMappingsAt("name", nameField)
+
+	// Whole lowercased name, for sorting by name
+	nameSortField := bleve.NewTextFieldMapping()
+	nameSortField.Name = "name_sort"
+	nameSortField.Analyzer = sortAnalyzer
+	nameSortField.Store = false
+	nameSortField.IncludeInAll = false
+	nameSortField.IncludeTermVectors = false
+	docMapping.AddFieldMappingsAt("name", nameField, nameSortField, exactFieldMapping("name"))
 
 	// Description field (text, stored)
 	descField := bleve.NewTextFieldMapping()
-	descField.Analyzer = "en"
+	descField.Analyzer = analyzer
 	descField.Store = true
 	descField.IncludeInAll = true
-	docMapping.AddFieldMappingsAt("description", descField)
+	docMapping.AddFieldMappingsAt("description", descField, exactFieldMapping("description"))
 
 	// Tags field (keyword, stored, faceted)
 	tagsField := bleve.NewTextFieldMapping()
@@ -86,12 +243,14 @@ func buildIndexMapping() mapping.IndexMapping {
 	docMapping.AddFieldMappingsAt("tags", tagsField)
 
 	// Content field (text)
-	// IMPORTANT: Store=false to save space. Content is loaded dynamically from source files when needed.
+	// Stored with term vectors only so matches can be highlighted. Search results
+	// don't return it - content is loaded dynamically from source files when needed.
 	contentField := bleve.NewTextFieldMapping()
-	contentField.Analyzer = "en"
-	contentField.Store = false // Do NOT store content - load dynamically from source files
+	contentField.Analyzer = analyzer
+	contentField.Store = true
+	contentField.IncludeTermVectors = true
 	contentField.IncludeInAll = true
-	docMapping.AddFieldMappingsAt("content", contentField)
+	docMapping.AddFieldMappingsAt("content", contentField, exactFieldMapping("content"))
 
 	// FilePath field (keyword, stored)
 	filePathField := bleve.NewTextFieldMapping()
@@ -105,76 +264,156 @@ func buildIndexMapping() mapping.IndexMapping {
 	authorField.Store = true
 	docMapping.AddFieldMappingsAt("author", authorField)
 
-	indexMapping.DefaultMapping = docMapping
+	// Language field (keyword, stored, for lang: queries and analyzing like the prompt)
+	languageField := bleve.NewTextFieldMapping()
+	languageField.Analyzer = "keyword"
+	languageField.Store = true
+	languageField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("language", languageField)
+
+	// TokenCount field (numeric, stored, used for size filtering)
+	tokenCountField := bleve.NewNumericFieldMapping()
+	tokenCountField.Store = true
+	tokenCountField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("token_count", tokenCountField)
+
+	// Fingerprint field (stored only, MinHash signature)
+	fingerprintField := bleve.NewTextFieldMapping()
+	fingerprintField.Analyzer = "keyword"
+	fingerprintField.Store = true
+	fingerprintField.Index = false
+	fingerprintField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("fingerprint", fingerprintField)
+
+	// MinHash band keys (keyword, indexed only)
+	bandsField := bleve.NewTextFieldMapping()
+	bandsField.Analyzer = "keyword"
+	bandsField.Store = false
+	bandsField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("minhash_bands", bandsField)
+
+	// User tags (keyword, stored, from tags.yml)
+	userTagsField := bleve.NewTextFieldMapping()
+	userTagsField.Analyzer = "keyword"
+	userTagsField.Store = true
+	userTagsField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("user_tags", userTagsField)
+
+	// Bookmarked flag (boolean, stored)
+	bookmarkedField := bleve.NewBooleanFieldMapping()
+	bookmarkedField.Store = true
+	bookmarkedField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("bookmarked", bookmarkedField)
+
+	// Alias names (text, stored, so searching "review" finds the prompt aliased review)
+	aliasesField := bleve.NewTextFieldMapping()
+	aliasesField.Analyzer = analyzer
+	aliasesField.Store = true
+	aliasesField.IncludeInAll = true
+	docMapping.AddFieldMappingsAt("aliases", aliasesField, exactFieldMapping("aliases"))
+
+	// Bookmark notes (text, stored)
+	notesField := bleve.NewTextFieldMapping()
+	notesField.Analyzer = analyzer
+	notesField.Store = true
+	notesField.IncludeInAll = true
+	docMapping.AddFieldMappingsAt("notes", notesField, exactFieldMapping("notes"))
+
+	// Bookmark usage (numeric and datetime, stored)
+	usageCountField := bleve.NewNumericFieldMapping()
+	usageCountField.Store = true
+	usageCountField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("usage_count", usageCountField)
+
+	lastUsedField := bleve.NewDateTimeFieldMapping()
+	lastUsedField.Store = true
+	lastUsedField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("last_used_at", lastUsedField)
+
+	// Source file modification time (datetime, stored, for sorting by update)
+	updatedAtField := bleve.NewDateTimeFieldMapping()
+	updatedAtField.Store = true
+	updatedAtField.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("updated_at", updatedAtField)
+
+	return docMapping
+}
+
+// exactFieldMapping is the case-preserving copy of a text field, checked by
+// case-sensitive searches. Term vectors are kept for phrase matching.
+func exactFieldMapping(name string) *mapping.FieldMapping {
+	field := bleve.NewTextFieldMapping()
+	field.Name = name + exactSuffix
+	field.Analyzer = exactAnalyzer
+	field.Store = false
+	field.IncludeInAll = false
+	return field
+}
 
-	return indexMapping
+// SetUserMetadata sets the user metadata (by prompt ID) written with prompts
+// indexed afterwards by IndexPrompt and IndexPrompts.
+func (i *Indexer) SetUserMetadata(meta map[string]models.UserMetadata) {
+	i.userMeta = meta
 }
 
 // IndexPrompt indexes a single prompt.
 func (i *Indexer) IndexPrompt(prompt models.Prompt) error {
-	return i.index.Index(prompt.ID, prompt)
+	return i.applyChanges([]models.Prompt{prompt}, nil, i.userMeta)
+}
+
+// IndexPromptWithMetadata indexes a prompt with the given user metadata,
+// replacing the prompt's previous document.
+func (i *Indexer) IndexPromptWithMetadata(prompt models.Prompt, meta models.UserMetadata) error {
+	return i.applyChanges([]models.Prompt{prompt}, nil, map[string]models.UserMetadata{prompt.ID: meta})
 }
 
 // IndexPrompts indexes multiple prompts in a batch.
-// Uses batch operations for better performance.
 func (i *Indexer) IndexPrompts(prompts []models.Prompt) error {
-	batch := i.index.NewBatch()
-	batchSize := 50 // Commit every 50 prompts
+	return i.applyChanges(prompts, nil, i.userMeta)
+}
 
-	for idx, prompt := range prompts {
-		if err := batch.Index(prompt.ID, prompt); err != nil {
-			return fmt.Errorf("failed to add prompt %s to batch: %w", prompt.ID, err)
+// newDocument builds the indexed document for a prompt and its user metadata,
+// filling in the fields derived from its content (token count, similarity fingerprint).
+func newDocument(prompt models.Prompt, meta models.UserMetadata) document {
+	if prompt.Content != "" {
+		if prompt.TokenCount == 0 {
+			prompt.TokenCount = tokens.Count(prompt.Content)
 		}
-
-		// Commit batch when it reaches the batch size
-		if (idx+1)%batchSize == 0 {
-			if err := i.index.Batch(batch); err != nil {
-				return fmt.Errorf("failed to commit batch: %w", err)
-			}
-			batch = i.index.NewBatch()
+		if prompt.Fingerprint == "" {
+			prompt.Fingerprint = similarity.Fingerprint(parser.StripFrontMatter(prompt.Content))
 		}
 	}
 
-	// Commit remaining prompts
-	if batch.Size() > 0 {
-		if err := i.index.Batch(batch); err != nil {
-			return fmt.Errorf("failed to commit final batch: %w", err)
-		}
+	return document{
+		Prompt:       prompt,
+		UserMetadata: meta,
+		MinHashBands: similarity.FingerprintBands(prompt.Fingerprint),
 	}
-
-	return nil
 }
 
 // DeletePrompt removes a prompt from the index.
 func (i *Indexer) DeletePrompt(promptID string) error {
-	return i.index.Delete(promptID)
+	return i.applyChanges(nil, []string{promptID}, nil)
 }
 
-// DeletePromptsBySource removes all prompts from a specific source.
-// Useful for re-indexing after source updates.
+// DeletePromptsBySource removes all prompts from a specific source by deleting
+// the source's index.
 func (i *Indexer) DeletePromptsBySource(sourceID string) error {
-	// Query for all prompts from this source
-	query := bleve.NewMatchQuery(sourceID)
-	query.SetField("source_id")
-
-	search := bleve.NewSearchRequest(query)
-	search.Size = 10000 // Large batch
-
-	results, err := i.index.Search(search)
-	if err != nil {
-		return fmt.Errorf("failed to search for source prompts: %w", err)
+	if i.remote != nil {
+		return i.remote.call("delete-source", changesRequest{SourceID: sourceID}, nil)
 	}
-
-	// Delete each prompt
-	batch := i.index.NewBatch()
-	for _, hit := range results.Hits {
-		batch.Delete(hit.ID)
+	if i.readOnly {
+		return ErrReadOnly
 	}
 
-	if err := i.index.Batch(batch); err != nil {
+	ids, err := i.dropSource(sourceID)
+	if err != nil {
 		return fmt.Errorf("failed to delete source prompts: %w", err)
 	}
 
+	if len(ids) > 0 {
+		notifyChanged(i.path, nil, ids)
+	}
 	return nil
 }
 
@@ -183,35 +422,185 @@ func (i *Indexer) DeleteBySource(sourceID string) error {
 	return i.DeletePromptsBySource(sourceID)
 }
 
-// ReindexSource re-indexes all prompts from a source.
-// Deletes old prompts and indexes new ones.
-func (i *Indexer) ReindexSource(sourceID string, prompts []models.Prompt) error {
-	// Delete old prompts from this source
-	if err := i.DeletePromptsBySource(sourceID); err != nil {
-		return fmt.Errorf("failed to delete old prompts: %w", err)
+// SourceIDs returns the IDs of the sources that have an index.
+func (i *Indexer) SourceIDs() ([]string, error) {
+	if i.remote != nil {
+		var ids []string
+		err := i.remote.call("source-ids", nil, &ids)
+		return ids, err
 	}
 
-	// Index new prompts
-	if err := i.IndexPrompts(prompts); err != nil {
-		return fmt.Errorf("failed to index new prompts: %w", err)
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	ids := make([]string, 0, len(i.sources))
+	for sourceID := range i.sources {
+		ids = append(ids, sourceID)
 	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// SourcePromptIDs returns the IDs of all indexed prompts from a source.
+func (i *Indexer) SourcePromptIDs(sourceID string) ([]string, error) {
+	if i.remote != nil {
+		var ids []string
+		err := i.remote.call("source-prompt-ids", changesRequest{SourceID: sourceID}, &ids)
+		return ids, err
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	index, ok := i.sources[sourceID]
+	if !ok {
+		return nil, nil
+	}
+	ids, err := indexDocIDs(index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for source prompts: %w", err)
+	}
+	return ids, nil
+}
 
+// ApplyChanges indexes (adding or replacing) and deletes prompts in a single
+// batch per source, so searches never see a source half updated. A prompt both
+// deleted and indexed is kept.
+func (i *Indexer) ApplyChanges(prompts []models.Prompt, deleteIDs []string) error {
+	return i.applyChanges(prompts, deleteIDs, i.userMeta)
+}
+
+// applyChanges is ApplyChanges with the user metadata to index prompts with.
+func (i *Indexer) applyChanges(prompts []models.Prompt, deleteIDs []string, meta map[string]models.UserMetadata) error {
+	if i.remote != nil {
+		return i.remote.call("apply", changesRequest{
+			Prompts:      prompts,
+			DeleteIDs:    deleteIDs,
+			UserMetadata: promptsMetadata(prompts, meta),
+		}, nil)
+	}
+	if i.readOnly {
+		return ErrReadOnly
+	}
+
+	batches := make(map[string]*bleve.Batch)
+	var sourceIDs []string
+	batchOf := func(sourceID string) (*bleve.Batch, error) {
+		if batch, ok := batches[sourceID]; ok {
+			return batch, nil
+		}
+		index, err := i.sourceIndex(sourceID)
+		if err != nil {
+			return nil, err
+		}
+		batches[sourceID] = index.NewBatch()
+		sourceIDs = append(sourceIDs, sourceID)
+		return batches[sourceID], nil
+	}
+
+	indexed := make(map[string]bool, len(prompts))
+	for _, prompt := range prompts {
+		batch, err := batchOf(promptSourceID(prompt.ID))
+		if err != nil {
+			return err
+		}
+		if err := batch.Index(prompt.ID, newDocument(prompt, meta[prompt.ID])); err != nil {
+			return fmt.Errorf("failed to add prompt %s to batch: %w", prompt.ID, err)
+		}
+		indexed[prompt.ID] = true
+	}
+	var deleted []string
+	for _, id := range deleteIDs {
+		if indexed[id] {
+			continue
+		}
+		// A source without an index has nothing to delete
+		i.mu.RLock()
+		_, ok := i.sources[promptSourceID(id)]
+		i.mu.RUnlock()
+		if !ok {
+			continue
+		}
+		batch, err := batchOf(promptSourceID(id))
+		if err != nil {
+			return err
+		}
+		batch.Delete(id)
+		deleted = append(deleted, id)
+	}
+
+	if len(batches) == 0 {
+		return nil
+	}
+	// Holding i.mu keeps a source's index from being swapped out mid batch
+	i.mu.RLock()
+	for _, sourceID := range sourceIDs {
+		index, ok := i.sources[sourceID]
+		if !ok {
+			continue
+		}
+		if err := index.Batch(batches[sourceID]); err != nil {
+			i.mu.RUnlock()
+			return fmt.Errorf("failed to commit batch of %s: %w", sourceID, err)
+		}
+	}
+	i.mu.RUnlock()
+
+	notifyChanged(i.path, prompts, deleted)
+	return nil
+}
+
+// ReindexSource re-indexes all prompts from a source: a new index of the
+// source is built and swapped in for its old one, dropping prompts no longer
+// in the source. See NewSourceWriter to index prompts as they are parsed.
+func (i *Indexer) ReindexSource(sourceID string, prompts []models.Prompt) error {
+	return i.reindexSource(sourceID, prompts, i.userMeta)
+}
+
+// reindexSource is ReindexSource with the user metadata to index prompts with.
+func (i *Indexer) reindexSource(sourceID string, prompts []models.Prompt, meta map[string]models.UserMetadata) error {
+	w, err := i.newSourceWriter(sourceID, meta)
+	if err != nil {
+		return fmt.Errorf("failed to index new prompts: %w", err)
+	}
+	for _, prompt := range prompts {
+		if err := w.Add(prompt); err != nil {
+			w.Abort()
+			return fmt.Errorf("failed to index new prompts: %w", err)
+		}
+	}
+	if err := w.Commit(); err != nil {
+		return fmt.Errorf("failed to index new prompts: %w", err)
+	}
 	return nil
 }
 
 // Close closes the index.
 func (i *Indexer) Close() error {
-	return i.index.Close()
+	if i.remote != nil {
+		i.remote.client.CloseIdleConnections()
+		return nil
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.closeSources()
 }
 
-// GetIndex returns the underlying bleve index for advanced operations.
+// GetIndex returns the alias over the indexes of all sources for advanced
+// operations, nil when another process serves the index.
 func (i *Indexer) GetIndex() bleve.Index {
 	return i.index
 }
 
 // Count returns the total number of documents in the index.
 func (i *Indexer) Count() (uint64, error) {
-	return i.index.DocCount()
+	if i.remote != nil {
+		var count uint64
+		err := i.remote.call("count", nil, &count)
+		return count, err
+	}
+	return i.docCount()
 }
 
 // DeleteIndex completely removes the index from disk.
@@ -0,0 +1,118 @@
+package index
+
+import (
+	"sort"
+
+	"github.com/blevesearch/bleve/v2/analysis/lang/ar"
+	"github.com/blevesearch/bleve/v2/analysis/lang/cjk"
+	"github.com/blevesearch/bleve/v2/analysis/lang/ckb"
+	"github.com/blevesearch/bleve/v2/analysis/lang/da"
+	"github.com/blevesearch/bleve/v2/analysis/lang/de"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+	"github.com/blevesearch/bleve/v2/analysis/lang/es"
+	"github.com/blevesearch/bleve/v2/analysis/lang/fa"
+	"github.com/blevesearch/bleve/v2/analysis/lang/fi"
+	"github.com/blevesearch/bleve/v2/analysis/lang/fr"
+	"github.com/blevesearch/bleve/v2/analysis/lang/hi"
+	"github.com/blevesearch/bleve/v2/analysis/lang/hr"
+	"github.com/blevesearch/bleve/v2/analysis/lang/hu"
+	"github.com/blevesearch/bleve/v2/analysis/lang/it"
+	"github.com/blevesearch/bleve/v2/analysis/lang/nl"
+	"github.com/blevesearch/bleve/v2/analysis/lang/no"
+	"github.com/blevesearch/bleve/v2/analysis/lang/pl"
+	"github.com/blevesearch/bleve/v2/analysis/lang/pt"
+	"github.com/blevesearch/bleve/v2/analysis/lang/ro"
+	"github.com/blevesearch/bleve/v2/analysis/lang/ru"
+	"github.com/blevesearch/bleve/v2/analysis/lang/sv"
+	"github.com/blevesearch/bleve/v2/analysis/lang/tr"
+	"github.com/whisller/pkit/internal/language"
+)
+
+// languageAnalyzers maps the languages of language.Codes to the analyzer of
+// the text fields of their prompts. Chinese, Japanese and Korean have no
+// stemming, they share the cjk analyzer, which indexes pairs of characters.
+var languageAnalyzers = map[string]string{
+	"ar":  ar.AnalyzerName,
+	"cjk": cjk.AnalyzerName,
+	"ckb": ckb.AnalyzerName,
+	"da":  da.AnalyzerName,
+	"de":  de.AnalyzerName,
+	"en":  en.AnalyzerName,
+	"es":  es.AnalyzerName,
+	"fa":  fa.AnalyzerName,
+	"fi":  fi.AnalyzerName,
+	"fr":  fr.AnalyzerName,
+	"hi":  hi.AnalyzerName,
+	"hr":  hr.AnalyzerName,
+	"hu":  hu.AnalyzerName,
+	"it":  it.AnalyzerName,
+	"ja":  cjk.AnalyzerName,
+	"ko":  cjk.AnalyzerName,
+	"nl":  nl.AnalyzerName,
+	"no":  no.AnalyzerName,
+	"pl":  pl.AnalyzerName,
+	"pt":  pt.AnalyzerName,
+	"ro":  ro.AnalyzerName,
+	"ru":  ru.AnalyzerName,
+	"sv":  sv.AnalyzerName,
+	"tr":  tr.AnalyzerName,
+	"zh":  cjk.AnalyzerName,
+}
+
+// languageAnalyzer returns the analyzer of the text fields of prompts in a
+// language, queryAnalyzer for prompts of no or an unknown language.
+func languageAnalyzer(code string) string {
+	if analyzer, ok := languageAnalyzers[code]; ok {
+		return analyzer
+	}
+	return queryAnalyzer
+}
+
+// textAnalyzers returns the analyzers of all languages, each once, sorted.
+func textAnalyzers() []string {
+	seen := make(map[string]bool)
+	var analyzers []string
+	for _, code := range language.Codes {
+		if analyzer := languageAnalyzer(code); !seen[analyzer] {
+			seen[analyzer] = true
+			analyzers = append(analyzers, analyzer)
+		}
+	}
+	sort.Strings(analyzers)
+	return analyzers
+}
+
+// BleveType picks the document mapping of the prompt's language, so its text
+// fields are analyzed with the stemming and stop words of that language.
+func (d document) BleveType() string {
+	return languageAnalyzer(d.Language)
+}
+
+// indexedAnalyzers returns the analyzers of the languages of the indexed
+// prompts, sorted, so search input is analyzed the way each of them was.
+func (i *Indexer) indexedAnalyzers() []string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	for _, index := range i.sourceIndexes() {
+		dict, err := index.FieldDict("language")
+		if err != nil {
+			continue
+		}
+		for entry, err := dict.Next(); err == nil && entry != nil; entry, err = dict.Next() {
+			seen[languageAnalyzer(entry.Term)] = true
+		}
+		_ = dict.Close()
+	}
+	if len(seen) == 0 {
+		return []string{queryAnalyzer}
+	}
+
+	analyzers := make([]string, 0, len(seen))
+	for analyzer := range seen {
+		analyzers = append(analyzers, analyzer)
+	}
+	sort.Strings(analyzers)
+	return analyzers
+}
@@ -0,0 +1,690 @@
+package index
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// queryAnalyzer analyzes the text fields of prompts in the default language,
+// and the hint words of Suggest.
+const queryAnalyzer = "en"
+
+// exactAnalyzer analyzes the case-preserving copies of text fields used by
+// case-sensitive searches.
+const exactAnalyzer = "exact"
+
+// exactSuffix names the case-preserving copy of a text field ("name_exact").
+const exactSuffix = "_exact"
+
+// textFields are the analyzed fields that have a case-preserving copy.
+var textFields = []string{"name", "description", "content", "aliases", "notes"}
+
+// queryField describes a field that can be targeted with a field:value prefix.
+type queryField struct {
+	// Names of the fields in the index mapping; a term matches in any of them
+	names []string
+
+	// Keyword fields are matched exactly, text fields are analyzed
+	keyword bool
+}
+
+// queryFields maps the prefixes accepted in queries to index fields.
+var queryFields = map[string]queryField{
+	"name":        {names: []string{"name"}},
+	"desc":        {names: []string{"description"}},
+	"description": {names: []string{"description"}},
+	"content":     {names: []string{"content"}},
+	"alias":       {names: []string{"aliases"}},
+	"notes":       {names: []string{"notes"}},
+	"tag":         {names: []string{"tags", "user_tags"}, keyword: true},
+	"tags":        {names: []string{"tags", "user_tags"}, keyword: true},
+	"source":      {names: []string{"source_id"}, keyword: true},
+	"author":      {names: []string{"author"}, keyword: true},
+	"id":          {names: []string{"id"}, keyword: true},
+	"lang":        {names: []string{"language"}, keyword: true},
+}
+
+// QueryError is a syntax error in a search query.
+type QueryError struct {
+	// Offset of the offending token in runes (0-based)
+	Pos int
+
+	// The offending token, empty at the end of the query
+	Token string
+
+	Message string
+}
+
+func (e *QueryError) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("invalid query at column %d: %s", e.Pos+1, e.Message)
+	}
+	return fmt.Sprintf("invalid query at column %d near %q: %s", e.Pos+1, e.Token, e.Message)
+}
+
+// Expr is a node of a parsed search query.
+type Expr interface {
+	// String returns the expression in a canonical, fully parenthesized form
+	String() string
+}
+
+// TermExpr matches a word, wildcard pattern or phrase, optionally in one field.
+type TermExpr struct {
+	Field  string // query prefix ("tag", "name"...), empty for all fields
+	Text   string
+	Phrase bool
+}
+
+// Wildcard reports whether the term is a wildcard pattern.
+func (t *TermExpr) Wildcard() bool {
+	return !t.Phrase && strings.ContainsAny(t.Text, "*?")
+}
+
+func (t *TermExpr) String() string {
+	text := t.Text
+	if t.Phrase {
+		text = `"` + text + `"`
+	}
+	if t.Field != "" {
+		return t.Field + ":" + text
+	}
+	return text
+}
+
+// NotExpr excludes documents matching X.
+type NotExpr struct {
+	X Expr
+}
+
+func (n *NotExpr) String() string {
+	return "-" + n.X.String()
+}
+
+// AndExpr matches documents matching all of Xs.
+type AndExpr struct {
+	Xs []Expr
+}
+
+func (a *AndExpr) String() string {
+	return joinExprs("AND", a.Xs)
+}
+
+// OrExpr matches documents matching any of Xs.
+type OrExpr struct {
+	Xs []Expr
+}
+
+func (o *OrExpr) String() string {
+	return joinExprs("OR", o.Xs)
+}
+
+func joinExprs(op string, xs []Expr) string {
+	parts := make([]string, len(xs))
+	for i, x := range xs {
+		parts[i] = x.String()
+	}
+	return "(" + op + " " + strings.Join(parts, " ") + ")"
+}
+
+// ParseQuery parses a search query. It returns nil for an empty query.
+//
+// Syntax:
+//
+//	word             match in name, description and content
+//	"two words"      phrase
+//	field:value      match in one field (name, desc, content, alias, notes, tag, source, author, id)
+//	field:"a b"      phrase in one field
+//	rev*, te?t       wildcards
+//	-term, NOT term  exclude
+//	a OR b           either (terms are ANDed by default; AND may be written explicitly)
+//	(a OR b) c       grouping
+func ParseQuery(input string) (Expr, error) {
+	tokens, err := lexQuery(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, nil
+	}
+
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind == tokRParen {
+		return nil, p.errorAt(tok, "unmatched closing parenthesis")
+	} else if tok.kind != tokEOF {
+		return nil, p.errorAt(tok, "unexpected token")
+	}
+	return expr, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokPhrase
+	tokField // field prefix, followed by tokWord or tokPhrase
+	tokMinus
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+)
+
+type queryToken struct {
+	kind tokenKind
+	text string
+	pos  int // in runes
+}
+
+// lexQuery splits a query into tokens.
+func lexQuery(input string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(input)
+
+	isDelim := func(r rune) bool {
+		return unicode.IsSpace(r) || r == '(' || r == ')' || r == '"'
+	}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, queryToken{kind: tokLParen, text: "(", pos: i})
+			i++
+
+		case r == ')':
+			tokens = append(tokens, queryToken{kind: tokRParen, text: ")", pos: i})
+			i++
+
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, &QueryError{Pos: i, Token: string(runes[i:]), Message: "unterminated phrase (missing closing quote)"}
+			}
+			tokens = append(tokens, queryToken{kind: tokPhrase, text: string(runes[i+1 : end]), pos: i})
+			i = end + 1
+
+		case r == '-':
+			tokens = append(tokens, queryToken{kind: tokMinus, text: "-", pos: i})
+			i++
+
+		default:
+			start := i
+			for i < len(runes) && !isDelim(runes[i]) {
+				i++
+			}
+			word := string(runes[start:i])
+
+			switch word {
+			case "AND":
+				tokens = append(tokens, queryToken{kind: tokAnd, text: word, pos: start})
+				continue
+			case "OR":
+				tokens = append(tokens, queryToken{kind: tokOr, text: word, pos: start})
+				continue
+			case "NOT":
+				tokens = append(tokens, queryToken{kind: tokNot, text: word, pos: start})
+				continue
+			}
+
+			// field:value splits at the first colon
+			if colon := strings.IndexRune(word, ':'); colon > 0 {
+				field := word[:colon]
+				if _, ok := queryFields[strings.ToLower(field)]; !ok {
+					return nil, &QueryError{Pos: start, Token: word, Message: fmt.Sprintf("unknown field %q (use %s)", field, knownFields())}
+				}
+				tokens = append(tokens, queryToken{kind: tokField, text: strings.ToLower(field), pos: start})
+				if value := word[colon+1:]; value != "" {
+					tokens = append(tokens, queryToken{kind: tokWord, text: value, pos: start + utf8.RuneCountInString(word[:colon+1])})
+				}
+				continue
+			}
+
+			tokens = append(tokens, queryToken{kind: tokWord, text: word, pos: start})
+		}
+	}
+
+	tokens = append(tokens, queryToken{kind: tokEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+// knownFields lists the accepted field prefixes for error messages.
+func knownFields() string {
+	names := make([]string, 0, len(queryFields))
+	for name := range queryFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// queryParser is a recursive descent parser over query tokens.
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() queryToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *queryParser) errorAt(tok queryToken, message string) error {
+	return &QueryError{Pos: tok.pos, Token: tok.text, Message: message}
+}
+
+// parseOr parses: and { OR and }
+func (p *queryParser) parseOr() (Expr, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	xs := []Expr{first}
+	for p.peek().kind == tokOr {
+		p.next()
+		x, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		xs = append(xs, x)
+	}
+
+	if len(xs) == 1 {
+		return first, nil
+	}
+	return &OrExpr{Xs: xs}, nil
+}
+
+// parseAnd parses: unary { [AND] unary }
+func (p *queryParser) parseAnd() (Expr, error) {
+	var xs []Expr
+	for {
+		tok := p.peek()
+		switch tok.kind {
+		case tokEOF, tokOr, tokRParen:
+			if len(xs) == 0 {
+				return nil, p.errorAt(tok, "expected a search term")
+			}
+			if len(xs) == 1 {
+				return xs[0], nil
+			}
+			return &AndExpr{Xs: xs}, nil
+
+		case tokAnd:
+			if len(xs) == 0 {
+				return nil, p.errorAt(tok, "AND must follow a search term")
+			}
+			p.next()
+			if k := p.peek().kind; k == tokEOF || k == tokOr || k == tokRParen || k == tokAnd {
+				return nil, p.errorAt(p.peek(), "expected a search term after AND")
+			}
+		}
+
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		xs = append(xs, x)
+	}
+}
+
+// parseUnary parses: { - | NOT } primary
+func (p *queryParser) parseUnary() (Expr, error) {
+	if k := p.peek().kind; k == tokMinus || k == tokNot {
+		tok := p.next()
+		if tok.kind == tokMinus && p.peek().pos != tok.pos+1 {
+			return nil, p.errorAt(tok, "nothing to exclude after -")
+		}
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &NotExpr{X: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary parses: ( or ) | field: value | word | "phrase"
+func (p *queryParser) parsePrimary() (Expr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokRParen {
+			return nil, p.errorAt(tok, "missing closing parenthesis")
+		}
+		p.next()
+		return x, nil
+
+	case tokField:
+		value := p.peek()
+		if value.kind != tokWord && value.kind != tokPhrase || value.pos != tok.pos+utf8.RuneCountInString(tok.text)+1 {
+			return nil, p.errorAt(tok, "missing value after "+tok.text+":")
+		}
+		p.next()
+		return &TermExpr{Field: tok.text, Text: value.text, Phrase: value.kind == tokPhrase}, nil
+
+	case tokWord:
+		return &TermExpr{Text: tok.text}, nil
+
+	case tokPhrase:
+		return &TermExpr{Text: tok.text, Phrase: true}, nil
+
+	case tokRParen:
+		return nil, p.errorAt(tok, "unmatched closing parenthesis")
+
+	default:
+		return nil, p.errorAt(tok, "expected a search term")
+	}
+}
+
+// compileOptions controls how the words of a query are matched.
+type compileOptions struct {
+	// Match words within an edit distance scaled by their length
+	fuzzy bool
+
+	// The term being typed, whose last word is also matched as a prefix
+	prefix *TermExpr
+
+	// Also require text matches with the same case
+	caseSensitive bool
+
+	// Analyzers of the index the query runs against
+	mapping mapping.IndexMapping
+
+	// Analyzers of the languages of the indexed prompts. Free text and field
+	// terms are analyzed with each, the same way text fields of prompts in
+	// that language were at index time, so stemmed forms match.
+	analyzers []string
+}
+
+// compileQuery turns a parsed query into a bleve query.
+func compileQuery(expr Expr, opts compileOptions) query.Query {
+	switch e := expr.(type) {
+	case *TermExpr:
+		return compileTerm(e, opts)
+
+	case *NotExpr:
+		return query.NewBooleanQuery([]query.Query{bleve.NewMatchAllQuery()}, nil, []query.Query{compileQuery(e.X, opts)})
+
+	case *AndExpr:
+		var must, mustNot []query.Query
+		for _, x := range e.Xs {
+			if not, ok := x.(*NotExpr); ok {
+				mustNot = append(mustNot, compileQuery(not.X, opts))
+			} else {
+				must = append(must, compileQuery(x, opts))
+			}
+		}
+		if len(mustNot) == 0 {
+			return bleve.NewConjunctionQuery(must...)
+		}
+		if len(must) == 0 {
+			must = []query.Query{bleve.NewMatchAllQuery()}
+		}
+		return query.NewBooleanQuery(must, nil, mustNot)
+
+	case *OrExpr:
+		xs := make([]query.Query, len(e.Xs))
+		for i, x := range e.Xs {
+			xs[i] = compileQuery(x, opts)
+		}
+		return bleve.NewDisjunctionQuery(xs...)
+	}
+
+	return bleve.NewMatchNoneQuery()
+}
+
+// compileTerm maps a single term onto the bleve query type for its field.
+func compileTerm(t *TermExpr, opts compileOptions) query.Query {
+	field, ok := queryFields[t.Field]
+	if !ok {
+		// No prefix: all fields
+		return compileFieldTerm(t, "", false, opts)
+	}
+
+	if len(field.names) == 1 {
+		return compileFieldTerm(t, field.names[0], field.keyword, opts)
+	}
+
+	xs := make([]query.Query, len(field.names))
+	for i, name := range field.names {
+		xs[i] = compileFieldTerm(t, name, field.keyword, opts)
+	}
+	return bleve.NewDisjunctionQuery(xs...)
+}
+
+// compileFieldTerm matches a term in one index field, or in all fields when
+// name is empty.
+func compileFieldTerm(t *TermExpr, name string, keyword bool, opts compileOptions) query.Query {
+	if keyword {
+		if t.Wildcard() {
+			q := bleve.NewWildcardQuery(t.Text)
+			q.SetField(name)
+			return q
+		}
+		q := bleve.NewTermQuery(t.Text)
+		q.SetField(name)
+		return q
+	}
+
+	q := compileLanguages(t, name, opts)
+	if !opts.caseSensitive {
+		return q
+	}
+
+	// Text fields are indexed lowercased, so the case is checked against
+	// their case-preserving copies. A case difference would be within the
+	// edit distance of fuzzy matching, so the spelling has to match too.
+	exactOpts := opts
+	exactOpts.fuzzy = false
+	exactNames := textFields
+	if name != "" {
+		exactNames = []string{name}
+	}
+	exact := make([]query.Query, len(exactNames))
+	for i, exactName := range exactNames {
+		exact[i] = compileText(t, exactName+exactSuffix, exactAnalyzer, exactOpts)
+	}
+	return bleve.NewConjunctionQuery(q, bleve.NewDisjunctionQuery(exact...))
+}
+
+// compileLanguages matches a term in a text field, or in all fields when name
+// is empty, analyzed for each language of opts.analyzers. Analyzers that turn
+// the term into the same words as an earlier one are skipped, so prompts
+// matching them don't score twice.
+func compileLanguages(t *TermExpr, name string, opts compileOptions) query.Query {
+	analyzers := opts.analyzers
+	switch {
+	case len(analyzers) == 0 || t.Wildcard():
+		return compileText(t, name, queryAnalyzer, opts)
+	case len(analyzers) == 1:
+		return compileText(t, name, analyzers[0], opts)
+	}
+
+	var xs []query.Query
+	seen := make(map[string]bool)
+	for _, analyzer := range analyzers {
+		if a := opts.mapping.AnalyzerNamed(analyzer); a != nil {
+			var words []string
+			for _, token := range a.Analyze([]byte(t.Text)) {
+				words = append(words, string(token.Term))
+			}
+			key := strings.Join(words, " ")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		}
+		xs = append(xs, compileText(t, name, analyzer, opts))
+	}
+
+	if len(xs) == 1 {
+		return xs[0]
+	}
+	return bleve.NewDisjunctionQuery(xs...)
+}
+
+// compileText matches a term in a text field analyzed with analyzer, or in
+// all fields when name is empty.
+func compileText(t *TermExpr, name string, analyzer string, opts compileOptions) query.Query {
+	switch {
+	case t.Phrase:
+		q := bleve.NewMatchPhraseQuery(t.Text)
+		q.Analyzer = analyzer
+		if name != "" {
+			q.SetField(name)
+		}
+		return q
+
+	case t.Wildcard():
+		text := t.Text
+		if analyzer != exactAnalyzer {
+			text = strings.ToLower(text)
+		}
+		q := bleve.NewWildcardQuery(text)
+		if name != "" {
+			q.SetField(name)
+		}
+		return q
+
+	case opts.fuzzy || opts.prefix == t:
+		if q := compileWords(t.Text, name, opts.mapping.AnalyzerNamed(analyzer), opts.fuzzy, opts.prefix == t); q != nil {
+			return q
+		}
+	}
+
+	q := bleve.NewMatchQuery(t.Text)
+	q.Analyzer = analyzer
+	if analyzer == exactAnalyzer {
+		q.SetOperator(query.MatchQueryOperatorAnd)
+	}
+	if name != "" {
+		q.SetField(name)
+	}
+	return q
+}
+
+// compileWords analyzes text into the terms the index holds and requires
+// each of them, within an edit distance scaled by its length when fuzzy is
+// set, and the last one also as a prefix when prefix is set. Exact matches
+// score highest. Returns nil when text has no terms (e.g. only stop words).
+func compileWords(text, name string, analyzer analysis.Analyzer, fuzzy, prefix bool) query.Query {
+	if analyzer == nil {
+		return nil
+	}
+	tokens := analyzer.Analyze([]byte(text))
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	words := make([]query.Query, len(tokens))
+	for i, token := range tokens {
+		term := string(token.Term)
+
+		exact := bleve.NewTermQuery(term)
+		exact.SetBoost(exactBoost)
+		alternatives := []query.FieldableQuery{exact}
+
+		if distance := fuzziness(term); fuzzy && distance > 0 {
+			q := bleve.NewFuzzyQuery(term)
+			q.Fuzziness = distance
+			alternatives = append(alternatives, q)
+		}
+		if prefix && i == len(tokens)-1 {
+			alternatives = append(alternatives, bleve.NewPrefixQuery(term))
+		}
+
+		disjuncts := make([]query.Query, len(alternatives))
+		for j, q := range alternatives {
+			if name != "" {
+				q.SetField(name)
+			}
+			disjuncts[j] = q
+		}
+		words[i] = bleve.NewDisjunctionQuery(disjuncts...)
+	}
+
+	if len(words) == 1 {
+		return words[0]
+	}
+	return bleve.NewConjunctionQuery(words...)
+}
+
+// exactBoost ranks exact word matches above fuzzy and prefix ones.
+const exactBoost = 2.0
+
+// fuzziness is the edit distance allowed for a term: none for short terms,
+// where a single edit changes the word, up to two for long ones.
+func fuzziness(term string) int {
+	switch n := utf8.RuneCountInString(term); {
+	case n < 3:
+		return 0
+	case n < 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// endsWithSpace reports whether a query ends in whitespace, so its last word
+// is complete.
+func endsWithSpace(q string) bool {
+	r, size := utf8.DecodeLastRuneInString(q)
+	return size > 0 && unicode.IsSpace(r)
+}
+
+// lastTerm returns the term typed last in a query, or nil.
+func lastTerm(expr Expr) *TermExpr {
+	switch e := expr.(type) {
+	case *TermExpr:
+		return e
+	case *NotExpr:
+		return lastTerm(e.X)
+	case *AndExpr:
+		if len(e.Xs) > 0 {
+			return lastTerm(e.Xs[len(e.Xs)-1])
+		}
+	case *OrExpr:
+		if len(e.Xs) > 0 {
+			return lastTerm(e.Xs[len(e.Xs)-1])
+		}
+	}
+	return nil
+}
+
+// tagQuery matches a tag exactly in source-provided or user tags.
+func tagQuery(tag string) query.Query {
+	return compileTerm(&TermExpr{Field: "tag", Text: tag}, compileOptions{})
+}
@@ -0,0 +1,153 @@
+package index
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "empty",
+			input: "   ",
+			want:  "<nil>",
+		},
+		{
+			name:  "single word",
+			input: "review",
+			want:  "review",
+		},
+		{
+			name:  "implicit and",
+			input: "code review",
+			want:  "(AND code review)",
+		},
+		{
+			name:  "fields, negation and phrase",
+			input: `name:review -tag:nsfw author:f "code smell"`,
+			want:  `(AND name:review -tag:nsfw author:f "code smell")`,
+		},
+		{
+			name:  "field phrase",
+			input: `desc:"pull request"`,
+			want:  `desc:"pull request"`,
+		},
+		{
+			name:  "or binds looser than and",
+			input: "a b OR c",
+			want:  "(OR (AND a b) c)",
+		},
+		{
+			name:  "grouping",
+			input: "(tag:go OR tag:rust) review",
+			want:  "(AND (OR tag:go tag:rust) review)",
+		},
+		{
+			name:  "explicit and, not",
+			input: "summary AND NOT source:fabric",
+			want:  "(AND summary -source:fabric)",
+		},
+		{
+			name:  "negated group",
+			input: "-(a OR b)",
+			want:  "-(OR a b)",
+		},
+		{
+			name:  "wildcard and id with colon",
+			input: "summar* id:fabric:summarize",
+			want:  "(AND summar* id:fabric:summarize)",
+		},
+		{
+			name:  "field names are case insensitive",
+			input: "Tag:Go",
+			want:  "tag:Go",
+		},
+		{
+			name:  "hyphen inside word",
+			input: "code-review",
+			want:  "code-review",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseQuery(tt.input)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q) error = %v", tt.input, err)
+			}
+
+			got := "<nil>"
+			if expr != nil {
+				got = expr.String()
+			}
+			if got != tt.want {
+				t.Errorf("ParseQuery(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantPos int
+	}{
+		{name: "unknown field", input: "review colour:red", wantPos: 7},
+		{name: "unterminated phrase", input: `a "code smell`, wantPos: 2},
+		{name: "missing closing parenthesis", input: "x (a OR b", wantPos: 2},
+		{name: "unmatched closing parenthesis", input: "a b)", wantPos: 3},
+		{name: "missing field value", input: "tag: go", wantPos: 0},
+		{name: "dangling or", input: "a OR", wantPos: 4},
+		{name: "leading and", input: "AND a", wantPos: 0},
+		{name: "lone minus", input: "a - b", wantPos: 2},
+		{name: "empty group", input: "a () b", wantPos: 3},
+		{name: "position in runes", input: "żółw tag:", wantPos: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseQuery(tt.input)
+
+			var qerr *QueryError
+			if !errors.As(err, &qerr) {
+				t.Fatalf("ParseQuery(%q) error = %v, want *QueryError", tt.input, err)
+			}
+			if qerr.Pos != tt.wantPos {
+				t.Errorf("ParseQuery(%q) error position = %d, want %d (%v)", tt.input, qerr.Pos, tt.wantPos, err)
+			}
+		})
+	}
+}
+
+func TestLastTerm(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "single word", input: "summ", want: "summ"},
+		{name: "last of several", input: "code rev", want: "rev"},
+		{name: "last alternative", input: "a OR (b c)", want: "c"},
+		{name: "negated", input: "review -summ", want: "summ"},
+		{name: "field term", input: "review name:co", want: "name:co"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseQuery(tt.input)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q) error = %v", tt.input, err)
+			}
+
+			got := lastTerm(expr)
+			if got == nil || got.String() != tt.want {
+				t.Errorf("lastTerm(%q) = %v, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
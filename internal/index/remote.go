@@ -0,0 +1,309 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/whisller/pkit/pkg/models"
+)
+
+// bleve's store takes an exclusive lock on the index, so only one process can
+// write to it. A process holding the index open for long (pkit web) serves it
+// on a Unix socket next to the index; indexers opened by other pkit processes
+// while it runs forward their calls there instead of waiting for the lock.
+
+// socketFile is the name of the socket, in the index's directory.
+const socketFile = "pkit.sock"
+
+// socketTimeout bounds one call to the serving process. Rebuilding a large
+// source is the slowest call.
+const socketTimeout = 5 * time.Minute
+
+// pingTimeout bounds checking whether a process serves the socket.
+const pingTimeout = time.Second
+
+// SocketPath returns the path of the socket an index is served on.
+func SocketPath(indexPath string) string {
+	return filepath.Join(filepath.Dir(indexPath), socketFile)
+}
+
+// Serving reports whether a process serves the index on its socket.
+func Serving(indexPath string) bool {
+	return connect(indexPath) != nil
+}
+
+// Remote reports whether the indexer forwards its calls to another process
+// serving the index.
+func (i *Indexer) Remote() bool {
+	return i.remote != nil
+}
+
+// remoteIndex forwards indexer calls to the process serving the index.
+type remoteIndex struct {
+	client *http.Client
+}
+
+// connect returns a client of the process serving the index, or nil when none
+// is listening (a socket file left by a crashed process is ignored).
+func connect(indexPath string) *remoteIndex {
+	socketPath := SocketPath(indexPath)
+	if _, err := os.Stat(socketPath); err != nil {
+		return nil
+	}
+
+	dialer := net.Dialer{}
+	remote := &remoteIndex{client: &http.Client{
+		Timeout: socketTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	var version int
+	if err := remote.callContext(ctx, "ping", nil, &version); err != nil || version != SchemaVersion {
+		return nil
+	}
+	return remote
+}
+
+// call runs a method of the serving process's indexer, decoding its result into resp.
+func (r *remoteIndex) call(method string, req, resp interface{}) error {
+	return r.callContext(context.Background(), method, req, resp)
+}
+
+func (r *remoteIndex) callContext(ctx context.Context, method string, req, resp interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://pkit/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpResp, err := r.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("index server: %w", err)
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+
+	if httpResp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(httpResp.Body).Decode(&errResp); err != nil || errResp.Error == "" {
+			return fmt.Errorf("index server: %s", httpResp.Status)
+		}
+		return errors.New(errResp.Error)
+	}
+
+	if resp == nil {
+		return nil
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(resp); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	return nil
+}
+
+// Requests of the methods served on the socket.
+type (
+	promptRequest struct {
+		ID string `json:"id"`
+	}
+
+	similarRequest struct {
+		ID   string         `json:"id"`
+		Opts SimilarOptions `json:"opts"`
+	}
+
+	suggestRequest struct {
+		Text       string   `json:"text"`
+		Hints      []string `json:"hints"`
+		MaxResults int      `json:"max_results"`
+	}
+
+	duplicatesRequest struct {
+		ID        string  `json:"id,omitempty"`
+		Threshold float64 `json:"threshold"`
+	}
+
+	// Prompts are written with the user metadata of the calling process
+	changesRequest struct {
+		SourceID     string                         `json:"source_id,omitempty"`
+		Prompts      []models.Prompt                `json:"prompts"`
+		DeleteIDs    []string                       `json:"delete_ids,omitempty"`
+		UserMetadata map[string]models.UserMetadata `json:"user_metadata,omitempty"`
+	}
+
+	versionsResponse struct {
+		Schema int    `json:"schema"`
+		Build  string `json:"build"`
+	}
+)
+
+// promptsMetadata returns the entries of meta for prompts.
+func promptsMetadata(prompts []models.Prompt, meta map[string]models.UserMetadata) map[string]models.UserMetadata {
+	subset := make(map[string]models.UserMetadata)
+	for _, prompt := range prompts {
+		if m, ok := meta[prompt.ID]; ok {
+			subset[prompt.ID] = m
+		}
+	}
+	return subset
+}
+
+// ServeSocket serves the index to other pkit processes on its socket until
+// the returned stop function is called. The indexer must have the index open
+// itself.
+func (i *Indexer) ServeSocket() (stop func() error, err error) {
+	if i.remote != nil {
+		return nil, fmt.Errorf("index is served by another process")
+	}
+
+	// A socket file left by a process that crashed; the index lock proves
+	// nobody serves it
+	socketPath := SocketPath(i.path)
+	if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+
+	server := &http.Server{Handler: i.socketHandler(), ReadHeaderTimeout: pingTimeout}
+	go func() { _ = server.Serve(listener) }()
+
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := server.Shutdown(ctx)
+		_ = os.Remove(socketPath)
+		return err
+	}, nil
+}
+
+// socketHandler routes socket requests to the indexer's methods.
+func (i *Indexer) socketHandler() http.Handler {
+	methods := map[string]func(body io.Reader) (interface{}, error){
+		"ping": func(io.Reader) (interface{}, error) {
+			return SchemaVersion, nil
+		},
+		"versions": func(io.Reader) (interface{}, error) {
+			schema, build, err := i.Versions()
+			return versionsResponse{Schema: schema, Build: build}, err
+		},
+		"count": func(io.Reader) (interface{}, error) {
+			return i.Count()
+		},
+		"search": func(body io.Reader) (interface{}, error) {
+			var opts SearchOptions
+			if err := json.NewDecoder(body).Decode(&opts); err != nil {
+				return nil, err
+			}
+			return i.SearchPage(opts)
+		},
+		"prompt": func(body io.Reader) (interface{}, error) {
+			var req promptRequest
+			if err := json.NewDecoder(body).Decode(&req); err != nil {
+				return nil, err
+			}
+			return i.GetPromptByID(req.ID)
+		},
+		"similar": func(body io.Reader) (interface{}, error) {
+			var req similarRequest
+			if err := json.NewDecoder(body).Decode(&req); err != nil {
+				return nil, err
+			}
+			return i.Similar(req.ID, req.Opts)
+		},
+		"suggest": func(body io.Reader) (interface{}, error) {
+			var req suggestRequest
+			if err := json.NewDecoder(body).Decode(&req); err != nil {
+				return nil, err
+			}
+			return i.Suggest(req.Text, req.Hints, req.MaxResults)
+		},
+		"duplicates-of": func(body io.Reader) (interface{}, error) {
+			var req duplicatesRequest
+			if err := json.NewDecoder(body).Decode(&req); err != nil {
+				return nil, err
+			}
+			return i.DuplicatesOf(req.ID, req.Threshold)
+		},
+		"duplicate-clusters": func(body io.Reader) (interface{}, error) {
+			var req duplicatesRequest
+			if err := json.NewDecoder(body).Decode(&req); err != nil {
+				return nil, err
+			}
+			return i.DuplicateClusters(req.Threshold)
+		},
+		"source-ids": func(io.Reader) (interface{}, error) {
+			return i.SourceIDs()
+		},
+		"source-prompt-ids": func(body io.Reader) (interface{}, error) {
+			var req changesRequest
+			if err := json.NewDecoder(body).Decode(&req); err != nil {
+				return nil, err
+			}
+			return i.SourcePromptIDs(req.SourceID)
+		},
+		"apply": func(body io.Reader) (interface{}, error) {
+			var req changesRequest
+			if err := json.NewDecoder(body).Decode(&req); err != nil {
+				return nil, err
+			}
+			return nil, i.applyChanges(req.Prompts, req.DeleteIDs, req.UserMetadata)
+		},
+		"reindex-source": func(body io.Reader) (interface{}, error) {
+			var req changesRequest
+			if err := json.NewDecoder(body).Decode(&req); err != nil {
+				return nil, err
+			}
+			return nil, i.reindexSource(req.SourceID, req.Prompts, req.UserMetadata)
+		},
+		"delete-source": func(body io.Reader) (interface{}, error) {
+			var req changesRequest
+			if err := json.NewDecoder(body).Decode(&req); err != nil {
+				return nil, err
+			}
+			return nil, i.DeletePromptsBySource(req.SourceID)
+		},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, ok := methods[r.URL.Path[1:]]
+		if !ok || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		result, err := method(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(result)
+	})
+}
@@ -0,0 +1,107 @@
+package index
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// SchemaVersion is the version of the mapping built by buildIndexMapping. Bump
+// it with every change to fields or analyzers, so indexes built before the
+// change are rebuilt instead of silently missing it.
+const SchemaVersion = 4
+
+// BuildVersion is the pkit version recorded in the indexes it creates.
+var BuildVersion = "dev"
+
+// Rebuild, when set, is called by NewIndexer for an index built with another
+// schema version. It should rebuild the index at indexPath from the sources,
+// or return an error (such as schemaErr) to leave it as it is.
+var Rebuild func(indexPath string, schemaErr *SchemaError) error
+
+// Internal keys the versions are stored under in the index.
+var (
+	schemaVersionKey = []byte("pkit_schema_version")
+	buildVersionKey  = []byte("pkit_build_version")
+)
+
+// SchemaError is returned when an index was built with another schema version.
+type SchemaError struct {
+	Path string
+
+	// Schema version of the index (0 for indexes from before versioning)
+	Version int
+
+	// pkit version that built the index ("" when unknown)
+	BuildVersion string
+}
+
+func (e *SchemaError) Error() string {
+	builtBy := "an older pkit"
+	if e.BuildVersion != "" {
+		builtBy = "pkit " + e.BuildVersion
+	}
+	return fmt.Sprintf("search index was built by %s (schema version %d, this pkit needs %d), run 'pkit reindex --full'",
+		builtBy, e.Version, SchemaVersion)
+}
+
+// writeVersions records the schema and pkit version in a new index.
+func writeVersions(index bleve.Index) error {
+	if err := index.SetInternal(schemaVersionKey, []byte(strconv.Itoa(SchemaVersion))); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+	if err := index.SetInternal(buildVersionKey, []byte(BuildVersion)); err != nil {
+		return fmt.Errorf("failed to record pkit version: %w", err)
+	}
+	return nil
+}
+
+// readVersions returns the schema and pkit version recorded in an index, 0 and
+// "" when there are none.
+func readVersions(index bleve.Index) (int, string, error) {
+	schema, err := index.GetInternal(schemaVersionKey)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read schema version: %w", err)
+	}
+	build, err := index.GetInternal(buildVersionKey)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read pkit version: %w", err)
+	}
+
+	version, _ := strconv.Atoi(string(schema))
+	return version, string(build), nil
+}
+
+// checkSchema returns a *SchemaError when an index was built with another
+// schema version.
+func checkSchema(index bleve.Index, indexPath string) error {
+	version, build, err := readVersions(index)
+	if err != nil {
+		return err
+	}
+	if version != SchemaVersion {
+		return &SchemaError{Path: indexPath, Version: version, BuildVersion: build}
+	}
+	return nil
+}
+
+// Versions returns the schema version of the index and the pkit version that
+// built it, "" when no source is indexed yet.
+func (i *Indexer) Versions() (schema int, build string, err error) {
+	if i.remote != nil {
+		var resp versionsResponse
+		err := i.remote.call("versions", nil, &resp)
+		return resp.Schema, resp.Build, err
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	// Every source index is created with the same versions
+	indexes := i.sourceIndexes()
+	if len(indexes) == 0 {
+		return SchemaVersion, "", nil
+	}
+	return readVersions(indexes[0])
+}
@@ -2,10 +2,12 @@ package index
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/blevesearch/bleve/v2"
 	"github.com/blevesearch/bleve/v2/search"
 	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/whisller/pkit/internal/similarity"
 	"github.com/whisller/pkit/pkg/models"
 )
 
@@ -14,50 +16,276 @@ type SearchOptions struct {
 	// Query string to search for
 	Query string
 
-	// MaxResults limits the number of results
+	// MaxResults limits the number of results (0 returns every match)
 	MaxResults int
 
+	// Offset skips this many results, for paging
+	Offset int
+
 	// Source filter (optional)
 	SourceID string
 
-	// Tags filter (optional)
+	// Tags filter (optional), matching source-provided and user tags
 	Tags []string
 
-	// Fuzzy matching enabled
+	// Only bookmarked prompts
+	Bookmarked bool
+
+	// Fuzzy matching enabled: words match within an edit distance scaled by
+	// their length
 	Fuzzy bool
 
+	// Match the last word of the query as a prefix too, for search-as-you-type.
+	// A query ending in a space has no word being typed.
+	Prefix bool
+
 	// Case sensitive search
 	CaseSensitive bool
+
+	// Maximum token count (0 means no limit)
+	MaxTokens int
+
+	// Collapse near-duplicate prompts into their best-ranked representative
+	Collapse bool
+
+	// Return matched fragments of name, description and content
+	Highlight bool
+
+	// Result order, one of SortOrders ("" sorts by score)
+	Sort string
+
+	// Count matches per source and tag (SearchPage.Facets)
+	Facets bool
+
+	// Blend in embedding similarity to the query (optional)
+	Semantic *SemanticOptions
+}
+
+// Facets returned with SearchOptions.Facets.
+const (
+	FacetSources  = "sources"   // Source IDs
+	FacetTags     = "tags"      // Source-provided tags
+	FacetUserTags = "user_tags" // User tags
+)
+
+// facetSize is how many of the most frequent values are counted per facet.
+const facetSize = 50
+
+// FacetCount is the number of matching prompts with one facet value.
+type FacetCount struct {
+	Term  string `json:"term"`
+	Count int    `json:"count"`
+}
+
+// SearchPage is one page of search results.
+type SearchPage struct {
+	Results []SearchResult
+
+	// Number of prompts matching the search, across all pages (before collapsing
+	// near-duplicates)
+	Total int
+
+	// Counts per facet, most frequent first (only with SearchOptions.Facets)
+	Facets map[string][]FacetCount
+}
+
+// highlightFields are the fields matched fragments are returned for.
+var highlightFields = []string{"name", "description", "content"}
+
+// resultFields are the stored fields returned with hits. Content is stored for
+// highlighting only and is left out to keep results small.
+var resultFields = []string{
+	"source_id", "name", "description", "tags", "file_path", "author", "language", "token_count", "fingerprint", "updated_at",
+	"user_tags", "bookmarked", "aliases", "notes", "usage_count", "last_used_at",
 }
 
 // SearchResult contains a search hit with prompt data.
 type SearchResult struct {
 	Prompt models.Prompt
 	Score  float64
+
+	// Tags, bookmark and aliases the user attached to the prompt
+	UserMetadata models.UserMetadata
+
+	// IDs of near-duplicates collapsed into this result (only with SearchOptions.Collapse)
+	Duplicates []string
+
+	// Matched fragments per field (only with SearchOptions.Highlight). Fragments
+	// are HTML-escaped with matches wrapped in HighlightStart and HighlightEnd.
+	Highlights map[string][]string
 }
 
 // Search searches for prompts matching the query.
 func (i *Indexer) Search(opts SearchOptions) ([]SearchResult, error) {
+	page, err := i.SearchPage(opts)
+	if err != nil {
+		return nil, err
+	}
+	return page.Results, nil
+}
+
+// SearchPage searches like Search and also returns the total number of
+// matches and, with SearchOptions.Facets, counts per source and tag.
+func (i *Indexer) SearchPage(opts SearchOptions) (*SearchPage, error) {
+	if err := ValidateSort(opts.Sort); err != nil {
+		return nil, err
+	}
+
+	if i.remote != nil {
+		// Syntax errors are reported here to keep their *QueryError type
+		if _, err := ParseQuery(opts.Query); err != nil {
+			return nil, err
+		}
+		var page SearchPage
+		if err := i.remote.call("search", opts, &page); err != nil {
+			return nil, err
+		}
+		return &page, nil
+	}
+
 	// Build query
-	q := i.buildQuery(opts)
+	q, err := i.buildQuery(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	docCount, err := i.docCount()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count documents: %w", err)
+	}
+
+	size := opts.MaxResults
+	if size <= 0 {
+		size = int(docCount)
+	}
+
+	// Results reordered after the search are fetched from the first one and
+	// paged here
+	from, window := opts.Offset, size
+	blend := blendsSemantic(opts)
+	reorder := opts.Collapse || opts.Sort == SortFrecency || blend
+	if reorder {
+		from, window = 0, opts.Offset+size
+	}
+	if opts.Collapse {
+		// Over-fetch so enough results remain after collapsing
+		window *= 3
+	}
+
+	searchOpts := opts
+	if opts.Sort == SortFrecency || blend {
+		// Scores are only comparable within one search, so every match is
+		// ranked; highlights are added for the returned page only
+		window = int(docCount)
+		searchOpts.Highlight = false
+	}
+
+	page, err := i.search(q, from, window, searchOpts, opts.Facets)
+	if err != nil {
+		return nil, err
+	}
+
+	if blend {
+		if err := i.rankBySemantic(opts, page.Results); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Sort == SortFrecency {
+		rankByFrecency(page.Results)
+	}
+
+	if opts.Collapse {
+		page.Results = CollapseDuplicates(page.Results, similarity.DefaultThreshold)
+	}
+
+	if reorder {
+		page.Results = pageResults(page.Results, opts.Offset, size)
+	}
+
+	if opts.Highlight && !searchOpts.Highlight {
+		if err := i.highlightResults(q, page.Results); err != nil {
+			return nil, err
+		}
+	}
+
+	return page, nil
+}
+
+// highlightResults fills in the highlights of results found without them.
+func (i *Indexer) highlightResults(q query.Query, results []SearchResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(results))
+	for idx, result := range results {
+		ids[idx] = result.Prompt.ID
+	}
+
+	searchReq := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(q, bleve.NewDocIDQuery(ids)), len(ids), 0, false)
+	searchReq.Highlight = bleve.NewHighlightWithStyle(snippetHighlighter)
+	searchReq.Highlight.Fields = highlightFields
+
+	searchResults, err := i.searchAll(searchReq)
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	highlights := make(map[string]map[string][]string, len(searchResults.Hits))
+	for _, hit := range searchResults.Hits {
+		highlights[hit.ID] = matchedFragments(hit.Fragments)
+	}
+	for idx := range results {
+		results[idx].Highlights = highlights[results[idx].Prompt.ID]
+	}
+	return nil
+}
+
+// pageResults returns up to size results starting at offset.
+func pageResults(results []SearchResult, offset, size int) []SearchResult {
+	if offset >= len(results) {
+		return nil
+	}
+	results = results[offset:]
+	if len(results) > size {
+		results = results[:size]
+	}
+	return results
+}
 
+// search runs one search request and converts its hits.
+func (i *Indexer) search(q query.Query, from, size int, opts SearchOptions, facets bool) (*SearchPage, error) {
 	// Create search request
-	searchReq := bleve.NewSearchRequest(q)
-	searchReq.Size = opts.MaxResults
-	searchReq.Fields = []string{"*"} // Include all stored fields
+	searchReq := bleve.NewSearchRequestOptions(q, size, from, false)
+	searchReq.Fields = resultFields
+	if fields := sortFields(opts.Sort); fields != nil {
+		searchReq.SortBy(fields)
+	}
+	if opts.Highlight {
+		searchReq.Highlight = bleve.NewHighlightWithStyle(snippetHighlighter)
+		searchReq.Highlight.Fields = highlightFields
+	}
 
-	// Add facets for source and tags
-	searchReq.AddFacet("sources", bleve.NewFacetRequest("source_id", 10))
-	searchReq.AddFacet("tags", bleve.NewFacetRequest("tags", 20))
+	// Add facets for sources and tags
+	if facets {
+		searchReq.AddFacet(FacetSources, bleve.NewFacetRequest("source_id", facetSize))
+		searchReq.AddFacet(FacetTags, bleve.NewFacetRequest("tags", facetSize))
+		searchReq.AddFacet(FacetUserTags, bleve.NewFacetRequest("user_tags", facetSize))
+	}
 
 	// Execute search
-	searchResults, err := i.index.Search(searchReq)
+	searchResults, err := i.searchAll(searchReq)
 	if err != nil {
 		return nil, fmt.Errorf("search failed: %w", err)
 	}
 
+	page := &SearchPage{
+		Results: make([]SearchResult, 0, len(searchResults.Hits)),
+		Total:   int(searchResults.Total),
+	}
+
 	// Convert results to SearchResult slice
-	results := make([]SearchResult, 0, len(searchResults.Hits))
 	for _, hit := range searchResults.Hits {
 		prompt, err := i.hitToPrompt(hit)
 		if err != nil {
@@ -66,31 +294,58 @@ func (i *Indexer) Search(opts SearchOptions) ([]SearchResult, error) {
 			continue
 		}
 
-		results = append(results, SearchResult{
-			Prompt: prompt,
-			Score:  hit.Score,
+		page.Results = append(page.Results, SearchResult{
+			Prompt:       prompt,
+			Score:        hit.Score,
+			UserMetadata: hitToUserMetadata(hit),
+			Highlights:   matchedFragments(hit.Fragments),
 		})
 	}
 
-	return results, nil
+	if facets {
+		page.Facets = make(map[string][]FacetCount, len(searchResults.Facets))
+		for name, facet := range searchResults.Facets {
+			counts := []FacetCount{}
+			if facet.Terms != nil {
+				for _, term := range facet.Terms.Terms() {
+					counts = append(counts, FacetCount{Term: term.Term, Count: term.Count})
+				}
+			}
+			page.Facets[name] = counts
+		}
+	}
+
+	return page, nil
 }
 
 // buildQuery builds a bleve query from search options.
-func (i *Indexer) buildQuery(opts SearchOptions) query.Query {
+// Returns a *QueryError when the query string is not valid query syntax.
+func (i *Indexer) buildQuery(opts SearchOptions) (query.Query, error) {
 	var queries []query.Query
 
 	// Main query string
-	if opts.Query != "" {
-		if opts.Fuzzy {
-			// Use fuzzy match query for typo tolerance
-			fuzzyQuery := bleve.NewFuzzyQuery(opts.Query)
-			fuzzyQuery.Fuzziness = 1 // Allow 1 character difference
-			queries = append(queries, fuzzyQuery)
-		} else {
-			// Use match query for standard search
-			matchQuery := bleve.NewMatchQuery(opts.Query)
-			queries = append(queries, matchQuery)
+	expr, err := ParseQuery(opts.Query)
+	if err != nil {
+		return nil, err
+	}
+	if expr != nil {
+		compileOpts := compileOptions{
+			fuzzy:         opts.Fuzzy,
+			caseSensitive: opts.CaseSensitive,
+			mapping:       i.index.Mapping(),
+			analyzers:     i.indexedAnalyzers(),
+		}
+		if opts.Prefix && !endsWithSpace(opts.Query) {
+			compileOpts.prefix = lastTerm(expr)
+		}
+		if opts.CaseSensitive && compileOpts.mapping.AnalyzerNamed(exactAnalyzer) == nil {
+			return nil, fmt.Errorf("case-sensitive search needs a newer index, run 'pkit reindex'")
 		}
+		text := compileQuery(expr, compileOpts)
+		if opts.Semantic != nil {
+			text = semanticQuery(text, opts.Semantic)
+		}
+		queries = append(queries, text)
 	}
 
 	// Source filter
@@ -100,24 +355,38 @@ func (i *Indexer) buildQuery(opts SearchOptions) query.Query {
 		queries = append(queries, sourceQuery)
 	}
 
-	// Tags filter
+	// Tags filter (source-provided or user tags)
 	if len(opts.Tags) > 0 {
 		for _, tag := range opts.Tags {
-			tagQuery := bleve.NewMatchQuery(tag)
-			tagQuery.SetField("tags")
-			queries = append(queries, tagQuery)
+			queries = append(queries, tagQuery(tag))
 		}
 	}
 
+	// Bookmarked filter
+	if opts.Bookmarked {
+		bookmarkedQuery := bleve.NewBoolFieldQuery(true)
+		bookmarkedQuery.SetField("bookmarked")
+		queries = append(queries, bookmarkedQuery)
+	}
+
+	// Token count filter
+	if opts.MaxTokens > 0 {
+		maxTokens := float64(opts.MaxTokens)
+		inclusive := true
+		tokensQuery := bleve.NewNumericRangeInclusiveQuery(nil, &maxTokens, nil, &inclusive)
+		tokensQuery.SetField("token_count")
+		queries = append(queries, tokensQuery)
+	}
+
 	// Combine all queries with AND logic
 	if len(queries) == 0 {
 		// No filters, return all documents
-		return bleve.NewMatchAllQuery()
+		return bleve.NewMatchAllQuery(), nil
 	} else if len(queries) == 1 {
-		return queries[0]
+		return queries[0], nil
 	} else {
 		conjunctionQuery := bleve.NewConjunctionQuery(queries...)
-		return conjunctionQuery
+		return conjunctionQuery, nil
 	}
 }
 
@@ -143,11 +412,19 @@ func (i *Indexer) hitToPrompt(hit *search.DocumentMatch) (models.Prompt, error)
 	if val, ok := hit.Fields["author"].(string); ok {
 		prompt.Author = val
 	}
-
-	// Content is NOT stored in index - it's loaded dynamically from source files when needed
-	// The content field may still be in hit.Fields but will be empty
-	if val, ok := hit.Fields["content"].(string); ok {
-		prompt.Content = val
+	if val, ok := hit.Fields["language"].(string); ok {
+		prompt.Language = val
+	}
+	if val, ok := hit.Fields["token_count"].(float64); ok {
+		prompt.TokenCount = int(val)
+	}
+	if val, ok := hit.Fields["fingerprint"].(string); ok {
+		prompt.Fingerprint = val
+	}
+	if val, ok := hit.Fields["updated_at"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, val); err == nil {
+			prompt.UpdatedAt = t
+		}
 	}
 
 	// Tags might be stored as interface{} slice
@@ -164,6 +441,47 @@ func (i *Indexer) hitToPrompt(hit *search.DocumentMatch) (models.Prompt, error)
 	return prompt, nil
 }
 
+// hitToUserMetadata extracts the user metadata stored with a hit.
+func hitToUserMetadata(hit *search.DocumentMatch) models.UserMetadata {
+	var meta models.UserMetadata
+
+	if val, ok := hit.Fields["bookmarked"].(bool); ok {
+		meta.Bookmarked = val
+	}
+	if val, ok := hit.Fields["notes"].(string); ok {
+		meta.Notes = val
+	}
+	if val, ok := hit.Fields["usage_count"].(float64); ok {
+		meta.UsageCount = int(val)
+	}
+	if val, ok := hit.Fields["last_used_at"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, val); err == nil {
+			meta.LastUsedAt = &t
+		}
+	}
+	meta.UserTags = stringsField(hit.Fields["user_tags"])
+	meta.Aliases = stringsField(hit.Fields["aliases"])
+
+	return meta
+}
+
+// stringsField reads a stored text field that may hold one or several values.
+func stringsField(val interface{}) []string {
+	switch v := val.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
 // SearchBySource returns all prompts from a specific source.
 func (i *Indexer) SearchBySource(sourceID string, maxResults int) ([]SearchResult, error) {
 	return i.Search(SearchOptions{
@@ -182,13 +500,21 @@ func (i *Indexer) SearchByTag(tag string, maxResults int) ([]SearchResult, error
 
 // GetPromptByID retrieves a specific prompt by ID.
 func (i *Indexer) GetPromptByID(promptID string) (*models.Prompt, error) {
+	if i.remote != nil {
+		var prompt models.Prompt
+		if err := i.remote.call("prompt", promptRequest{ID: promptID}, &prompt); err != nil {
+			return nil, err
+		}
+		return &prompt, nil
+	}
+
 	// Use search to get the document instead of Document() which may not exist
 	query := bleve.NewDocIDQuery([]string{promptID})
 	searchReq := bleve.NewSearchRequest(query)
 	searchReq.Size = 1
-	searchReq.Fields = []string{"*"}
+	searchReq.Fields = resultFields
 
-	searchResults, err := i.index.Search(searchReq)
+	searchResults, err := i.searchAll(searchReq)
 	if err != nil {
 		return nil, fmt.Errorf("search failed: %w", err)
 	}
@@ -0,0 +1,117 @@
+package index
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// SemanticOptions blends embedding similarity into a search.
+type SemanticOptions struct {
+	// Cosine similarity of the query embedding to each prompt, by prompt ID
+	Similarities map[string]float64
+
+	// Share of the score taken from similarity (0 to 1); the rest comes from
+	// the keyword score
+	Weight float64
+}
+
+// semanticCandidates is how many of the most similar prompts are matched even
+// when they share no words with the query.
+const semanticCandidates = 50
+
+// candidates returns the IDs of the prompts most similar to the query.
+func (s *SemanticOptions) candidates() []string {
+	ids := make([]string, 0, len(s.Similarities))
+	for id, similarity := range s.Similarities {
+		if similarity > 0 {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(a, b int) bool {
+		if s.Similarities[ids[a]] != s.Similarities[ids[b]] {
+			return s.Similarities[ids[a]] > s.Similarities[ids[b]]
+		}
+		return ids[a] < ids[b]
+	})
+	if len(ids) > semanticCandidates {
+		ids = ids[:semanticCandidates]
+	}
+	return ids
+}
+
+// semanticQuery widens a keyword query to the prompts most similar to it.
+func semanticQuery(keyword query.Query, opts *SemanticOptions) query.Query {
+	ids := opts.candidates()
+	if len(ids) == 0 {
+		return keyword
+	}
+	return bleve.NewDisjunctionQuery(keyword, bleve.NewDocIDQuery(ids))
+}
+
+// blendsSemantic reports whether results are ranked by blended score; other
+// sort orders only take the extra candidates.
+func blendsSemantic(opts SearchOptions) bool {
+	return opts.Semantic != nil && (opts.Sort == "" || opts.Sort == SortScore || opts.Sort == SortFrecency)
+}
+
+// rankBySemantic rescores results by blending their keyword score, normalized
+// to the best one, with their similarity to the query, and sorts them best
+// first.
+func (i *Indexer) rankBySemantic(opts SearchOptions, results []SearchResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+
+	// Candidate scores are inflated by the ID match, so keyword scores come
+	// from the keyword query alone
+	keywordOpts := opts
+	keywordOpts.Semantic = nil
+	keyword, err := i.buildQuery(keywordOpts)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, len(results))
+	for idx, result := range results {
+		ids[idx] = result.Prompt.ID
+	}
+
+	// A filter leaves the keyword scores as they are
+	q := bleve.NewBooleanQuery()
+	q.AddMust(keyword)
+	q.AddFilter(bleve.NewDocIDQuery(ids))
+
+	searchReq := bleve.NewSearchRequestOptions(q, len(ids), 0, false)
+	searchResults, err := i.searchAll(searchReq)
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	scores := make(map[string]float64, len(searchResults.Hits))
+	maxScore := 0.0
+	for _, hit := range searchResults.Hits {
+		scores[hit.ID] = hit.Score
+		if hit.Score > maxScore {
+			maxScore = hit.Score
+		}
+	}
+
+	weight := opts.Semantic.Weight
+	for idx := range results {
+		score := 0.0
+		if maxScore > 0 {
+			score = scores[results[idx].Prompt.ID] / maxScore
+		}
+		similarity := max(opts.Semantic.Similarities[results[idx].Prompt.ID], 0)
+		results[idx].Score = (1-weight)*score + weight*similarity
+	}
+
+	sort.SliceStable(results, func(a, b int) bool {
+		return results[a].Score > results[b].Score
+	})
+	return nil
+}
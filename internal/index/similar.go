@@ -0,0 +1,230 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/whisller/pkit/internal/language"
+)
+
+// SimilarOptions configures a "more like this" search.
+type SimilarOptions struct {
+	// Maximum number of results
+	MaxResults int
+
+	// Only prompts from sources other than the prompt's own
+	OtherSources bool
+}
+
+// similarTerms is how many of a prompt's most significant terms are searched for.
+const similarTerms = 25
+
+// minSimilarTermLength skips terms too short to say much about a prompt.
+const minSimilarTermLength = 3
+
+// similarFields are the stored fields a prompt's terms are taken from.
+var similarFields = []string{"name", "description", "content"}
+
+// weightedTerm is a term with its tf-idf weight in a piece of text.
+type weightedTerm struct {
+	term   string
+	weight float64
+}
+
+// Similar returns the prompts most like promptID ("more like this"): a search
+// for the prompt's most significant terms, each boosted by its tf-idf weight.
+// The prompt itself is never included.
+func (i *Indexer) Similar(promptID string, opts SimilarOptions) ([]SearchResult, error) {
+	if i.remote != nil {
+		var results []SearchResult
+		err := i.remote.call("similar", similarRequest{ID: promptID, Opts: opts}, &results)
+		return results, err
+	}
+
+	searchReq := bleve.NewSearchRequest(bleve.NewDocIDQuery([]string{promptID}))
+	searchReq.Size = 1
+	searchReq.Fields = append([]string{"source_id", "language"}, similarFields...)
+
+	searchResults, err := i.searchAll(searchReq)
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	if len(searchResults.Hits) == 0 {
+		return nil, fmt.Errorf("prompt not found: %s", promptID)
+	}
+	hit := searchResults.Hits[0]
+
+	var text strings.Builder
+	for _, field := range similarFields {
+		if value, ok := hit.Fields[field].(string); ok {
+			text.WriteString(value)
+			text.WriteString("\n")
+		}
+	}
+
+	// Terms only this prompt has can't match any other
+	lang, _ := hit.Fields["language"].(string)
+	terms, err := i.significantTerms(text.String(), languageAnalyzer(lang), similarTerms, 2)
+	if err != nil {
+		return nil, err
+	}
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	exclude := []query.Query{bleve.NewDocIDQuery([]string{promptID})}
+	if sourceID, ok := hit.Fields["source_id"].(string); ok && opts.OtherSources {
+		q := bleve.NewTermQuery(sourceID)
+		q.SetField("source_id")
+		exclude = append(exclude, q)
+	}
+
+	q := query.NewBooleanQuery([]query.Query{bleve.NewDisjunctionQuery(termQueries(terms)...)}, nil, exclude)
+	page, err := i.search(q, 0, opts.MaxResults, SearchOptions{}, false)
+	if err != nil {
+		return nil, err
+	}
+	return page.Results, nil
+}
+
+// hintBoost weighs the hint words of Suggest against the input's own terms,
+// the best of which has a boost of 1.
+const hintBoost = 2.0
+
+// Suggest ranks prompts for input text such as a diff, a log or an article: a
+// search for the text's most significant terms plus hint words saying what
+// kind of text it is (see the suggest package).
+func (i *Indexer) Suggest(text string, hints []string, maxResults int) ([]SearchResult, error) {
+	if i.remote != nil {
+		var results []SearchResult
+		err := i.remote.call("suggest", suggestRequest{Text: text, Hints: hints, MaxResults: maxResults}, &results)
+		return results, err
+	}
+
+	terms, err := i.significantTerms(text, languageAnalyzer(language.Detect(text)), similarTerms, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	should := termQueries(terms)
+	for _, hint := range hints {
+		var q query.Query
+		if strings.Contains(hint, " ") {
+			phrase := bleve.NewMatchPhraseQuery(hint)
+			phrase.Analyzer = queryAnalyzer
+			phrase.SetBoost(hintBoost)
+			q = phrase
+		} else {
+			match := bleve.NewMatchQuery(hint)
+			match.Analyzer = queryAnalyzer
+			match.SetBoost(hintBoost)
+			q = match
+		}
+		should = append(should, q)
+	}
+	if len(should) == 0 {
+		return nil, nil
+	}
+
+	page, err := i.search(bleve.NewDisjunctionQuery(should...), 0, maxResults, SearchOptions{}, false)
+	if err != nil {
+		return nil, err
+	}
+	return page.Results, nil
+}
+
+// termQueries searches for weighted terms, boosted relative to the first
+// (best) one.
+func termQueries(terms []weightedTerm) []query.Query {
+	queries := make([]query.Query, len(terms))
+	for idx, t := range terms {
+		q := bleve.NewTermQuery(t.term)
+		q.SetBoost(t.weight / terms[0].weight)
+		queries[idx] = q
+	}
+	return queries
+}
+
+// significantTerms returns up to n terms of text, analyzed with analyzerName,
+// that best tell it apart from other prompts, highest tf-idf weight first.
+// Terms fewer than minDocFreq prompts have are skipped.
+func (i *Indexer) significantTerms(text, analyzerName string, n int, minDocFreq uint64) ([]weightedTerm, error) {
+	analyzer := i.index.Mapping().AnalyzerNamed(analyzerName)
+	if analyzer == nil {
+		return nil, fmt.Errorf("analyzer %s not found", analyzerName)
+	}
+
+	freqs := make(map[string]int)
+	for _, token := range analyzer.Analyze([]byte(text)) {
+		if utf8.RuneCount(token.Term) >= minSimilarTermLength {
+			freqs[string(token.Term)]++
+		}
+	}
+
+	docCount, err := i.docCount()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count documents: %w", err)
+	}
+
+	docFreqs, err := i.termDocFreqs(freqs)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := make([]weightedTerm, 0, len(freqs))
+	for term, freq := range freqs {
+		docFreq := docFreqs[term]
+		if docFreq == 0 || docFreq < minDocFreq {
+			continue
+		}
+		idf := 1 + math.Log(float64(docCount)/float64(docFreq))
+		terms = append(terms, weightedTerm{term: term, weight: math.Sqrt(float64(freq)) * idf})
+	}
+
+	sort.Slice(terms, func(a, b int) bool {
+		if terms[a].weight != terms[b].weight {
+			return terms[a].weight > terms[b].weight
+		}
+		return terms[a].term < terms[b].term
+	})
+	if len(terms) > n {
+		terms = terms[:n]
+	}
+	return terms, nil
+}
+
+// termDocFreqs returns how many prompts of all sources have each of terms.
+func (i *Indexer) termDocFreqs(terms map[string]int) (map[string]uint64, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	docFreqs := make(map[string]uint64, len(terms))
+	for _, index := range i.sourceIndexes() {
+		advanced, err := index.Advanced()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open index reader: %w", err)
+		}
+		reader, err := advanced.Reader()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open index reader: %w", err)
+		}
+
+		for term := range terms {
+			termReader, err := reader.TermFieldReader(context.Background(), []byte(term), "_all", false, false, false)
+			if err != nil {
+				_ = reader.Close()
+				return nil, fmt.Errorf("failed to read term %s: %w", term, err)
+			}
+			docFreqs[term] += termReader.Count()
+			_ = termReader.Close()
+		}
+		_ = reader.Close()
+	}
+	return docFreqs, nil
+}
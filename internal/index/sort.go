@@ -0,0 +1,96 @@
+package index
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/whisller/pkit/pkg/models"
+)
+
+// Sort orders for SearchOptions.Sort.
+const (
+	SortScore    = "score"    // Best match first (default)
+	SortUsage    = "usage"    // Most used first
+	SortRecent   = "recent"   // Most recently used first
+	SortUpdated  = "updated"  // Most recently updated in the source first
+	SortName     = "name"     // Alphabetical by name
+	SortFrecency = "frecency" // Best match boosted by how often and how recently it was used
+)
+
+// SortOrders lists the valid sort orders.
+var SortOrders = []string{SortScore, SortUsage, SortRecent, SortUpdated, SortName, SortFrecency}
+
+// frecencyBoost scales how much frecency raises a prompt's score.
+const frecencyBoost = 0.5
+
+// ValidateSort returns an error for an unknown sort order. "" means SortScore.
+func ValidateSort(order string) error {
+	if order == "" {
+		return nil
+	}
+	for _, valid := range SortOrders {
+		if order == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid sort '%s' (use %s)", order, strings.Join(SortOrders, ", "))
+}
+
+// sortFields returns the index sort for an order, or nil to sort by score.
+// Ties are broken by score, then by name.
+func sortFields(order string) []string {
+	switch order {
+	case SortUsage:
+		return []string{"-usage_count", "-_score", "name_sort"}
+	case SortRecent:
+		return []string{"-last_used_at", "-_score", "name_sort"}
+	case SortUpdated:
+		return []string{"-updated_at", "-_score", "name_sort"}
+	case SortName:
+		return []string{"name_sort", "_id"}
+	case SortFrecency:
+		return []string{"-_score", "name_sort"}
+	default:
+		return nil
+	}
+}
+
+// Frecency rates how much a prompt is used: its usage count, weighted by how
+// long ago it was last used.
+func Frecency(meta models.UserMetadata, now time.Time) float64 {
+	if meta.UsageCount == 0 {
+		return 0
+	}
+
+	weight := 0.1
+	if meta.LastUsedAt != nil {
+		switch age := now.Sub(*meta.LastUsedAt); {
+		case age < 4*24*time.Hour:
+			weight = 1
+		case age < 14*24*time.Hour:
+			weight = 0.7
+		case age < 31*24*time.Hour:
+			weight = 0.5
+		case age < 90*24*time.Hour:
+			weight = 0.3
+		}
+	}
+
+	return float64(meta.UsageCount) * weight
+}
+
+// frecencyScore blends a search score with the prompt's frecency.
+func frecencyScore(result SearchResult, now time.Time) float64 {
+	return result.Score * (1 + frecencyBoost*math.Log1p(Frecency(result.UserMetadata, now)))
+}
+
+// rankByFrecency orders results by their score boosted by frecency.
+func rankByFrecency(results []SearchResult) {
+	now := time.Now()
+	sort.SliceStable(results, func(i, j int) bool {
+		return frecencyScore(results[i], now) > frecencyScore(results[j], now)
+	})
+}
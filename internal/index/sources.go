@@ -0,0 +1,590 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/whisller/pkit/pkg/models"
+)
+
+// Every source has its own bleve index in the index directory, so a source is
+// rebuilt, swapped or dropped without touching the others. Searches go through
+// an index alias over all of them.
+
+// sourceIndexExt is the extension of source index directories.
+const sourceIndexExt = ".bleve"
+
+// SourceError is returned when the index of one source can't be opened, such as
+// when it is corrupted. Deleting it with DeleteSourceIndex and reindexing the
+// source repairs it.
+type SourceError struct {
+	SourceID string
+	Err      error
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("failed to open the search index of %s: %v (run 'pkit reindex --source %s')", e.SourceID, e.Err, e.SourceID)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// sourceIndexPath returns the path of a source's index. The '/' of owner/repo
+// source IDs is stored as '_', which source IDs can't contain.
+func sourceIndexPath(indexPath, sourceID string) string {
+	return filepath.Join(indexPath, strings.ReplaceAll(sourceID, "/", "_")+sourceIndexExt)
+}
+
+// indexSourceID returns the source ID of a source index directory name, and
+// false when it is not one.
+func indexSourceID(name string) (string, bool) {
+	base, ok := strings.CutSuffix(name, sourceIndexExt)
+	if !ok {
+		return "", false
+	}
+	return strings.ReplaceAll(base, "_", "/"), true
+}
+
+// promptSourceID returns the source part of a prompt ID.
+func promptSourceID(promptID string) string {
+	sourceID, _, _ := strings.Cut(promptID, ":")
+	return sourceID
+}
+
+// DeleteSourceIndex removes the index of one source from disk.
+func DeleteSourceIndex(indexPath, sourceID string) error {
+	return os.RemoveAll(sourceIndexPath(indexPath, sourceID))
+}
+
+// openIndex opens the indexes of all sources in the index directory, creating
+// it when missing, with the given bleve runtime config.
+func openIndex(indexPath string, runtimeConfig map[string]interface{}, readOnly bool) (*Indexer, error) {
+	if err := checkLayout(indexPath); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(indexPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create index directory: %w", err)
+	}
+	entries, err := os.ReadDir(indexPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index directory: %w", err)
+	}
+	if !readOnly {
+		if err := recoverMoves(indexPath, entries); err != nil {
+			return nil, err
+		}
+		if entries, err = os.ReadDir(indexPath); err != nil {
+			return nil, fmt.Errorf("failed to read index directory: %w", err)
+		}
+	}
+
+	indexMapping, err := buildIndexMapping()
+	if err != nil {
+		return nil, err
+	}
+	alias := bleve.NewIndexAlias()
+	if err := alias.SetIndexMapping(indexMapping); err != nil {
+		return nil, err
+	}
+	indexer := &Indexer{
+		index:    alias,
+		path:     indexPath,
+		sources:  make(map[string]bleve.Index),
+		readOnly: readOnly,
+	}
+
+	for _, entry := range entries {
+		sourceID, ok := indexSourceID(entry.Name())
+		if !ok || !entry.IsDir() {
+			continue
+		}
+
+		index, err := bleve.OpenUsing(sourceIndexPath(indexPath, sourceID), runtimeConfig)
+		if errors.Is(err, bolt.ErrTimeout) {
+			_ = indexer.closeSources()
+			return nil, fmt.Errorf("failed to open index (is it in use by another pkit process?): %w", err)
+		}
+		if err != nil {
+			_ = indexer.closeSources()
+			return nil, &SourceError{SourceID: sourceID, Err: err}
+		}
+		if err := checkSchema(index, indexPath); err != nil {
+			_ = index.Close()
+			_ = indexer.closeSources()
+			return nil, err
+		}
+		indexer.sources[sourceID] = index
+	}
+
+	for _, index := range indexer.sources {
+		indexer.index.Add(index)
+	}
+	if len(indexer.sources) == 0 {
+		// Searching an alias of no index fails, searching an empty one finds nothing
+		if err := indexer.addEmpty(); err != nil {
+			return nil, err
+		}
+	}
+
+	return indexer, nil
+}
+
+// checkLayout returns a *SchemaError for an index directory holding a single
+// index of all sources, as built before sources had an index each.
+func checkLayout(indexPath string) error {
+	if _, err := os.Stat(filepath.Join(indexPath, "index_meta.json")); err != nil {
+		return nil
+	}
+
+	schemaErr := &SchemaError{Path: indexPath}
+	index, err := bleve.OpenUsing(indexPath, map[string]interface{}{
+		"read_only":    true,
+		"bolt_timeout": pingTimeout.String(),
+	})
+	if err == nil {
+		schemaErr.Version, schemaErr.BuildVersion, _ = readVersions(index)
+		_ = index.Close()
+	}
+	return schemaErr
+}
+
+// addEmpty adds an empty in-memory index to the alias, for while no source has
+// an index.
+func (i *Indexer) addEmpty() error {
+	empty, err := bleve.NewMemOnly(i.index.Mapping())
+	if err != nil {
+		return fmt.Errorf("failed to create empty index: %w", err)
+	}
+	i.empty = empty
+	i.index.Add(empty)
+	return nil
+}
+
+// sourceIndex returns the index of a source, creating it when it has none.
+func (i *Indexer) sourceIndex(sourceID string) (bleve.Index, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if index, ok := i.sources[sourceID]; ok {
+		return index, nil
+	}
+
+	index, err := i.createIndex(sourceIndexPath(i.path, sourceID))
+	if err != nil {
+		return nil, err
+	}
+	i.addSource(sourceID, index)
+	return index, nil
+}
+
+// createIndex creates an empty index with the current mapping and versions.
+func (i *Indexer) createIndex(path string) (bleve.Index, error) {
+	index, err := bleve.New(path, i.index.Mapping())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create index: %w", err)
+	}
+	if err := writeVersions(index); err != nil {
+		_ = index.Close()
+		_ = os.RemoveAll(path)
+		return nil, err
+	}
+	return index, nil
+}
+
+// addSource adds the index of a source to the alias, replacing the empty
+// index. The caller holds i.mu.
+func (i *Indexer) addSource(sourceID string, index bleve.Index) {
+	i.sources[sourceID] = index
+	if i.empty != nil {
+		i.index.Swap([]bleve.Index{index}, []bleve.Index{i.empty})
+		_ = i.empty.Close()
+		i.empty = nil
+		return
+	}
+	i.index.Add(index)
+}
+
+// sourceIndexes returns the indexes of all sources, ordered by source ID. The
+// caller holds i.mu while it uses them, so none is swapped or closed meanwhile.
+func (i *Indexer) sourceIndexes() []bleve.Index {
+	ids := make([]string, 0, len(i.sources))
+	for sourceID := range i.sources {
+		ids = append(ids, sourceID)
+	}
+	sort.Strings(ids)
+
+	indexes := make([]bleve.Index, len(ids))
+	for n, sourceID := range ids {
+		indexes[n] = i.sources[sourceID]
+	}
+	return indexes
+}
+
+// sourceBatchSize is how many prompts a SourceWriter indexes per batch.
+const sourceBatchSize = 500
+
+// SourceWriter builds a new index of one source from prompts added one at a
+// time and swaps it in for the source's old index on Commit, so searches see
+// either all old or all new prompts and the documents of a source are never
+// held in memory whole. Prompts are reported to Changed once Commit swapped
+// them in. When another process serves the index, prompts are sent to it on
+// Commit.
+type SourceWriter struct {
+	indexer  *Indexer
+	sourceID string
+	meta     map[string]models.UserMetadata
+
+	// New index and the prompts not written to it yet
+	index   bleve.Index
+	batched []models.Prompt
+
+	// IDs of all prompts added
+	ids map[string]bool
+
+	// Prompts added, for Changed or the serving process
+	prompts []models.Prompt
+}
+
+// NewSourceWriter starts a new index of a source, written with the user
+// metadata set by SetUserMetadata. Either Commit or Abort must be called.
+func (i *Indexer) NewSourceWriter(sourceID string) (*SourceWriter, error) {
+	return i.newSourceWriter(sourceID, i.userMeta)
+}
+
+// newSourceWriter is NewSourceWriter with the user metadata to index prompts with.
+func (i *Indexer) newSourceWriter(sourceID string, meta map[string]models.UserMetadata) (*SourceWriter, error) {
+	w := &SourceWriter{indexer: i, sourceID: sourceID, meta: meta, ids: make(map[string]bool)}
+	if i.remote != nil {
+		return w, nil
+	}
+	if i.readOnly {
+		return nil, ErrReadOnly
+	}
+
+	newPath := sourceIndexPath(i.path, sourceID) + ".new"
+	_ = os.RemoveAll(newPath)
+	index, err := i.createIndex(newPath)
+	if err != nil {
+		return nil, err
+	}
+	w.index = index
+	return w, nil
+}
+
+// Add adds a prompt to the new index.
+func (w *SourceWriter) Add(prompt models.Prompt) error {
+	w.ids[prompt.ID] = true
+	w.prompts = append(w.prompts, prompt)
+	if w.indexer.remote != nil {
+		return nil
+	}
+
+	w.batched = append(w.batched, prompt)
+	if len(w.batched) >= sourceBatchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// Count returns how many prompts were added.
+func (w *SourceWriter) Count() int {
+	return len(w.ids)
+}
+
+// flush writes the pending batch to the new index.
+func (w *SourceWriter) flush() error {
+	if len(w.batched) == 0 {
+		return nil
+	}
+
+	batch := w.index.NewBatch()
+	for n, doc := range newDocuments(w.batched, w.meta) {
+		if err := batch.Index(w.batched[n].ID, doc); err != nil {
+			return fmt.Errorf("failed to add prompt %s to batch: %w", w.batched[n].ID, err)
+		}
+	}
+	if err := w.index.Batch(batch); err != nil {
+		return fmt.Errorf("failed to commit batch: %w", err)
+	}
+
+	w.batched = nil
+	return nil
+}
+
+// newDocuments builds the documents of prompts on all CPUs, as counting tokens
+// and fingerprinting content takes longer than indexing.
+func newDocuments(prompts []models.Prompt, meta map[string]models.UserMetadata) []document {
+	docs := make([]document, len(prompts))
+	workers := runtime.NumCPU()
+
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := n; idx < len(prompts); idx += workers {
+				docs[idx] = newDocument(prompts[idx], meta[prompts[idx].ID])
+			}
+		}()
+	}
+	wg.Wait()
+	return docs
+}
+
+// Commit swaps the new index in for the source's old one, dropping prompts no
+// longer in the source. The new index is moved into place and opened before it
+// replaces the old one in the alias, all under i.mu, which searches wait on, so
+// no search finds the source missing. When that fails the old index is put back.
+func (w *SourceWriter) Commit() error {
+	i := w.indexer
+	if i.remote != nil {
+		return i.remote.call("reindex-source", changesRequest{
+			SourceID:     w.sourceID,
+			Prompts:      w.prompts,
+			UserMetadata: promptsMetadata(w.prompts, w.meta),
+		}, nil)
+	}
+
+	path := sourceIndexPath(i.path, w.sourceID)
+	newPath := path + ".new"
+	oldPath := path + ".old"
+
+	if err := w.flush(); err != nil {
+		w.Abort()
+		return err
+	}
+	// The index is reopened at its final path
+	err := w.index.Close()
+	w.index = nil
+	if err != nil {
+		_ = os.RemoveAll(newPath)
+		return fmt.Errorf("failed to close new index: %w", err)
+	}
+
+	i.mu.Lock()
+	old, hadOld := i.sources[w.sourceID]
+	var oldIDs []string
+	if hadOld {
+		oldIDs, err = indexDocIDs(old)
+		if err != nil {
+			i.mu.Unlock()
+			_ = os.RemoveAll(newPath)
+			return err
+		}
+		// bleve deletes files it no longer needs from the directory it was
+		// opened at, so the old index is closed before another takes its place
+		_ = old.Close()
+	}
+
+	index, err := moveIntoPlace(path, newPath, oldPath)
+	if err != nil {
+		if hadOld {
+			i.restoreSource(w.sourceID, old, path)
+		}
+		i.mu.Unlock()
+		return &SourceError{SourceID: w.sourceID, Err: err}
+	}
+	if hadOld {
+		i.index.Swap([]bleve.Index{index}, []bleve.Index{old})
+		i.sources[w.sourceID] = index
+	} else {
+		i.addSource(w.sourceID, index)
+	}
+	i.mu.Unlock()
+	_ = os.RemoveAll(oldPath)
+
+	var deleted []string
+	for _, id := range oldIDs {
+		if !w.ids[id] {
+			deleted = append(deleted, id)
+		}
+	}
+	if len(w.prompts) > 0 || len(deleted) > 0 {
+		notifyChanged(i.path, w.prompts, deleted)
+	}
+	return nil
+}
+
+// moveIntoPlace moves the index at newPath to path and opens it, moving the
+// index at path, if any, to oldPath. On failure the index at oldPath is moved
+// back to path.
+func moveIntoPlace(path, newPath, oldPath string) (bleve.Index, error) {
+	_ = os.RemoveAll(oldPath)
+	movedOld := true
+	if err := os.Rename(path, oldPath); err != nil {
+		if !os.IsNotExist(err) {
+			_ = os.RemoveAll(newPath)
+			return nil, fmt.Errorf("failed to move old index aside: %w", err)
+		}
+		movedOld = false
+	}
+	restoreOld := func() {
+		if movedOld {
+			_ = os.Rename(oldPath, path)
+		}
+	}
+
+	if err := os.Rename(newPath, path); err != nil {
+		_ = os.RemoveAll(newPath)
+		restoreOld()
+		return nil, fmt.Errorf("failed to move new index in place: %w", err)
+	}
+
+	index, err := bleve.OpenUsing(path, map[string]interface{}{
+		"bolt_timeout": lockTimeout.String(),
+	})
+	if err != nil {
+		_ = os.RemoveAll(path)
+		restoreOld()
+		return nil, fmt.Errorf("failed to open new index: %w", err)
+	}
+	return index, nil
+}
+
+// restoreSource reopens the index of a source at path in place of its closed
+// index old, after its new index failed to replace it. A source whose index
+// can't be reopened is left out of the alias. The caller holds i.mu.
+func (i *Indexer) restoreSource(sourceID string, old bleve.Index, path string) {
+	index, err := bleve.OpenUsing(path, map[string]interface{}{
+		"bolt_timeout": lockTimeout.String(),
+	})
+	if err == nil {
+		i.index.Swap([]bleve.Index{index}, []bleve.Index{old})
+		i.sources[sourceID] = index
+		return
+	}
+
+	i.index.Remove(old)
+	delete(i.sources, sourceID)
+	if len(i.sources) == 0 {
+		_ = i.addEmpty()
+	}
+}
+
+// recoverMoves finishes swaps of source indexes interrupted by the process
+// dying in Commit: an index moved aside to <id>.bleve.old is moved back when
+// no index took its place, and deleted otherwise.
+func recoverMoves(indexPath string, entries []os.DirEntry) error {
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), sourceIndexExt+".old")
+		if !ok || !entry.IsDir() {
+			continue
+		}
+		oldPath := filepath.Join(indexPath, entry.Name())
+		path := filepath.Join(indexPath, name+sourceIndexExt)
+
+		if _, err := os.Stat(path); err == nil {
+			_ = os.RemoveAll(oldPath)
+			continue
+		}
+		if err := os.Rename(oldPath, path); err != nil {
+			return fmt.Errorf("failed to restore index %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Abort drops the new index, keeping the source's old one.
+func (w *SourceWriter) Abort() {
+	if w.index == nil {
+		return
+	}
+	_ = w.index.Close()
+	w.index = nil
+	_ = os.RemoveAll(sourceIndexPath(w.indexer.path, w.sourceID) + ".new")
+}
+
+// dropSource closes and deletes the index of a source, returning the IDs of
+// the prompts it held.
+func (i *Indexer) dropSource(sourceID string) ([]string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	index, ok := i.sources[sourceID]
+	if !ok {
+		return nil, nil
+	}
+	ids, err := indexDocIDs(index)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(i.sources, sourceID)
+	if len(i.sources) == 0 {
+		if err := i.addEmpty(); err != nil {
+			return nil, err
+		}
+	}
+	i.index.Remove(index)
+	if err := index.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close index of %s: %w", sourceID, err)
+	}
+	if err := DeleteSourceIndex(i.path, sourceID); err != nil {
+		return nil, fmt.Errorf("failed to delete index of %s: %w", sourceID, err)
+	}
+	return ids, nil
+}
+
+// indexDocIDs returns the IDs of all documents in an index.
+func indexDocIDs(index bleve.Index) ([]string, error) {
+	docCount, err := index.DocCount()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count documents: %w", err)
+	}
+
+	req := bleve.NewSearchRequest(bleve.NewMatchAllQuery())
+	req.Size = int(docCount)
+	results, err := index.Search(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	ids := make([]string, len(results.Hits))
+	for n, hit := range results.Hits {
+		ids[n] = hit.ID
+	}
+	return ids, nil
+}
+
+// closeSources closes the indexes of all sources.
+func (i *Indexer) closeSources() error {
+	var firstErr error
+	for _, index := range i.sources {
+		if err := index.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if i.empty != nil {
+		_ = i.empty.Close()
+	}
+	return firstErr
+}
+
+// searchAll runs a search across the indexes of all sources, scoring matches with
+// term statistics of all of them, so scores compare across sources.
+func (i *Indexer) searchAll(req *bleve.SearchRequest) (*bleve.SearchResult, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	ctx := context.WithValue(context.Background(), search.SearchTypeKey, search.GlobalScoring)
+	return i.index.SearchInContext(ctx, req)
+}
+
+// docCount returns the number of documents in the indexes of all sources.
+func (i *Indexer) docCount() (uint64, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.index.DocCount()
+}
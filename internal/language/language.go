@@ -0,0 +1,154 @@
+// Package language names the natural language prompts are written in and
+// recognises it from their text, so each prompt can be searched with the
+// stemming and stop words of its own language.
+package language
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Default is the language of prompts whose language is not set and can't be
+// recognised.
+const Default = "en"
+
+// Codes are the languages a source can be set to: ISO 639-1 codes, plus "cjk"
+// for mixed Chinese, Japanese and Korean text.
+var Codes = []string{
+	"ar", "cjk", "ckb", "da", "de", "en", "es", "fa", "fi", "fr", "hi", "hr",
+	"hu", "it", "ja", "ko", "nl", "no", "pl", "pt", "ro", "ru", "sv", "tr", "zh",
+}
+
+// Supported reports whether code is one of Codes.
+func Supported(code string) bool {
+	i := sort.SearchStrings(Codes, code)
+	return i < len(Codes) && Codes[i] == code
+}
+
+// maxDetectRunes bounds how much of a text Detect looks at.
+const maxDetectRunes = 5000
+
+// Shares of the letters of a text that have to be in a script for the text to
+// be taken as written in it. Chinese and Japanese prompts often quote English
+// terms, so a smaller share of Han and kana is enough.
+const (
+	minCJKShare    = 0.2
+	minScriptShare = 0.5
+)
+
+// minStopWords is how many stop words of a language other than English a text
+// needs, and more than it has English ones, to be taken as written in it.
+const minStopWords = 3
+
+// stopWords are frequent words that tell apart the languages written in
+// the Latin script.
+var stopWords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "that", "with", "for", "you", "are", "this", "your", "be", "it", "as"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ein", "eine", "mit", "sie", "ich", "zu", "den", "auf", "für", "wird", "sind", "oder", "wie"},
+	"fr": {"le", "la", "les", "et", "est", "une", "des", "du", "pour", "que", "qui", "dans", "vous", "pas", "sur", "avec"},
+	"es": {"el", "la", "los", "las", "y", "es", "una", "del", "que", "para", "por", "con", "en", "su", "como", "se"},
+	"it": {"il", "la", "che", "di", "e", "è", "un", "una", "per", "non", "sono", "con", "gli", "del", "della"},
+	"pt": {"o", "a", "os", "as", "e", "é", "um", "uma", "do", "da", "que", "para", "com", "não", "se", "em", "você"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "te", "zijn", "voor", "met", "je", "wordt"},
+	"pl": {"i", "w", "nie", "na", "się", "z", "że", "jest", "to", "do", "jak", "co", "dla"},
+	"sv": {"och", "att", "det", "är", "som", "en", "på", "för", "med", "inte", "har", "av", "jag", "du"},
+}
+
+// latinLanguages is the order ties between stop word counts are broken in.
+var latinLanguages = []string{"en", "de", "fr", "es", "it", "pt", "nl", "pl", "sv"}
+
+// stopWordLanguages maps each stop word to the languages it is one of.
+var stopWordLanguages = func() map[string][]string {
+	m := make(map[string][]string)
+	for _, code := range latinLanguages {
+		for _, word := range stopWords[code] {
+			m[word] = append(m[word], code)
+		}
+	}
+	return m
+}()
+
+// Detect returns the language text is most likely written in, Default when
+// it can't tell. Texts in the Chinese, Japanese, Korean, Cyrillic, Arabic and
+// Devanagari scripts are recognised by their script, texts in the Latin script
+// by their stop words.
+func Detect(text string) string {
+	var letters, han, kana, hangul, cyrillic, arabic, devanagari int
+	n := 0
+	for _, r := range text {
+		if n++; n > maxDetectRunes {
+			break
+		}
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		case unicode.Is(unicode.Devanagari, r):
+			devanagari++
+		}
+	}
+	if letters == 0 {
+		return Default
+	}
+
+	share := func(count int) float64 {
+		return float64(count) / float64(letters)
+	}
+	switch {
+	case kana > 0 && share(kana+han) >= minCJKShare:
+		return "ja"
+	case share(hangul) >= minCJKShare:
+		return "ko"
+	case share(han) >= minCJKShare:
+		return "zh"
+	case share(cyrillic) >= minScriptShare:
+		return "ru"
+	case share(arabic) >= minScriptShare:
+		return "ar"
+	case share(devanagari) >= minScriptShare:
+		return "hi"
+	}
+
+	return detectLatin(text)
+}
+
+// detectLatin tells the language of a text in the Latin script by which
+// language's stop words it uses most.
+func detectLatin(text string) string {
+	if len(text) > maxDetectRunes*2 {
+		text = text[:maxDetectRunes*2]
+	}
+
+	counts := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, word := range words {
+		for _, code := range stopWordLanguages[word] {
+			counts[code]++
+		}
+	}
+
+	best := Default
+	for _, code := range latinLanguages {
+		if counts[code] > counts[best] {
+			best = code
+		}
+	}
+	if best != Default && counts[best] < minStopWords {
+		return Default
+	}
+	return best
+}
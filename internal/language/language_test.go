@@ -0,0 +1,70 @@
+package language
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "english",
+			input: "You are an expert code reviewer. Review the code for bugs and explain each of them to the author.",
+			want:  "en",
+		},
+		{
+			name:  "german",
+			input: "Du bist ein erfahrener Übersetzer. Übersetze den folgenden Text ins Englische und erkläre die schwierigen Stellen, die nicht eindeutig sind.",
+			want:  "de",
+		},
+		{
+			name:  "french",
+			input: "Vous êtes un assistant qui résume les articles. Donnez les points clés dans une liste pour le lecteur.",
+			want:  "fr",
+		},
+		{
+			name:  "spanish",
+			input: "Eres un asistente que resume los artículos. Escribe una lista con los puntos clave para el lector.",
+			want:  "es",
+		},
+		{
+			name:  "japanese",
+			input: "あなたは経験豊富な翻訳者です。次の文章を英語に翻訳してください。Markdown の書式は保持してください。",
+			want:  "ja",
+		},
+		{
+			name:  "chinese",
+			input: "你是一位经验丰富的代码审查员。请审查以下代码并指出其中的错误。",
+			want:  "zh",
+		},
+		{
+			name:  "korean",
+			input: "당신은 숙련된 번역가입니다. 다음 글을 영어로 번역하세요.",
+			want:  "ko",
+		},
+		{
+			name:  "russian",
+			input: "Ты опытный редактор. Исправь ошибки в следующем тексте.",
+			want:  "ru",
+		},
+		{
+			name:  "too few stop words",
+			input: "Zusammenfassung der Artikel",
+			want:  "en",
+		},
+		{
+			name:  "no letters",
+			input: "1234 -- {}",
+			want:  "en",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.input); got != tt.want {
+				t.Errorf("Detect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
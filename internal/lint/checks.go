@@ -0,0 +1,256 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/whisller/pkit/internal/compose"
+	"github.com/whisller/pkit/internal/parser"
+)
+
+func init() {
+	Register(EmptyContentCheck{})
+	Register(FrontMatterCheck{})
+	Register(BrokenIncludeCheck{})
+	Register(UndefinedVarCheck{})
+	Register(DuplicateNameCheck{})
+	Register(EmptyDescriptionCheck{})
+	Register(TrailingWhitespaceCheck{})
+}
+
+// EmptyContentCheck reports prompts without any text.
+type EmptyContentCheck struct{}
+
+func (c EmptyContentCheck) Name() string        { return "empty-content" }
+func (c EmptyContentCheck) Description() string { return "Prompt has no content" }
+
+func (c EmptyContentCheck) Run(ctx *Context) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, t := range ctx.Targets {
+		if strings.TrimSpace(parser.StripFrontMatter(t.Prompt.Content)) == "" {
+			diagnostics = append(diagnostics, diagnostic(c, SeverityError, t, -1, "prompt has no content"))
+		}
+	}
+	return diagnostics
+}
+
+// FrontMatterCheck reports front matter blocks that are not valid YAML.
+type FrontMatterCheck struct{}
+
+func (c FrontMatterCheck) Name() string        { return "front-matter" }
+func (c FrontMatterCheck) Description() string { return "Front matter is not valid YAML" }
+
+func (c FrontMatterCheck) Run(ctx *Context) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, t := range ctx.Targets {
+		if _, _, err := parser.SplitFrontMatter(t.Prompt.Content); err != nil {
+			diagnostics = append(diagnostics, diagnostic(c, SeverityError, t, 0, err.Error()))
+		}
+	}
+	return diagnostics
+}
+
+// BrokenIncludeCheck reports include directives whose target does not exist
+// and includes that loop back to the prompt that contains them.
+type BrokenIncludeCheck struct{}
+
+func (c BrokenIncludeCheck) Name() string { return "broken-include" }
+func (c BrokenIncludeCheck) Description() string {
+	return "Include directive points to a missing prompt or forms a cycle"
+}
+
+func (c BrokenIncludeCheck) Run(ctx *Context) []Diagnostic {
+	var diagnostics []Diagnostic
+
+	// Include graph between prompts being linted, for cycle detection
+	byID := make(map[string]Target, len(ctx.Targets))
+	for _, t := range ctx.Targets {
+		byID[t.Prompt.ID] = t
+	}
+
+	for _, t := range ctx.Targets {
+		for _, inc := range compose.FindIncludes(t.Prompt.Content) {
+			promptID, ok := c.resolve(ctx, byID, inc.Target)
+			if !ok {
+				diagnostics = append(diagnostics, diagnostic(c, SeverityError, t, inc.Offset,
+					fmt.Sprintf("include target %q does not exist", inc.Target)))
+				continue
+			}
+
+			if path := c.cycle(ctx, byID, promptID, t.Prompt.ID, []string{t.Prompt.ID}); path != nil {
+				diagnostics = append(diagnostics, diagnostic(c, SeverityError, t, inc.Offset,
+					fmt.Sprintf("include cycle: %s", strings.Join(path, " -> "))))
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+// resolve maps an include target to a prompt ID, preferring prompts being linted.
+func (c BrokenIncludeCheck) resolve(ctx *Context, byID map[string]Target, target string) (string, bool) {
+	if _, ok := byID[target]; ok {
+		return target, true
+	}
+	if ctx.ResolveInclude != nil {
+		return ctx.ResolveInclude(target)
+	}
+	return "", false
+}
+
+// cycle follows includes from promptID and returns the path back to origin, if any.
+func (c BrokenIncludeCheck) cycle(ctx *Context, byID map[string]Target, promptID, origin string, path []string) []string {
+	path = append(path, promptID)
+	if promptID == origin {
+		return path
+	}
+	for _, seen := range path[:len(path)-1] {
+		if seen == promptID {
+			// Loop that does not involve origin; reported from its own members
+			return nil
+		}
+	}
+
+	t, ok := byID[promptID]
+	if !ok {
+		return nil
+	}
+	for _, inc := range compose.FindIncludes(t.Prompt.Content) {
+		next, ok := c.resolve(ctx, byID, inc.Target)
+		if !ok {
+			continue
+		}
+		if found := c.cycle(ctx, byID, next, origin, path); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// variablePattern matches template variables such as {{input}} or {{ lang_code }}.
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+
+// builtinVariables are filled in by pkit itself.
+var builtinVariables = map[string]bool{
+	strings.Trim(compose.InputPlaceholder, "{}"): true,
+}
+
+// UndefinedVarCheck reports {{variables}} that are neither built in nor declared
+// in front matter (as a "vars" list or map).
+type UndefinedVarCheck struct{}
+
+func (c UndefinedVarCheck) Name() string { return "undefined-var" }
+func (c UndefinedVarCheck) Description() string {
+	return "Template variable is not built in or declared in front matter"
+}
+
+func (c UndefinedVarCheck) Run(ctx *Context) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, t := range ctx.Targets {
+		frontMatter, _, _ := parser.SplitFrontMatter(t.Prompt.Content)
+		declared := declaredVariables(frontMatter)
+
+		reported := make(map[string]bool)
+		for _, loc := range variablePattern.FindAllStringSubmatchIndex(t.Prompt.Content, -1) {
+			name := t.Prompt.Content[loc[2]:loc[3]]
+			if builtinVariables[name] || declared[name] || reported[name] {
+				continue
+			}
+			reported[name] = true
+			diagnostics = append(diagnostics, diagnostic(c, SeverityWarning, t, loc[0],
+				fmt.Sprintf("variable {{%s}} is not defined (declare it under \"vars\" in front matter)", name)))
+		}
+	}
+	return diagnostics
+}
+
+// declaredVariables returns the names listed under "vars" in front matter.
+func declaredVariables(frontMatter map[string]interface{}) map[string]bool {
+	declared := make(map[string]bool)
+	switch vars := frontMatter["vars"].(type) {
+	case []interface{}:
+		for _, v := range vars {
+			if name, ok := v.(string); ok {
+				declared[name] = true
+			}
+		}
+	case map[string]interface{}:
+		for name := range vars {
+			declared[name] = true
+		}
+	}
+	return declared
+}
+
+// DuplicateNameCheck reports prompts of the same source that share a name
+// (e.g. two markdown files with the same base name), which makes one shadow the other.
+type DuplicateNameCheck struct{}
+
+func (c DuplicateNameCheck) Name() string        { return "duplicate-name" }
+func (c DuplicateNameCheck) Description() string { return "Several prompts share the same ID" }
+
+func (c DuplicateNameCheck) Run(ctx *Context) []Diagnostic {
+	var diagnostics []Diagnostic
+	first := make(map[string]Target)
+	for _, t := range ctx.Targets {
+		key := strings.ToLower(t.Prompt.ID)
+		if prev, ok := first[key]; ok {
+			diagnostics = append(diagnostics, diagnostic(c, SeverityError, t, -1,
+				fmt.Sprintf("prompt ID %q is also defined by %s", t.Prompt.ID, prev.File)))
+			continue
+		}
+		first[key] = t
+	}
+	return diagnostics
+}
+
+// EmptyDescriptionCheck reports prompts without a description of their own.
+type EmptyDescriptionCheck struct{}
+
+func (c EmptyDescriptionCheck) Name() string        { return "empty-description" }
+func (c EmptyDescriptionCheck) Description() string { return "Prompt has no description" }
+
+func (c EmptyDescriptionCheck) Run(ctx *Context) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, t := range ctx.Targets {
+		desc := strings.TrimSpace(t.Prompt.Description)
+		if desc == "" || desc == parser.DefaultDescription {
+			diagnostics = append(diagnostics, diagnostic(c, SeverityWarning, t, -1,
+				"prompt has no description (add a paragraph below the first heading)"))
+		}
+	}
+	return diagnostics
+}
+
+// TrailingWhitespaceCheck reports whitespace at the end of lines and blank
+// lines at the end of the file.
+type TrailingWhitespaceCheck struct{}
+
+func (c TrailingWhitespaceCheck) Name() string { return "trailing-whitespace" }
+func (c TrailingWhitespaceCheck) Description() string {
+	return "Trailing whitespace or blank lines at end of file"
+}
+
+func (c TrailingWhitespaceCheck) Run(ctx *Context) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, t := range ctx.Targets {
+		content := t.Prompt.Content
+
+		offset := 0
+		for _, line := range strings.SplitAfter(content, "\n") {
+			text := strings.TrimRight(line, "\r\n")
+			if trimmed := strings.TrimRight(text, " \t"); len(trimmed) < len(text) {
+				diagnostics = append(diagnostics, diagnostic(c, SeverityWarning, t, offset+len(trimmed),
+					"trailing whitespace"))
+			}
+			offset += len(line)
+		}
+
+		if body := strings.TrimRight(content, " \t\r\n"); body != "" && strings.Count(content[len(body):], "\n") > 1 {
+			diagnostics = append(diagnostics, diagnostic(c, SeverityWarning, t, len(body)+1,
+				"blank lines at end of file"))
+		}
+	}
+	return diagnostics
+}
@@ -0,0 +1,163 @@
+// Package lint runs quality and consistency checks over parsed prompts.
+package lint
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/whisller/pkit/pkg/models"
+)
+
+// Severity is how serious a diagnostic is.
+type Severity string
+
+const (
+	// SeverityError marks problems that break prompts (e.g. unresolvable includes)
+	SeverityError Severity = "error"
+
+	// SeverityWarning marks style and consistency problems
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a single problem reported by a check.
+type Diagnostic struct {
+	// Name of the check that reported the problem
+	Check string `json:"check"`
+
+	// Severity of the problem
+	Severity Severity `json:"severity"`
+
+	// Prompt the problem was found in
+	PromptID string `json:"prompt_id"`
+
+	// File the prompt was parsed from
+	File string `json:"file"`
+
+	// 1-based line and column within File (0 when unknown)
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+
+	// Human-readable description of the problem
+	Message string `json:"message"`
+}
+
+// Target is a prompt to lint together with the file it came from.
+type Target struct {
+	Prompt models.Prompt
+
+	// Path used in reports (relative to the working directory when possible)
+	File string
+
+	// Set when the prompt's content is only part of File (a prompts.csv cell),
+	// so offsets in it are not positions in File and diagnostics get none
+	PartOfFile bool
+}
+
+// Context is what checks operate on.
+type Context struct {
+	// Prompts being linted
+	Targets []Target
+
+	// ResolveInclude maps an include target (prompt ID or alias) to a prompt ID.
+	// It reports false when the target does not exist.
+	ResolveInclude func(target string) (promptID string, ok bool)
+}
+
+// Check is a single lint rule.
+// Implementations must be safe to run on any set of prompts.
+type Check interface {
+	// Name returns the rule identifier used in reports and --disable
+	Name() string
+
+	// Description returns a one-line summary of what the rule looks for
+	Description() string
+
+	// Run inspects the prompts and returns any problems found
+	Run(ctx *Context) []Diagnostic
+}
+
+// registry holds the checks run by default, in registration order.
+var registry []Check
+
+// Register adds a check to the default set.
+func Register(check Check) {
+	registry = append(registry, check)
+}
+
+// Checks returns all registered checks.
+func Checks() []Check {
+	checks := make([]Check, len(registry))
+	copy(checks, registry)
+	return checks
+}
+
+// Run runs every registered check that is not disabled and returns the
+// diagnostics ordered by file and position.
+func Run(ctx *Context, disabled []string) []Diagnostic {
+	skip := make(map[string]bool, len(disabled))
+	for _, name := range disabled {
+		skip[strings.TrimSpace(name)] = true
+	}
+
+	var diagnostics []Diagnostic
+	for _, check := range registry {
+		if skip[check.Name()] {
+			continue
+		}
+		diagnostics = append(diagnostics, check.Run(ctx)...)
+	}
+
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	return diagnostics
+}
+
+// Count returns the number of errors and warnings in diagnostics.
+func Count(diagnostics []Diagnostic) (errors, warnings int) {
+	for _, d := range diagnostics {
+		switch d.Severity {
+		case SeverityError:
+			errors++
+		case SeverityWarning:
+			warnings++
+		}
+	}
+	return errors, warnings
+}
+
+// position converts a byte offset in content to a 1-based line and column.
+func position(content string, offset int) (line, column int) {
+	if offset > len(content) {
+		offset = len(content)
+	}
+	before := content[:offset]
+	line = strings.Count(before, "\n") + 1
+	column = offset - strings.LastIndex(before, "\n")
+	return line, column
+}
+
+// diagnostic builds a Diagnostic for a target at a byte offset of its content.
+// A negative offset, or a target that is only part of its file, leaves the
+// position unset.
+func diagnostic(check Check, severity Severity, t Target, offset int, message string) Diagnostic {
+	d := Diagnostic{
+		Check:    check.Name(),
+		Severity: severity,
+		PromptID: t.Prompt.ID,
+		File:     t.File,
+		Message:  message,
+	}
+	if offset >= 0 && !t.PartOfFile {
+		d.Line, d.Column = position(t.Prompt.Content, offset)
+	}
+	return d
+}
@@ -0,0 +1,172 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// Report formats supported by Write.
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+)
+
+// Write outputs diagnostics in the given format.
+// checked is the number of prompts linted; version is reported as the SARIF tool version.
+func Write(w io.Writer, format string, diagnostics []Diagnostic, checked int, version string) error {
+	switch format {
+	case FormatText:
+		return writeText(w, diagnostics)
+	case FormatJSON:
+		return writeJSON(w, diagnostics, checked)
+	case FormatSARIF:
+		return writeSARIF(w, diagnostics, version)
+	default:
+		return fmt.Errorf("unknown format: %s (supported: text, json, sarif)", format)
+	}
+}
+
+// writeText prints one compiler-style line per diagnostic:
+// file:line:column: severity: message [check]
+func writeText(w io.Writer, diagnostics []Diagnostic) error {
+	for _, d := range diagnostics {
+		location := d.File
+		if d.Line > 0 {
+			location = fmt.Sprintf("%s:%d:%d", d.File, d.Line, d.Column)
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s: %s [%s]\n", location, d.Severity, d.Message, d.Check); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJSON prints diagnostics with a summary.
+func writeJSON(w io.Writer, diagnostics []Diagnostic, checked int) error {
+	errors, warnings := Count(diagnostics)
+	if diagnostics == nil {
+		diagnostics = []Diagnostic{}
+	}
+
+	output := struct {
+		Prompts     int          `json:"prompts"`
+		Errors      int          `json:"errors"`
+		Warnings    int          `json:"warnings"`
+		Diagnostics []Diagnostic `json:"diagnostics"`
+	}{
+		Prompts:     checked,
+		Errors:      errors,
+		Warnings:    warnings,
+		Diagnostics: diagnostics,
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(output)
+}
+
+// SARIF 2.1.0 structures (only the parts pkit fills in).
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// writeSARIF prints diagnostics as a SARIF 2.1.0 log (e.g. for GitHub code scanning).
+func writeSARIF(w io.Writer, diagnostics []Diagnostic, version string) error {
+	rules := make([]sarifRule, 0, len(registry))
+	for _, check := range registry {
+		rules = append(rules, sarifRule{
+			ID:               check.Name(),
+			ShortDescription: sarifMessage{Text: check.Description()},
+		})
+	}
+
+	results := make([]sarifResult, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		location := sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(d.File)},
+		}
+		if d.Line > 0 {
+			location.Region = &sarifRegion{StartLine: d.Line, StartColumn: d.Column}
+		}
+
+		results = append(results, sarifResult{
+			RuleID:    d.Check,
+			Level:     string(d.Severity),
+			Message:   sarifMessage{Text: d.Message},
+			Locations: []sarifLocation{{PhysicalLocation: location}},
+		})
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "pkit",
+				Version:        version,
+				InformationURI: "https://github.com/whisller/pkit",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
@@ -37,18 +37,24 @@ func (p *AwesomeChatGPTParser) CanParse(sourcePath string) bool {
 
 // ParsePrompts extracts all prompts from prompts.csv file.
 func (p *AwesomeChatGPTParser) ParsePrompts(source *models.Source) ([]models.Prompt, error) {
-	var prompts []models.Prompt
+	return collectPrompts(func(yield func(models.Prompt) error) error {
+		return p.StreamPrompts(source, yield)
+	})
+}
 
+// StreamPrompts extracts the prompts of prompts.csv as they are parsed. The
+// file is read row by row, without workers.
+func (p *AwesomeChatGPTParser) StreamPrompts(source *models.Source, yield func(models.Prompt) error) error {
 	csvPath := filepath.Join(source.LocalPath, "prompts.csv")
 
 	// Check if CSV file exists
 	if _, err := os.Stat(csvPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("prompts.csv not found: %w", err)
+		return fmt.Errorf("prompts.csv not found: %w", err)
 	}
 
 	file, err := os.Open(csvPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open prompts.csv: %w", err)
+		return fmt.Errorf("failed to open prompts.csv: %w", err)
 	}
 	defer func() {
 		// File close error on read-only file is rare, ignore
@@ -60,7 +66,7 @@ func (p *AwesomeChatGPTParser) ParsePrompts(source *models.Source) ([]models.Pro
 	// Read header
 	_, err = reader.Read()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		return fmt.Errorf("failed to read CSV header: %w", err)
 	}
 
 	// Get file mod time for UpdatedAt
@@ -71,8 +77,8 @@ func (p *AwesomeChatGPTParser) ParsePrompts(source *models.Source) ([]models.Pro
 	}
 
 	// Read rows
-	rowNum := 1 // Start at 1 (header is row 0)
-	for {
+	count := 0
+	for rowNum := 1; ; rowNum++ { // Start at 1 (header is row 0)
 		row, err := reader.Read()
 		if err == io.EOF {
 			break
@@ -80,73 +86,108 @@ func (p *AwesomeChatGPTParser) ParsePrompts(source *models.Source) ([]models.Pro
 		if err != nil {
 			// Log warning but continue with other rows
 			fmt.Fprintf(os.Stderr, "Warning: failed to read CSV row %d: %v\n", rowNum, err)
-			rowNum++
 			continue
 		}
 
 		// Ensure row has enough columns
 		if len(row) < 5 {
 			fmt.Fprintf(os.Stderr, "Warning: skipping row %d with insufficient columns\n", rowNum)
-			rowNum++
 			continue
 		}
 
-		// Parse CSV row
-		act := row[0]         // Column: act
-		promptText := row[1]  // Column: prompt
-		forDevs := row[2]     // Column: for_devs
-		promptType := row[3]  // Column: type
-		contributor := row[4] // Column: contributor
-
-		// Derive metadata
-		name := slugify(act)                     // "Linux Terminal" → "linux-terminal"
-		description := truncate(promptText, 150) // First 150 chars of prompt
-
-		// Build tags from metadata
-		tags := []string{}
-		if strings.ToUpper(forDevs) == "TRUE" {
-			tags = append(tags, "dev")
-		}
-		if promptType != "" && promptType != "TEXT" {
-			tags = append(tags, strings.ToLower(promptType))
-		}
-
-		// Extract prompt content to cache file
-		cachePath, err := cache.WritePromptToCache(source.ID, name, promptText)
+		prompt, err := parseAwesomeRow(source, row, updatedAt)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to cache prompt %s: %v\n", name, err)
 			// Continue with other prompts
-			rowNum++
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 			continue
 		}
 
-		prompt := models.Prompt{
-			ID:          fmt.Sprintf("%s:%s", source.ID, name),
-			SourceID:    source.ID,
-			Name:        name,
-			Description: description,
-			Tags:        tags,
-			Author:      contributor,
-			Version:     "",
-			FilePath:    cachePath, // Path to cache file: cache/awesome/linux-terminal.md
-			Metadata: map[string]interface{}{
-				"act":      act,
-				"for_devs": forDevs,
-				"type":     promptType,
-			},
-			IndexedAt: time.Now(),
-			UpdatedAt: updatedAt,
+		if err := yield(prompt); err != nil {
+			return err
 		}
+		count++
+	}
+
+	if count == 0 {
+		return fmt.Errorf("no prompts found in %s", csvPath)
+	}
+
+	return nil
+}
 
-		prompts = append(prompts, prompt)
-		rowNum++
+// parseAwesomeRow builds the prompt of a prompts.csv row, writing its text to
+// the prompt cache.
+func parseAwesomeRow(source *models.Source, row []string, updatedAt time.Time) (models.Prompt, error) {
+	// Parse CSV row
+	act := row[0]         // Column: act
+	promptText := row[1]  // Column: prompt
+	forDevs := row[2]     // Column: for_devs
+	promptType := row[3]  // Column: type
+	contributor := row[4] // Column: contributor
+
+	// Derive metadata
+	name := slugify(act)                     // "Linux Terminal" → "linux-terminal"
+	description := truncate(promptText, 150) // First 150 chars of prompt
+
+	// Build tags from metadata
+	tags := []string{}
+	if strings.ToUpper(forDevs) == "TRUE" {
+		tags = append(tags, "dev")
+	}
+	if promptType != "" && promptType != "TEXT" {
+		tags = append(tags, strings.ToLower(promptType))
+	}
+
+	// Extract prompt content to cache file
+	cachePath, err := cache.WritePromptToCache(source.ID, name, promptText)
+	if err != nil {
+		return models.Prompt{}, fmt.Errorf("failed to cache prompt %s: %w", name, err)
 	}
 
-	if len(prompts) == 0 {
-		return nil, fmt.Errorf("no prompts found in %s", csvPath)
+	return models.Prompt{
+		ID:          fmt.Sprintf("%s:%s", source.ID, name),
+		SourceID:    source.ID,
+		Name:        name,
+		Content:     promptText,
+		Description: description,
+		Tags:        tags,
+		Author:      contributor,
+		Version:     "",
+		Language:    promptLanguage(source, promptText),
+		FilePath:    cachePath, // Path to cache file: cache/awesome/linux-terminal.md
+		Metadata: map[string]interface{}{
+			"act":      act,
+			"for_devs": forDevs,
+			"type":     promptType,
+		},
+		IndexedAt: time.Now(),
+		UpdatedAt: updatedAt,
+	}, nil
+}
+
+// FindAwesomePrompt returns the prompt text of the prompts.csv row whose act slugifies to name.
+func FindAwesomePrompt(r io.Reader, name string) (string, error) {
+	reader := csv.NewReader(r)
+
+	// Skip header
+	if _, err := reader.Read(); err != nil {
+		return "", fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil || len(row) < 2 {
+			continue
+		}
+		if slugify(row[0]) == name {
+			return row[1], nil
+		}
 	}
 
-	return prompts, nil
+	return "", fmt.Errorf("prompt %s not found in prompts.csv", name)
 }
 
 // slugify converts "Linux Terminal" → "linux-terminal"
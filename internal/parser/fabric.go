@@ -10,6 +10,9 @@ import (
 	"github.com/whisller/pkit/pkg/models"
 )
 
+// DefaultDescription is used for prompts whose content has no description paragraph.
+const DefaultDescription = "Fabric pattern"
+
 // FabricParser parses Fabric patterns from danielmiessler/fabric repository.
 // Fabric patterns are stored in patterns/*/system.md files.
 type FabricParser struct{}
@@ -34,71 +37,115 @@ func (p *FabricParser) CanParse(sourcePath string) bool {
 
 // ParsePrompts extracts all prompts from Fabric patterns directory.
 func (p *FabricParser) ParsePrompts(source *models.Source) ([]models.Prompt, error) {
-	var prompts []models.Prompt
+	return collectPrompts(func(yield func(models.Prompt) error) error {
+		return p.StreamPrompts(source, yield)
+	})
+}
 
+// StreamPrompts extracts the Fabric patterns as they are parsed.
+func (p *FabricParser) StreamPrompts(source *models.Source, yield func(models.Prompt) error) error {
 	// Fabric patterns are stored in data/patterns/*/system.md
 	patternsDir := filepath.Join(source.LocalPath, "data", "patterns")
 
 	// Check if patterns directory exists
 	if _, err := os.Stat(patternsDir); os.IsNotExist(err) {
-		return nil, fmt.Errorf("patterns directory not found at %s: %w", patternsDir, err)
+		return fmt.Errorf("patterns directory not found at %s: %w", patternsDir, err)
 	}
 
 	entries, err := os.ReadDir(patternsDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read patterns directory: %w", err)
+		return fmt.Errorf("failed to read patterns directory: %w", err)
 	}
 
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
+	count, err := parseAll(func(send func(string) bool) error {
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			if !send(entry.Name()) {
+				break
+			}
 		}
-
-		systemFile := filepath.Join(patternsDir, entry.Name(), "system.md")
+		return nil
+	}, func(name string) (models.Prompt, error) {
+		systemFile := filepath.Join(patternsDir, name, "system.md")
 		if _, err := os.Stat(systemFile); os.IsNotExist(err) {
-			continue
+			return models.Prompt{}, errSkip
 		}
+		return parsePattern(source, name)
+	}, yield)
+	if err != nil {
+		return err
+	}
 
-		// Read file content
-		content, err := os.ReadFile(systemFile)
-		if err != nil {
-			// Log warning but continue with other patterns
-			fmt.Fprintf(os.Stderr, "Warning: failed to read %s: %v\n", systemFile, err)
-			continue
-		}
+	if count == 0 {
+		return fmt.Errorf("no patterns found in %s", patternsDir)
+	}
 
-		// Extract metadata from content
-		name := entry.Name()
-		description := extractDescription(content, 150)
+	return nil
+}
 
-		// Get file mod time for UpdatedAt
-		fileInfo, _ := os.Stat(systemFile)
-		updatedAt := time.Now()
-		if fileInfo != nil {
-			updatedAt = fileInfo.ModTime()
-		}
+// PromptID returns the ID of the pattern whose system.md is at path.
+func (p *FabricParser) PromptID(source *models.Source, path string) (string, bool) {
+	name, ok := patternName(path)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%s:%s", source.ID, name), true
+}
 
-		prompt := models.Prompt{
-			ID:          fmt.Sprintf("%s:%s", source.ID, name),
-			SourceID:    source.ID,
-			Name:        name,
-			Description: description,
-			Tags:        []string{},
-			Author:      "",
-			Version:     "",
-			FilePath:    fmt.Sprintf("data/patterns/%s/system.md", name),
-			IndexedAt:   time.Now(),
-			UpdatedAt:   updatedAt,
-		}
+// ParseFile extracts the pattern whose system.md is at path.
+func (p *FabricParser) ParseFile(source *models.Source, path string) (models.Prompt, error) {
+	name, ok := patternName(path)
+	if !ok {
+		return models.Prompt{}, fmt.Errorf("%s is not a pattern file", path)
+	}
+	return parsePattern(source, name)
+}
 
-		prompts = append(prompts, prompt)
+// patternName returns the pattern name of a data/patterns/<name>/system.md path.
+func patternName(path string) (string, bool) {
+	parts := strings.Split(path, "/")
+	if len(parts) != 4 || parts[0] != "data" || parts[1] != "patterns" || parts[3] != "system.md" {
+		return "", false
 	}
+	return parts[2], true
+}
+
+// parsePattern reads the system.md of the named pattern.
+func parsePattern(source *models.Source, name string) (models.Prompt, error) {
+	systemFile := filepath.Join(source.LocalPath, "data", "patterns", name, "system.md")
+
+	// Read file content
+	content, err := os.ReadFile(systemFile)
+	if err != nil {
+		return models.Prompt{}, fmt.Errorf("failed to read %s: %w", systemFile, err)
+	}
+
+	// Extract metadata from content
+	description := extractDescription(content, 150)
 
-	if len(prompts) == 0 {
-		return nil, fmt.Errorf("no patterns found in %s", patternsDir)
+	// Get file mod time for UpdatedAt
+	fileInfo, _ := os.Stat(systemFile)
+	updatedAt := time.Now()
+	if fileInfo != nil {
+		updatedAt = fileInfo.ModTime()
 	}
 
-	return prompts, nil
+	return models.Prompt{
+		ID:          fmt.Sprintf("%s:%s", source.ID, name),
+		SourceID:    source.ID,
+		Name:        name,
+		Content:     string(content),
+		Description: description,
+		Tags:        []string{},
+		Author:      "",
+		Version:     "",
+		Language:    promptLanguage(source, string(content)),
+		FilePath:    fmt.Sprintf("data/patterns/%s/system.md", name),
+		IndexedAt:   time.Now(),
+		UpdatedAt:   updatedAt,
+	}, nil
 }
 
 // extractDescription extracts the first paragraph from content, truncating to maxLen.
@@ -137,7 +184,7 @@ func extractDescription(content []byte, maxLen int) string {
 	}
 
 	if desc == "" {
-		return "Fabric pattern"
+		return DefaultDescription
 	}
 
 	return desc
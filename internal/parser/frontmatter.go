@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// frontMatterDelimiter opens and closes a YAML front matter block.
+const frontMatterDelimiter = "---"
+
+// SplitFrontMatter separates a leading YAML front matter block from the prompt body.
+// Content without front matter is returned unchanged with a nil map.
+//
+// Example:
+//
+//	---
+//	model: gpt-4o
+//	temperature: 0.2
+//	---
+//	You are a helpful assistant.
+func SplitFrontMatter(content string) (map[string]interface{}, string, error) {
+	text := strings.TrimPrefix(content, "\ufeff")
+	firstLine, rest, found := strings.Cut(text, "\n")
+	if !found || strings.TrimRight(firstLine, " \r") != frontMatterDelimiter {
+		return nil, content, nil
+	}
+
+	// Find the closing delimiter
+	offset := 0
+	for offset <= len(rest) {
+		line, _, _ := strings.Cut(rest[offset:], "\n")
+		if strings.TrimRight(line, " \r") == frontMatterDelimiter {
+			header := rest[:offset]
+			body := ""
+			if end := offset + len(line) + 1; end <= len(rest) {
+				body = rest[end:]
+			}
+
+			var fields map[string]interface{}
+			if err := yaml.Unmarshal([]byte(header), &fields); err != nil {
+				return nil, content, fmt.Errorf("invalid front matter: %w", err)
+			}
+			if fields == nil {
+				fields = map[string]interface{}{}
+			}
+
+			return fields, strings.TrimLeft(body, "\r\n"), nil
+		}
+
+		if offset+len(line) >= len(rest) {
+			break
+		}
+		offset += len(line) + 1
+	}
+
+	// No closing delimiter: treat as plain content
+	return nil, content, nil
+}
+
+// StripFrontMatter returns content without its front matter block.
+// Content with malformed front matter is returned unchanged.
+func StripFrontMatter(content string) string {
+	_, body, err := SplitFrontMatter(content)
+	if err != nil {
+		return content
+	}
+	return body
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -48,70 +49,108 @@ func (p *MarkdownParser) CanParse(sourcePath string) bool {
 
 // ParsePrompts extracts all prompts from markdown files.
 func (p *MarkdownParser) ParsePrompts(source *models.Source) ([]models.Prompt, error) {
-	var prompts []models.Prompt
+	return collectPrompts(func(yield func(models.Prompt) error) error {
+		return p.StreamPrompts(source, yield)
+	})
+}
 
-	// Walk source directory looking for .md files
-	err := filepath.Walk(source.LocalPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+// StreamPrompts extracts the prompts of markdown files as they are parsed.
+func (p *MarkdownParser) StreamPrompts(source *models.Source, yield func(models.Prompt) error) error {
+	count, err := parseAll(func(send func(string) bool) error {
+		// Walk source directory looking for .md files
+		err := filepath.WalkDir(source.LocalPath, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
 
-		// Skip directories
-		if info.IsDir() {
-			return nil
-		}
+			// Skip directories
+			if entry.IsDir() {
+				return nil
+			}
 
-		// Only process .md files
-		if filepath.Ext(path) != ".md" {
-			return nil
-		}
+			// Only process .md files, skipping README and common non-prompt files
+			if filepath.Ext(path) != ".md" || !isMarkdownPrompt(path) {
+				return nil
+			}
 
-		// Skip README and common non-prompt files
-		baseName := strings.ToLower(filepath.Base(path))
-		if baseName == "readme.md" || baseName == "license.md" || baseName == "contributing.md" {
+			relPath, _ := filepath.Rel(source.LocalPath, path)
+			if !send(relPath) {
+				return filepath.SkipAll
+			}
 			return nil
-		}
-
-		// Read file
-		content, err := os.ReadFile(path)
+		})
 		if err != nil {
-			// Log warning but continue with other files
-			fmt.Fprintf(os.Stderr, "Warning: failed to read %s: %v\n", path, err)
-			return nil
+			return fmt.Errorf("failed to walk directory: %w", err)
 		}
+		return nil
+	}, func(relPath string) (models.Prompt, error) {
+		return p.ParseFile(source, filepath.ToSlash(relPath))
+	}, yield)
+	if err != nil {
+		return err
+	}
 
-		// Extract metadata
-		name := strings.TrimSuffix(filepath.Base(path), ".md")
-		name = slugify(name)
-
-		description := extractDescription(content, 150)
-		relPath, _ := filepath.Rel(source.LocalPath, path)
-
-		prompt := models.Prompt{
-			ID:          fmt.Sprintf("%s:%s", source.ID, name),
-			SourceID:    source.ID,
-			Name:        name,
-			Content:     string(content),
-			Description: description,
-			Tags:        []string{},
-			Author:      "",
-			Version:     "",
-			FilePath:    relPath,
-			IndexedAt:   time.Now(),
-			UpdatedAt:   info.ModTime(),
-		}
+	if count == 0 {
+		return fmt.Errorf("no markdown prompts found in %s", source.LocalPath)
+	}
 
-		prompts = append(prompts, prompt)
-		return nil
-	})
+	return nil
+}
+
+// PromptID returns the ID of the prompt in the markdown file at path.
+func (p *MarkdownParser) PromptID(source *models.Source, path string) (string, bool) {
+	if filepath.Ext(path) != ".md" || !isMarkdownPrompt(path) {
+		return "", false
+	}
+	return fmt.Sprintf("%s:%s", source.ID, markdownName(path)), true
+}
 
+// ParseFile extracts the prompt in the markdown file at path.
+func (p *MarkdownParser) ParseFile(source *models.Source, path string) (models.Prompt, error) {
+	info, err := os.Stat(filepath.Join(source.LocalPath, filepath.FromSlash(path)))
 	if err != nil {
-		return nil, fmt.Errorf("failed to walk directory: %w", err)
+		return models.Prompt{}, fmt.Errorf("failed to read %s: %w", path, err)
 	}
+	return parseMarkdownFile(source, filepath.FromSlash(path), info)
+}
+
+// isMarkdownPrompt reports whether a markdown file holds a prompt rather than
+// being one of the common non-prompt files.
+func isMarkdownPrompt(path string) bool {
+	baseName := strings.ToLower(filepath.Base(path))
+	return baseName != "readme.md" && baseName != "license.md" && baseName != "contributing.md"
+}
+
+// markdownName returns the prompt name of a markdown file.
+func markdownName(path string) string {
+	return slugify(strings.TrimSuffix(filepath.Base(path), ".md"))
+}
 
-	if len(prompts) == 0 {
-		return nil, fmt.Errorf("no markdown prompts found in %s", source.LocalPath)
+// parseMarkdownFile reads the prompt in the markdown file at relPath.
+func parseMarkdownFile(source *models.Source, relPath string, info os.FileInfo) (models.Prompt, error) {
+	// Read file
+	path := filepath.Join(source.LocalPath, relPath)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return models.Prompt{}, fmt.Errorf("failed to read %s: %w", path, err)
 	}
 
-	return prompts, nil
+	// Extract metadata
+	name := markdownName(path)
+	description := extractDescription(content, 150)
+
+	return models.Prompt{
+		ID:          fmt.Sprintf("%s:%s", source.ID, name),
+		SourceID:    source.ID,
+		Name:        name,
+		Content:     string(content),
+		Description: description,
+		Tags:        []string{},
+		Author:      "",
+		Version:     "",
+		Language:    promptLanguage(source, string(content)),
+		FilePath:    relPath,
+		IndexedAt:   time.Now(),
+		UpdatedAt:   info.ModTime(),
+	}, nil
 }
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"github.com/whisller/pkit/internal/language"
 	"github.com/whisller/pkit/pkg/models"
 )
 
@@ -13,6 +14,14 @@ type Parser interface {
 	// Returns a slice of Prompt models and any error encountered.
 	ParsePrompts(source *models.Source) ([]models.Prompt, error)
 
+	// StreamPrompts extracts all prompts from a source directory like
+	// ParsePrompts, handing each to yield as soon as it is parsed instead of
+	// collecting them, so large sources are never held in memory whole. Prompt
+	// files may be read and parsed on up to Workers goroutines, but yield is
+	// called from one goroutine, in the same order ParsePrompts returns prompts.
+	// An error from yield stops parsing and is returned.
+	StreamPrompts(source *models.Source, yield func(models.Prompt) error) error
+
 	// CanParse checks if this parser can handle the given source path.
 	// Used for auto-detection of source formats.
 	CanParse(sourcePath string) bool
@@ -20,3 +29,26 @@ type Parser interface {
 	// Name returns the parser name for logging and debugging.
 	Name() string
 }
+
+// FileParser is implemented by parsers whose prompts each come from one file,
+// so that after a source update only the prompts of changed files are parsed
+// again. Paths are relative to the source root, with forward slashes.
+type FileParser interface {
+	Parser
+
+	// PromptID returns the ID of the prompt a file holds, and false when the
+	// file is not a prompt file of this format.
+	PromptID(source *models.Source, path string) (string, bool)
+
+	// ParseFile extracts the prompt of one prompt file.
+	ParseFile(source *models.Source, path string) (models.Prompt, error)
+}
+
+// promptLanguage returns the language of a prompt: the source's when it is
+// set, otherwise the one its content is written in.
+func promptLanguage(source *models.Source, content string) string {
+	if source.Language != "" {
+		return source.Language
+	}
+	return language.Detect(content)
+}
@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/whisller/pkit/pkg/models"
+)
+
+// Workers is how many prompt files are read and parsed at once.
+var Workers = runtime.NumCPU()
+
+// errSkip is returned by parse functions of parseAll for items that hold no
+// prompt, to skip them without a warning.
+var errSkip = errors.New("not a prompt")
+
+// collectPrompts returns all prompts stream hands over, for parsers whose
+// ParsePrompts is StreamPrompts into a slice.
+func collectPrompts(stream func(yield func(models.Prompt) error) error) ([]models.Prompt, error) {
+	var prompts []models.Prompt
+	err := stream(func(prompt models.Prompt) error {
+		prompts = append(prompts, prompt)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return prompts, nil
+}
+
+// parseResult is a parsed work item, numbered in the order items were produced.
+type parseResult struct {
+	seq    int
+	prompt models.Prompt
+	err    error
+}
+
+// parseAll parses the items produce sends with parse on Workers goroutines and
+// hands the prompts to yield from the calling goroutine, in the order the items
+// were sent. Items that fail to parse are warned about and skipped, those that
+// hold no prompt (errSkip) only skipped. produce stops when send returns false,
+// after yield failed. It returns how many prompts were handed over.
+func parseAll[T any](produce func(send func(T) bool) error, parse func(T) (models.Prompt, error), yield func(models.Prompt) error) (int, error) {
+	type item struct {
+		seq   int
+		value T
+	}
+	items := make(chan item, Workers)
+	results := make(chan parseResult, Workers)
+	done := make(chan struct{})
+
+	var produceErr error
+	go func() {
+		defer close(items)
+		seq := 0
+		produceErr = produce(func(value T) bool {
+			select {
+			case items <- item{seq: seq, value: value}:
+				seq++
+				return true
+			case <-done:
+				return false
+			}
+		})
+	}()
+
+	var wg sync.WaitGroup
+	for n := 0; n < Workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for it := range items {
+				prompt, err := parse(it.value)
+				select {
+				case results <- parseResult{seq: it.seq, prompt: prompt, err: err}:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Results arrive in any order and wait here until those before them did
+	pending := make(map[int]parseResult)
+	next, count := 0, 0
+	for result := range results {
+		pending[result.seq] = result
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			if errors.Is(ready.err, errSkip) {
+				continue
+			}
+			if ready.err != nil {
+				// Log warning but continue with other prompts
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", ready.err)
+				continue
+			}
+			if err := yield(ready.prompt); err != nil {
+				close(done)
+				for range results {
+				}
+				return count, err
+			}
+			count++
+		}
+	}
+
+	// All items were parsed, so produce has returned
+	return count, produceErr
+}
@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/whisller/pkit/pkg/models"
+)
+
+func TestStreamPromptsInOrder(t *testing.T) {
+	dir := t.TempDir()
+	var want []string
+	for n := 0; n < 200; n++ {
+		name := fmt.Sprintf("prompt-%03d", n)
+		if err := os.WriteFile(filepath.Join(dir, name+".md"), []byte("# "+name+"\n\nDoes task "+name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, "notes:"+name)
+	}
+	src := &models.Source{ID: "notes", LocalPath: dir}
+
+	var got []string
+	err := NewMarkdownParser().StreamPrompts(src, func(prompt models.Prompt) error {
+		got = append(got, prompt.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("StreamPrompts() order = %v, want %v", got, want)
+	}
+
+	// Stops at the first error of yield
+	errStop := errors.New("stop")
+	count := 0
+	err = NewMarkdownParser().StreamPrompts(src, func(models.Prompt) error {
+		count++
+		if count == 10 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) || count != 10 {
+		t.Errorf("StreamPrompts() = %v after %d prompts, want %v after 10", err, count, errStop)
+	}
+}
@@ -0,0 +1,290 @@
+// Package resolvecache keeps a compact map from aliases to prompt IDs and from
+// prompt IDs to the files prompts are read from, so 'pkit get' can resolve a
+// prompt without opening the search index or loading aliases and config.
+//
+// The cache is only a shortcut: entries are checked against the files they
+// describe and anything missing or out of date is resolved the slow way.
+package resolvecache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/whisller/pkit/pkg/models"
+)
+
+// File is the name of the cache file, kept next to the search index.
+const File = "resolve.cache"
+
+// header is the first line of the cache file. Files with another header are
+// ignored, so changing the format only needs a new version.
+const header = "pkit-resolve-cache 1"
+
+// Entry locates the file of one prompt.
+type Entry struct {
+	Name string
+
+	// Absolute path of the prompt file
+	Path string
+
+	// SHA-256 of the file content when it was indexed
+	Hash string
+}
+
+// Cache maps aliases and prompt IDs to prompt files.
+type Cache struct {
+	// Content of the cache file. Lookups search it in place, so resolving one
+	// prompt does not parse the whole cache; it is parsed when first modified.
+	data   []byte
+	parsed bool
+
+	// Prompt IDs of aliases by name; compositions are left out
+	aliases map[string]string
+
+	// Stamp of the aliases file the aliases were read from
+	aliasesStamp string
+
+	prompts map[string]Entry
+	dirty   bool
+}
+
+// Path returns the cache file path for an index directory.
+func Path(indexBasePath string) string {
+	return filepath.Join(indexBasePath, File)
+}
+
+// New returns an empty cache.
+func New() *Cache {
+	return &Cache{
+		parsed:  true,
+		aliases: make(map[string]string),
+		prompts: make(map[string]Entry),
+	}
+}
+
+// Load reads the cache file, returning an empty cache when there is none or
+// it was written in another format.
+func Load(path string) (*Cache, error) {
+	cache := New()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resolve cache: %w", err)
+	}
+
+	if !bytes.HasPrefix(data, []byte(header+"\n")) {
+		return cache, nil
+	}
+	cache.data = data
+	cache.parsed = false
+	return cache, nil
+}
+
+// The file holds one record per line after the header, with tab-separated
+// fields: "s <stamp>", "a <alias> <id>" or "p <id> <name> <hash> <path>".
+
+// parse reads every record of the cache file into the maps.
+func (c *Cache) parse() {
+	if c.parsed {
+		return
+	}
+	c.parsed = true
+
+	_, rest, _ := bytes.Cut(c.data, []byte{'\n'})
+	c.data = nil
+	for len(rest) > 0 {
+		var line []byte
+		line, rest, _ = bytes.Cut(rest, []byte{'\n'})
+		fields := strings.Split(string(line), "\t")
+		switch {
+		case fields[0] == "s" && len(fields) == 2:
+			c.aliasesStamp = fields[1]
+		case fields[0] == "a" && len(fields) == 3:
+			c.aliases[fields[1]] = fields[2]
+		case fields[0] == "p" && len(fields) == 5:
+			c.prompts[fields[1]] = Entry{Name: fields[2], Hash: fields[3], Path: fields[4]}
+		}
+	}
+}
+
+// find returns the fields after prefix of the record starting with it, in
+// the unparsed cache file.
+func (c *Cache) find(prefix string) ([]string, bool) {
+	start := bytes.Index(c.data, []byte("\n"+prefix))
+	if start < 0 {
+		return nil, false
+	}
+	record := c.data[start+1+len(prefix):]
+	if end := bytes.IndexByte(record, '\n'); end >= 0 {
+		record = record[:end]
+	}
+	return strings.Split(string(record), "\t"), true
+}
+
+// Save writes the cache when it changed since it was loaded, replacing the
+// file atomically.
+func (c *Cache) Save(path string) error {
+	if !c.dirty {
+		return nil
+	}
+	c.parse()
+
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	_, _ = fmt.Fprintln(w, header)
+	_, _ = fmt.Fprintf(w, "s\t%s\n", c.aliasesStamp)
+	for name, promptID := range c.aliases {
+		_, _ = fmt.Fprintf(w, "a\t%s\t%s\n", name, promptID)
+	}
+	for promptID, entry := range c.prompts {
+		_, _ = fmt.Fprintf(w, "p\t%s\t%s\t%s\t%s\n", promptID, entry.Name, entry.Hash, entry.Path)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// A temporary file of our own, as other pkit processes may save at once
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), File+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write resolve cache: %w", err)
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write resolve cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write resolve cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write resolve cache: %w", err)
+	}
+
+	c.dirty = false
+	return nil
+}
+
+// Alias returns the prompt ID of an alias. The aliases are only trusted while
+// stamp, the current Stamp of the aliases file, matches the one they were read
+// from, so aliases edited by hand are not missed.
+func (c *Cache) Alias(name, stamp string) (string, bool) {
+	if !c.AliasesCurrent(stamp) {
+		return "", false
+	}
+	if !c.parsed {
+		fields, ok := c.find("a\t" + name + "\t")
+		if !ok || len(fields) != 1 {
+			return "", false
+		}
+		return fields[0], true
+	}
+	promptID, ok := c.aliases[name]
+	return promptID, ok
+}
+
+// AliasesCurrent reports whether the aliases were read from the aliases file
+// with the given stamp.
+func (c *Cache) AliasesCurrent(stamp string) bool {
+	if !c.parsed {
+		fields, ok := c.find("s\t")
+		return ok && len(fields) == 1 && fields[0] == stamp
+	}
+	return stamp == c.aliasesStamp
+}
+
+// SetAliases replaces the aliases with those read from the aliases file with
+// the given stamp.
+func (c *Cache) SetAliases(aliases []models.Alias, stamp string) {
+	c.parse()
+	c.aliases = make(map[string]string, len(aliases))
+	for _, a := range aliases {
+		if !a.IsComposition() && validField(a.Name) && validField(a.PromptID) {
+			c.aliases[a.Name] = a.PromptID
+		}
+	}
+	c.aliasesStamp = stamp
+	c.dirty = true
+}
+
+// Prompt returns the entry of a prompt ID.
+func (c *Cache) Prompt(promptID string) (Entry, bool) {
+	if !c.parsed {
+		fields, ok := c.find("p\t" + promptID + "\t")
+		if !ok || len(fields) != 3 {
+			return Entry{}, false
+		}
+		return Entry{Name: fields[0], Hash: fields[1], Path: fields[2]}, true
+	}
+	entry, ok := c.prompts[promptID]
+	return entry, ok
+}
+
+// Put records the file of a prompt. Prompts whose fields can't be stored are
+// left out.
+func (c *Cache) Put(promptID string, entry Entry) {
+	c.parse()
+	if !validField(promptID) || !validField(entry.Name) || !validField(entry.Path) {
+		delete(c.prompts, promptID)
+		return
+	}
+	if current, ok := c.prompts[promptID]; ok && current == entry {
+		return
+	}
+	c.prompts[promptID] = entry
+	c.dirty = true
+}
+
+// Delete forgets a prompt.
+func (c *Cache) Delete(promptID string) {
+	c.parse()
+	if _, ok := c.prompts[promptID]; ok {
+		delete(c.prompts, promptID)
+		c.dirty = true
+	}
+}
+
+// Read returns the content of the entry's file, or false when the file is gone
+// or changed since it was indexed.
+func (e Entry) Read() (string, bool) {
+	data, err := os.ReadFile(e.Path)
+	if err != nil || Hash(data) != e.Hash {
+		return "", false
+	}
+	return string(data), true
+}
+
+// Hash returns the hash of a prompt's content stored in entries.
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Stamp identifies a version of a file by its size and modification time, ""
+// when it does not exist.
+func Stamp(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return strconv.FormatInt(info.Size(), 10) + ":" + strconv.FormatInt(info.ModTime().UnixNano(), 10)
+}
+
+// validField reports whether a value can be stored in a tab-separated line.
+func validField(value string) bool {
+	return value != "" && !strings.ContainsAny(value, "\t\n")
+}
@@ -0,0 +1,146 @@
+package resolvecache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/whisller/pkit/pkg/models"
+)
+
+// resolveBudget is how long resolving an alias from the cache may take, so
+// that 'pkit get' stays fast in shell loops and prompts.
+const resolveBudget = 10 * time.Millisecond
+
+// benchmarkPrompts is the number of prompts in the benchmarked cache, a few
+// large sources.
+const benchmarkPrompts = 5000
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	promptPath := filepath.Join(dir, "review.md")
+	if err := os.WriteFile(promptPath, []byte("Review this"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := New()
+	cache.SetAliases([]models.Alias{
+		{Name: "rv", PromptID: "notes:review"},
+		{Name: "both", Compose: []string{"rv", "notes:other"}},
+	}, "1:1")
+	cache.Put("notes:review", Entry{Name: "review", Path: promptPath, Hash: Hash([]byte("Review this"))})
+	cache.Put("notes:tab", Entry{Name: "a\tb", Path: promptPath, Hash: "x"})
+
+	cachePath := Path(dir)
+	if err := cache.Save(cachePath); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id, ok := loaded.Alias("rv", "1:1"); !ok || id != "notes:review" {
+		t.Errorf("Alias(rv) = %q, %v", id, ok)
+	}
+	if _, ok := loaded.Alias("rv", "2:2"); ok {
+		t.Error("Alias() trusted aliases read from another aliases file")
+	}
+	if _, ok := loaded.Alias("both", "1:1"); ok {
+		t.Error("Alias() returned a composition")
+	}
+	if _, ok := loaded.Prompt("notes:tab"); ok {
+		t.Error("Prompt() returned an entry that can't be stored")
+	}
+
+	entry, ok := loaded.Prompt("notes:review")
+	if !ok {
+		t.Fatal("Prompt(notes:review) missing")
+	}
+	if content, ok := entry.Read(); !ok || content != "Review this" {
+		t.Errorf("Read() = %q, %v", content, ok)
+	}
+
+	// A file changed since it was indexed is not trusted
+	if err := os.WriteFile(promptPath, []byte("Review that"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := entry.Read(); ok {
+		t.Error("Read() returned a changed file")
+	}
+}
+
+func TestResolveWithinBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test")
+	}
+
+	cachePath, aliasesStamp := writeBenchmarkCache(t, t.TempDir())
+	result := testing.Benchmark(func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			resolve(b, cachePath, aliasesStamp)
+		}
+	})
+
+	if perOp := time.Duration(result.NsPerOp()); perOp > resolveBudget {
+		t.Errorf("resolving from a cache of %d prompts took %v, over the budget of %v", benchmarkPrompts, perOp, resolveBudget)
+	}
+}
+
+func BenchmarkResolve(b *testing.B) {
+	cachePath, aliasesStamp := writeBenchmarkCache(b, b.TempDir())
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		resolve(b, cachePath, aliasesStamp)
+	}
+}
+
+// resolve does what 'pkit get <alias>' does with the cache: load it, look the
+// alias and its prompt up and read the prompt file.
+func resolve(tb testing.TB, cachePath, aliasesStamp string) {
+	cache, err := Load(cachePath)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	promptID, ok := cache.Alias("alias-42", aliasesStamp)
+	if !ok {
+		tb.Fatal("alias not cached")
+	}
+	entry, ok := cache.Prompt(promptID)
+	if !ok {
+		tb.Fatal("prompt not cached")
+	}
+	if _, ok := entry.Read(); !ok {
+		tb.Fatal("prompt file not current")
+	}
+}
+
+// writeBenchmarkCache writes a cache of benchmarkPrompts prompts with an alias
+// each, and their files, to dir.
+func writeBenchmarkCache(tb testing.TB, dir string) (cachePath, aliasesStamp string) {
+	cache := New()
+	aliases := make([]models.Alias, 0, benchmarkPrompts)
+	for n := 0; n < benchmarkPrompts; n++ {
+		promptID := fmt.Sprintf("source:prompt-%d", n)
+		content := []byte(fmt.Sprintf("# Prompt %d\n\nYou are an assistant that does task number %d.\n", n, n))
+
+		path := filepath.Join(dir, fmt.Sprintf("prompt-%d.md", n))
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			tb.Fatal(err)
+		}
+		cache.Put(promptID, Entry{Name: fmt.Sprintf("prompt-%d", n), Path: path, Hash: Hash(content)})
+		aliases = append(aliases, models.Alias{Name: fmt.Sprintf("alias-%d", n), PromptID: promptID})
+	}
+
+	aliasesStamp = "1:1"
+	cache.SetAliases(aliases, aliasesStamp)
+
+	cachePath = Path(dir)
+	if err := cache.Save(cachePath); err != nil {
+		tb.Fatal(err)
+	}
+	return cachePath, aliasesStamp
+}
@@ -0,0 +1,274 @@
+// Package similarity detects near-duplicate prompts with MinHash signatures over
+// word shingles, and locality-sensitive hashing (LSH) bands to find candidates.
+package similarity
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// NumHashes is the number of MinHash values in a signature.
+	NumHashes = 64
+
+	// NumBands is the number of LSH bands a signature is split into.
+	NumBands = 16
+
+	// rowsPerBand is the number of signature values per band.
+	rowsPerBand = NumHashes / NumBands
+
+	// shingleSize is the number of words per shingle.
+	shingleSize = 3
+
+	// DefaultThreshold is the estimated similarity above which prompts are near-duplicates.
+	DefaultThreshold = 0.8
+)
+
+// Signature is a MinHash signature of a text.
+type Signature [NumHashes]uint32
+
+// seeds perturb the base shingle hash into NumHashes independent hash functions.
+var seeds = func() [NumHashes]uint64 {
+	var s [NumHashes]uint64
+	state := uint64(0x9E3779B97F4A7C15)
+	for i := range s {
+		state += 0x9E3779B97F4A7C15
+		s[i] = mix(state)
+	}
+	return s
+}()
+
+// mix is the splitmix64 finalizer.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xBF58476D1CE4E5B9
+	x ^= x >> 27
+	x *= 0x94D049BB133111EB
+	x ^= x >> 31
+	return x
+}
+
+// words splits text into lowercase words, ignoring punctuation.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// shingles returns the hashes of the word shingles of text.
+// Texts shorter than a shingle are hashed as a single shingle.
+func shingles(text string) []uint64 {
+	w := words(text)
+	if len(w) == 0 {
+		return nil
+	}
+
+	n := len(w) - shingleSize + 1
+	if n < 1 {
+		n = 1
+	}
+
+	hashes := make([]uint64, 0, n)
+	for i := 0; i < n; i++ {
+		end := i + shingleSize
+		if end > len(w) {
+			end = len(w)
+		}
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(strings.Join(w[i:end], " ")))
+		hashes = append(hashes, h.Sum64())
+	}
+	return hashes
+}
+
+// Compute returns the MinHash signature of text.
+// ok is false when text has no words to compare.
+func Compute(text string) (sig Signature, ok bool) {
+	hashes := shingles(text)
+	if len(hashes) == 0 {
+		return sig, false
+	}
+
+	for i := range sig {
+		sig[i] = ^uint32(0)
+	}
+	for _, h := range hashes {
+		for i := range sig {
+			if v := uint32(mix(h ^ seeds[i])); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig, true
+}
+
+// Fingerprint returns the MinHash signature of text encoded as hex,
+// or an empty string when text has no words.
+func Fingerprint(text string) string {
+	sig, ok := Compute(text)
+	if !ok {
+		return ""
+	}
+	return sig.String()
+}
+
+// String encodes the signature as hex.
+func (s Signature) String() string {
+	buf := make([]byte, NumHashes*4)
+	for i, v := range s {
+		binary.BigEndian.PutUint32(buf[i*4:], v)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// Parse decodes a hex fingerprint produced by Fingerprint.
+func Parse(fingerprint string) (Signature, error) {
+	var sig Signature
+	buf, err := hex.DecodeString(fingerprint)
+	if err != nil {
+		return sig, fmt.Errorf("invalid fingerprint: %w", err)
+	}
+	if len(buf) != NumHashes*4 {
+		return sig, fmt.Errorf("invalid fingerprint length: %d", len(buf))
+	}
+	for i := range sig {
+		sig[i] = binary.BigEndian.Uint32(buf[i*4:])
+	}
+	return sig, nil
+}
+
+// Similarity estimates the Jaccard similarity of the texts behind two signatures (0-1).
+func (s Signature) Similarity(other Signature) float64 {
+	equal := 0
+	for i := range s {
+		if s[i] == other[i] {
+			equal++
+		}
+	}
+	return float64(equal) / NumHashes
+}
+
+// Bands returns the LSH band keys of the signature. Signatures sharing at least
+// one band key are candidate near-duplicates.
+func (s Signature) Bands() []string {
+	keys := make([]string, NumBands)
+	buf := make([]byte, 4)
+	for b := 0; b < NumBands; b++ {
+		h := fnv.New64a()
+		for _, v := range s[b*rowsPerBand : (b+1)*rowsPerBand] {
+			binary.BigEndian.PutUint32(buf, v)
+			_, _ = h.Write(buf)
+		}
+		keys[b] = fmt.Sprintf("%02d%016x", b, h.Sum64())
+	}
+	return keys
+}
+
+// FingerprintBands returns the band keys of a hex fingerprint, or nil when it is invalid.
+func FingerprintBands(fingerprint string) []string {
+	sig, err := Parse(fingerprint)
+	if err != nil {
+		return nil
+	}
+	return sig.Bands()
+}
+
+// Cluster groups fingerprints into clusters of near-duplicates (estimated
+// similarity >= threshold). It returns clusters of at least two indexes into
+// fingerprints, each in input order, largest clusters first. Empty or invalid
+// fingerprints never match.
+func Cluster(fingerprints []string, threshold float64) [][]int {
+	sigs := make([]Signature, len(fingerprints))
+	valid := make([]bool, len(fingerprints))
+	buckets := make(map[string][]int)
+	for i, fp := range fingerprints {
+		sig, err := Parse(fp)
+		if err != nil {
+			continue
+		}
+		sigs[i], valid[i] = sig, true
+		for _, band := range sig.Bands() {
+			buckets[band] = append(buckets[band], i)
+		}
+	}
+
+	// Union-find over verified candidate pairs
+	parent := make([]int, len(fingerprints))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	checked := make(map[[2]int]bool)
+	for _, members := range buckets {
+		for x := 0; x < len(members); x++ {
+			for y := x + 1; y < len(members); y++ {
+				a, b := members[x], members[y]
+				if checked[[2]int{a, b}] {
+					continue
+				}
+				checked[[2]int{a, b}] = true
+				if sigs[a].Similarity(sigs[b]) >= threshold {
+					if ra, rb := find(a), find(b); ra != rb {
+						parent[rb] = ra
+					}
+				}
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for i := range fingerprints {
+		if valid[i] {
+			root := find(i)
+			groups[root] = append(groups[root], i)
+		}
+	}
+
+	var clusters [][]int
+	for _, members := range groups {
+		if len(members) > 1 {
+			sort.Ints(members)
+			clusters = append(clusters, members)
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i]) != len(clusters[j]) {
+			return len(clusters[i]) > len(clusters[j])
+		}
+		return clusters[i][0] < clusters[j][0]
+	})
+	return clusters
+}
+
+// Collapse keeps the first (best-ranked) item of every cluster of near-duplicates.
+// It returns the indexes to keep, in input order, and for each kept index the
+// indexes of the duplicates collapsed into it.
+func Collapse(fingerprints []string, threshold float64) (keep []int, duplicates map[int][]int) {
+	duplicates = make(map[int][]int)
+	collapsed := make(map[int]bool)
+	for _, cluster := range Cluster(fingerprints, threshold) {
+		duplicates[cluster[0]] = cluster[1:]
+		for _, i := range cluster[1:] {
+			collapsed[i] = true
+		}
+	}
+
+	for i := range fingerprints {
+		if !collapsed[i] {
+			keep = append(keep, i)
+		}
+	}
+	return keep, duplicates
+}
@@ -0,0 +1,63 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/whisller/pkit/internal/parser"
+	"github.com/whisller/pkit/pkg/models"
+)
+
+// ErrNotIncremental is returned by ChangedPrompts for source formats whose
+// prompts can't be parsed file by file, such as a single CSV of prompts.
+var ErrNotIncremental = errors.New("source format can only be re-indexed in full")
+
+// PromptChanges are the prompts of a source that changed between two commits.
+type PromptChanges struct {
+	// Prompts added or modified
+	Updated []models.Prompt
+
+	// IDs of prompts whose files were deleted
+	Deleted []string
+}
+
+// ChangedPrompts parses the prompts of the files changed in a source between
+// two commits. The source must be checked out at toSHA.
+func ChangedPrompts(src *models.Source, fromSHA, toSHA string) (*PromptChanges, error) {
+	p, err := GetParser(src.Format)
+	if err != nil {
+		return nil, err
+	}
+	fileParser, ok := p.(parser.FileParser)
+	if !ok {
+		return nil, ErrNotIncremental
+	}
+
+	paths, err := ChangedFiles(src.LocalPath, fromSHA, toSHA)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &PromptChanges{}
+	for _, path := range paths {
+		id, ok := fileParser.PromptID(src, path)
+		if !ok {
+			continue
+		}
+
+		if _, err := os.Stat(filepath.Join(src.LocalPath, filepath.FromSlash(path))); os.IsNotExist(err) {
+			changes.Deleted = append(changes.Deleted, id)
+			continue
+		}
+
+		prompt, err := fileParser.ParseFile(src, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		changes.Updated = append(changes.Updated, prompt)
+	}
+
+	return changes, nil
+}
@@ -3,9 +3,11 @@ package source
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
@@ -105,6 +107,88 @@ func GetCurrentCommitSHA(localPath string) (string, error) {
 	return ref.Hash().String(), nil
 }
 
+// ReadFileAtRevision returns the contents of a file as of a git revision
+// (commit SHA, branch, tag, or expressions such as HEAD~2).
+// path is relative to the repository root.
+func ReadFileAtRevision(localPath, rev, path string) ([]byte, error) {
+	repo, err := git.PlainOpen(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision '%s': %w", rev, err)
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", hash, err)
+	}
+
+	file, err := commit.File(filepath.ToSlash(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s does not exist at revision '%s': %w", path, rev, err)
+	}
+
+	contents, err := file.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at revision '%s': %w", path, rev, err)
+	}
+
+	return []byte(contents), nil
+}
+
+// ChangedFiles returns the paths of files added, modified or deleted between
+// two commits of a local repository, relative to the repository root with
+// forward slashes. A renamed file is listed under its old and new path.
+func ChangedFiles(localPath, fromSHA, toSHA string) ([]string, error) {
+	repo, err := git.PlainOpen(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+
+	fromTree, err := commitTree(repo, fromSHA)
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := commitTree(repo, toSHA)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTree(fromTree, toTree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff %s..%s: %w", fromSHA, toSHA, err)
+	}
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if name != "" && !seen[name] {
+				seen[name] = true
+				paths = append(paths, name)
+			}
+		}
+	}
+	return paths, nil
+}
+
+// commitTree returns the tree of a commit.
+func commitTree(repo *git.Repository, sha string) (*object.Tree, error) {
+	commit, err := repo.CommitObject(plumbing.NewHash(sha))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit %s: %w", sha, err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree of commit %s: %w", sha, err)
+	}
+	return tree, nil
+}
+
 // FetchRemote fetches remote changes without merging (for checking updates).
 // Returns the remote HEAD commit SHA and any error.
 func FetchRemote(localPath, token string) (remoteSHA string, err error) {
@@ -1,12 +1,14 @@
 package source
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/whisller/pkit/internal/config"
+	"github.com/whisller/pkit/internal/parser"
 	"github.com/whisller/pkit/pkg/models"
 )
 
@@ -18,10 +20,67 @@ func LoadPromptContent(prompt *models.Prompt) error {
 		return nil
 	}
 
+	fullPath, err := PromptFilePath(prompt)
+	if err != nil {
+		return err
+	}
+
+	// Read the file
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to read prompt file %s: %w", fullPath, err)
+	}
+
+	// Set the content
+	prompt.Content = string(content)
+	return nil
+}
+
+// LoadPromptContentAt returns a prompt's content as of a git revision of its source.
+// For awesome-chatgpt sources the prompt is looked up in prompts.csv at that revision.
+func LoadPromptContentAt(prompt *models.Prompt, rev string) (string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return "", fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var source *models.Source
+	for _, src := range cfg.Sources {
+		if src.ID == prompt.SourceID {
+			source = &src
+			break
+		}
+	}
+
+	if source == nil {
+		return "", fmt.Errorf("source not found: %s", prompt.SourceID)
+	}
+
+	if source.Format == "awesome_chatgpt" {
+		data, err := ReadFileAtRevision(source.LocalPath, rev, "prompts.csv")
+		if err != nil {
+			return "", err
+		}
+		content, err := parser.FindAwesomePrompt(bytes.NewReader(data), prompt.Name)
+		if err != nil {
+			return "", fmt.Errorf("%s at revision '%s': %w", prompt.ID, rev, err)
+		}
+		return content, nil
+	}
+
+	data, err := ReadFileAtRevision(source.LocalPath, rev, prompt.FilePath)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// PromptFilePath returns the absolute path of the file a prompt was parsed from.
+func PromptFilePath(prompt *models.Prompt) (string, error) {
 	// Get the source for this prompt
 	cfg, err := config.Load()
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return "", fmt.Errorf("failed to load config: %w", err)
 	}
 
 	// Find the source by ID
@@ -34,31 +93,47 @@ func LoadPromptContent(prompt *models.Prompt) error {
 	}
 
 	if source == nil {
-		return fmt.Errorf("source not found: %s", prompt.SourceID)
+		return "", fmt.Errorf("source not found: %s", prompt.SourceID)
 	}
 
-	// Determine full file path based on whether it's a cache path or source path
-	var fullPath string
+	return PromptFilePathIn(source, prompt)
+}
 
+// PromptFilePathIn resolves a prompt's file path against the given source,
+// which need not be subscribed (e.g. a repository being linted).
+func PromptFilePathIn(source *models.Source, prompt *models.Prompt) (string, error) {
 	if strings.HasPrefix(prompt.FilePath, "cache/") {
 		// Cache path: resolve from ~/.pkit/
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			return fmt.Errorf("failed to get user home directory: %w", err)
+			return "", fmt.Errorf("failed to get user home directory: %w", err)
 		}
-		fullPath = filepath.Join(homeDir, ".pkit", prompt.FilePath)
-	} else {
-		// Source path: resolve from source.LocalPath
-		fullPath = filepath.Join(source.LocalPath, prompt.FilePath)
+		return filepath.Join(homeDir, ".pkit", prompt.FilePath), nil
 	}
 
-	// Read the file
-	content, err := os.ReadFile(fullPath)
+	// Source path: resolve from source.LocalPath
+	return filepath.Join(source.LocalPath, prompt.FilePath), nil
+}
+
+// LoadUserPart loads the fabric-style user.md that sits next to a prompt's system.md.
+// Returns an empty string when the prompt has no user part.
+func LoadUserPart(prompt *models.Prompt) (string, error) {
+	if prompt.SourceID == "" || filepath.Base(prompt.FilePath) != "system.md" {
+		return "", nil
+	}
+
+	systemPath, err := PromptFilePath(prompt)
 	if err != nil {
-		return fmt.Errorf("failed to read prompt file %s: %w", fullPath, err)
+		return "", err
 	}
 
-	// Set the content
-	prompt.Content = string(content)
-	return nil
+	content, err := os.ReadFile(filepath.Join(filepath.Dir(systemPath), "user.md"))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read user part: %w", err)
+	}
+
+	return string(content), nil
 }
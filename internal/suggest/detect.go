@@ -0,0 +1,180 @@
+// Package suggest works out what kind of text was piped into 'pkit suggest'
+// (a diff, a stack trace, a log, code or prose) so prompts that handle that
+// kind of input can be ranked first.
+package suggest
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Kind is the kind of input text.
+type Kind string
+
+// Kinds of input, most specific first.
+const (
+	KindDiff       Kind = "diff"
+	KindStackTrace Kind = "stack trace"
+	KindLog        Kind = "log"
+	KindCode       Kind = "code"
+	KindProse      Kind = "prose"
+)
+
+// Hints describes input text.
+type Hints struct {
+	Kind Kind
+
+	// Programming language of code, diffs and stack traces, when recognised
+	// (e.g. "go", "python"); empty otherwise
+	Language string
+
+	// Words used by prompts that handle this kind of input
+	Terms []string
+}
+
+// kindTerms are the words prompts for each kind of input tend to use.
+var kindTerms = map[Kind][]string{
+	KindDiff:       {"review", "code", "change", "commit", "diff", "pull request"},
+	KindStackTrace: {"error", "debug", "bug", "fix", "exception", "stack trace"},
+	KindLog:        {"log", "analyze", "error", "incident"},
+	KindCode:       {"code", "review", "explain", "refactor"},
+	KindProse:      {"summarize", "summary", "extract", "article", "text"},
+}
+
+// Thresholds on the share of non-blank lines that look like log entries or code.
+const (
+	minLogLines  = 0.5
+	minCodeLines = 0.3
+)
+
+var (
+	diffLine = regexp.MustCompile(`(?m)^(diff --git |@@ -\d+(,\d+)? \+\d+(,\d+)? @@|--- a/|\+\+\+ b/)`)
+	diffFile = regexp.MustCompile(`(?m)^(?:\+\+\+ b/|diff --git a/\S+ b/)(\S+)`)
+
+	stackTraces = []struct {
+		language string
+		pattern  *regexp.Regexp
+	}{
+		{"python", regexp.MustCompile(`Traceback \(most recent call last\):`)},
+		{"go", regexp.MustCompile(`(?m)^goroutine \d+ \[|^panic: `)},
+		{"java", regexp.MustCompile(`(?m)^\s+at [\w$.]+\([\w$]+\.java:\d+\)|Exception in thread "`)},
+		{"javascript", regexp.MustCompile(`(?m)^\s+at .*\(?[^\s()]+\.[cm]?[jt]s:\d+:\d+\)?$`)},
+		{"rust", regexp.MustCompile(`thread '.*' panicked at`)},
+	}
+
+	logLine = regexp.MustCompile(`^\S*\s*(\[?\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}|\[?\d{2}:\d{2}:\d{2}|\[?(TRACE|DEBUG|INFO|WARN|WARNING|ERROR|FATAL)\b)`)
+
+	codeLine = regexp.MustCompile(`[{};]\s*$|^\s*(func|def|class|import|package|from|return|if|for|while|const|let|var|fn|pub|public|private|#include|//|#!)\b`)
+
+	languages = []struct {
+		language string
+		pattern  *regexp.Regexp
+	}{
+		{"go", regexp.MustCompile(`(?m)^package \w+$|^func (\(\w+ \*?\w+\) )?\w+\(`)},
+		{"python", regexp.MustCompile(`(?m)^\s*def \w+\(.*\):\s*$|^\s*from [\w.]+ import |^\s*import \w+$`)},
+		{"rust", regexp.MustCompile(`(?m)^\s*(pub )?fn \w+|let mut |^use \w+::`)},
+		{"java", regexp.MustCompile(`(?m)^\s*public (final )?(class|interface) |System\.out\.`)},
+		{"javascript", regexp.MustCompile(`(?m)^\s*(const|let) \w+ = |=> \{|require\(['"]|^\s*function \w+\(`)},
+		{"c", regexp.MustCompile(`(?m)^#include [<"]`)},
+	}
+
+	// Languages of files named in diffs, by extension
+	extensions = map[string]string{
+		".go": "go", ".py": "python", ".rs": "rust", ".java": "java", ".kt": "kotlin",
+		".js": "javascript", ".jsx": "javascript", ".ts": "typescript", ".tsx": "typescript",
+		".rb": "ruby", ".php": "php", ".c": "c", ".h": "c", ".cpp": "c++", ".cs": "c#",
+		".swift": "swift", ".sh": "shell", ".sql": "sql", ".md": "markdown",
+	}
+)
+
+// Detect works out what kind of text input is.
+func Detect(input string) Hints {
+	hints := Hints{Kind: detectKind(input)}
+
+	switch hints.Kind {
+	case KindDiff:
+		hints.Language = diffLanguage(input)
+	case KindStackTrace:
+		for _, trace := range stackTraces {
+			if trace.pattern.MatchString(input) {
+				hints.Language = trace.language
+				break
+			}
+		}
+	case KindCode:
+		hints.Language = codeLanguage(input)
+	}
+
+	hints.Terms = append([]string{}, kindTerms[hints.Kind]...)
+	if hints.Language != "" {
+		hints.Terms = append(hints.Terms, hints.Language)
+	}
+	return hints
+}
+
+// detectKind checks for each kind of input, most specific first.
+func detectKind(input string) Kind {
+	if len(diffLine.FindAllStringIndex(input, 3)) >= 2 {
+		return KindDiff
+	}
+
+	for _, trace := range stackTraces {
+		if trace.pattern.MatchString(input) {
+			return KindStackTrace
+		}
+	}
+
+	var lines, logLines, codeLines int
+	for _, line := range strings.Split(input, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines++
+		if logLine.MatchString(line) {
+			logLines++
+		}
+		if codeLine.MatchString(line) {
+			codeLines++
+		}
+	}
+	if lines == 0 {
+		return KindProse
+	}
+
+	switch {
+	case float64(logLines)/float64(lines) >= minLogLines:
+		return KindLog
+	case float64(codeLines)/float64(lines) >= minCodeLines:
+		return KindCode
+	default:
+		return KindProse
+	}
+}
+
+// diffLanguage returns the language of most files a diff changes.
+func diffLanguage(input string) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, match := range diffFile.FindAllStringSubmatch(input, -1) {
+		language := extensions[strings.ToLower(path.Ext(match[1]))]
+		if language == "" {
+			continue
+		}
+		counts[language]++
+		if best == "" || counts[language] > counts[best] {
+			best = language
+		}
+	}
+	return best
+}
+
+// codeLanguage returns the first language whose telltale syntax input has.
+func codeLanguage(input string) string {
+	for _, l := range languages {
+		if l.pattern.MatchString(input) {
+			return l.language
+		}
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package suggest
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantKind     Kind
+		wantLanguage string
+	}{
+		{
+			name: "git diff",
+			input: `diff --git a/cmd/main.go b/cmd/main.go
+index 1c2d3e4..5f6a7b8 100644
+--- a/cmd/main.go
++++ b/cmd/main.go
+@@ -10,6 +10,7 @@ func main() {
++	log.Println("starting")
+`,
+			wantKind:     KindDiff,
+			wantLanguage: "go",
+		},
+		{
+			name: "python traceback",
+			input: `Traceback (most recent call last):
+  File "app.py", line 3, in <module>
+    main()
+ZeroDivisionError: division by zero
+`,
+			wantKind:     KindStackTrace,
+			wantLanguage: "python",
+		},
+		{
+			name: "go panic",
+			input: `panic: runtime error: index out of range [3] with length 3
+
+goroutine 1 [running]:
+main.main()
+	/tmp/main.go:8 +0x1d
+`,
+			wantKind:     KindStackTrace,
+			wantLanguage: "go",
+		},
+		{
+			name: "log",
+			input: `2025-01-02 10:00:01 INFO server started
+2025-01-02 10:00:05 WARN slow request /api/search
+2025-01-02 10:00:09 ERROR connection reset
+`,
+			wantKind: KindLog,
+		},
+		{
+			name: "python code",
+			input: `import os
+
+def main():
+    path = os.getcwd()
+    print(path)
+`,
+			wantKind:     KindCode,
+			wantLanguage: "python",
+		},
+		{
+			name: "prose",
+			input: `The committee met on Tuesday to discuss the budget. After a long debate,
+members agreed to postpone the decision until more figures are available.
+`,
+			wantKind: KindProse,
+		},
+		{
+			name:     "empty",
+			input:    "\n\n",
+			wantKind: KindProse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Detect(tt.input)
+			if got.Kind != tt.wantKind || got.Language != tt.wantLanguage {
+				t.Errorf("Detect() = %s (%q), want %s (%q)", got.Kind, got.Language, tt.wantKind, tt.wantLanguage)
+			}
+		})
+	}
+}
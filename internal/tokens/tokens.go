@@ -0,0 +1,140 @@
+// Package tokens estimates how many LLM tokens a piece of text uses.
+//
+// Counts use an offline BPE tokenizer for the cl100k_base and o200k_base
+// encodings (vocabularies are embedded in the binary). When an encoding is
+// unknown or cannot be loaded, counts fall back to a characters/4 estimate.
+package tokens
+
+import (
+	"strconv"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+	"github.com/whisller/pkit/internal/config"
+)
+
+// Supported encodings.
+const (
+	EncodingCL100K   = "cl100k_base"
+	EncodingO200K    = "o200k_base"
+	EncodingEstimate = "estimate"
+)
+
+// DefaultEncoding is used when no encoding is configured.
+const DefaultEncoding = EncodingCL100K
+
+// Counter counts tokens in text.
+type Counter interface {
+	// Count returns the number of tokens in text
+	Count(text string) int
+
+	// Encoding returns the name of the encoding used
+	Encoding() string
+}
+
+func init() {
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+var (
+	countersMu sync.Mutex
+	counters   = map[string]Counter{}
+
+	defaultOnce    sync.Once
+	defaultCounter Counter
+)
+
+// Get returns a counter for the named encoding.
+// Unknown encodings, or encodings that fail to load, fall back to the estimate.
+func Get(encoding string) Counter {
+	if encoding == "" {
+		encoding = DefaultEncoding
+	}
+
+	countersMu.Lock()
+	defer countersMu.Unlock()
+
+	if c, ok := counters[encoding]; ok {
+		return c
+	}
+
+	var c Counter = estimateCounter{}
+	if encoding != EncodingEstimate {
+		if enc, err := tiktoken.GetEncoding(encoding); err == nil {
+			c = &bpeCounter{name: encoding, enc: enc}
+		}
+	}
+
+	counters[encoding] = c
+	return c
+}
+
+// Default returns the counter for the encoding configured in ~/.pkit/config.yml.
+func Default() Counter {
+	defaultOnce.Do(func() {
+		encoding := DefaultEncoding
+		if cfg, err := config.Load(); err == nil && cfg.Tokens.Encoding != "" {
+			encoding = cfg.Tokens.Encoding
+		}
+		defaultCounter = Get(encoding)
+	})
+	return defaultCounter
+}
+
+// Count counts tokens in text using the configured encoding.
+func Count(text string) int {
+	return Default().Count(text)
+}
+
+// Estimate approximates the token count as one token per four characters.
+func Estimate(text string) int {
+	chars := utf8.RuneCountInString(text)
+	return (chars + 3) / 4
+}
+
+// Format renders a token count compactly (e.g. "850", "1.2k", "12k").
+func Format(n int) string {
+	switch {
+	case n < 1000:
+		return strconv.Itoa(n)
+	case n < 10000:
+		whole, tenth := n/1000, (n%1000)/100
+		if tenth == 0 {
+			return strconv.Itoa(whole) + "k"
+		}
+		return strconv.Itoa(whole) + "." + strconv.Itoa(tenth) + "k"
+	default:
+		return strconv.Itoa((n+500)/1000) + "k"
+	}
+}
+
+// bpeCounter counts tokens with a tiktoken BPE encoding.
+type bpeCounter struct {
+	name string
+	enc  *tiktoken.Tiktoken
+}
+
+func (c *bpeCounter) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	// Special tokens in prompt text are counted as ordinary text
+	return len(c.enc.EncodeOrdinary(text))
+}
+
+func (c *bpeCounter) Encoding() string {
+	return c.name
+}
+
+// estimateCounter approximates token counts from the character count.
+type estimateCounter struct{}
+
+func (estimateCounter) Count(text string) int {
+	return Estimate(text)
+}
+
+func (estimateCounter) Encoding() string {
+	return EncodingEstimate
+}
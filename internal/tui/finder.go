@@ -15,8 +15,10 @@ import (
 	"github.com/mattn/go-runewidth"
 	"github.com/rmhubbert/bubbletea-overlay"
 	"github.com/whisller/pkit/internal/bookmark"
+	"github.com/whisller/pkit/internal/index"
 	"github.com/whisller/pkit/internal/source"
 	"github.com/whisller/pkit/internal/tag"
+	"github.com/whisller/pkit/internal/tokens"
 	"github.com/whisller/pkit/pkg/models"
 )
 
@@ -66,6 +68,7 @@ type KeyMap struct {
 	SwitchPanel    key.Binding
 	ToggleFilter   key.Binding
 	Search         key.Binding // T010: Search key binding
+	Sort           key.Binding
 }
 
 // DefaultKeyMap returns the default key bindings
@@ -131,13 +134,18 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("/"),
 			key.WithHelp("/", "search"),
 		),
+		Sort: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "change sort order"),
+		),
 	}
 }
 
 // PromptItem wraps a prompt for display in the list
 type PromptItem struct {
 	Prompt     models.Prompt
-	Bookmarked bool // Whether this prompt is bookmarked
+	Bookmarked bool   // Whether this prompt is bookmarked
+	Snippet    string // Highlighted match shown instead of the description (search only)
 }
 
 func (i PromptItem) Title() string {
@@ -147,11 +155,53 @@ func (i PromptItem) Title() string {
 	}
 	return i.Prompt.ID
 }
-func (i PromptItem) Description() string { return i.Prompt.Description }
+func (i PromptItem) Description() string {
+	description := i.Prompt.Description
+	if i.Snippet != "" {
+		description = i.Snippet
+	}
+
+	// Show estimated token count next to the description
+	if i.Prompt.TokenCount > 0 {
+		return fmt.Sprintf("%s · ~%s tokens", description, tokens.Format(i.Prompt.TokenCount))
+	}
+	return description
+}
 func (i PromptItem) FilterValue() string {
 	return i.Prompt.ID + " " + i.Prompt.Name + " " + i.Prompt.Description
 }
 
+// SearchHit is a prompt matching a search query.
+type SearchHit struct {
+	ID string
+
+	// Matched fragments per field, in index.SearchResult.Highlights format
+	Highlights map[string][]string
+}
+
+// SearchFunc returns the prompts matching a query in the given order (one of
+// index.SortOrders). An empty query matches every prompt.
+type SearchFunc func(query string, order string) ([]SearchHit, error)
+
+// matchStyle marks matched terms in search snippets.
+var matchStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("220"))
+
+// FinderOptions connects the finder to the search index.
+type FinderOptions struct {
+	// Search runs the search box query; when nil, prompts are matched by substring
+	Search SearchFunc
+
+	// Sort is the initial order of the list (one of index.SortOrders). Prompts
+	// are passed in score order; other orders are applied through Search.
+	Sort string
+
+	// MetadataChanged is called after the finder changes a prompt's tags or bookmark
+	MetadataChanged func(promptID string)
+
+	// Similar returns the IDs of prompts like the previewed one (may be nil)
+	Similar func(promptID string) ([]string, error)
+}
+
 // FinderModel is the Bubbletea model for the interactive finder
 type FinderModel struct {
 	list            list.Model
@@ -179,6 +229,7 @@ type FinderModel struct {
 	statusTimeout   time.Time
 	currentPromptID string         // ID of prompt being operated on
 	currentPrompt   *models.Prompt // Full prompt for preview
+	currentSimilar  []string       // IDs of prompts like the previewed one
 	promptTags      []string       // Tags for current prompt (for removal)
 	tagRemoveCursor int
 	previewScroll   int // Scroll position for preview
@@ -195,6 +246,15 @@ type FinderModel struct {
 	searchQuery   string
 	searchInput   textinput.Model
 	preSearchList []models.Prompt
+	searchFunc    SearchFunc          // nil: substring match on ID, name and description
+	searchMatches map[string][]string // Highlighted matches of the last search, by prompt ID
+	sortOrder     string              // One of index.SortOrders
+
+	// Called after tags or bookmarks of a prompt change (may be nil)
+	metadataChangedFunc func(promptID string)
+
+	// Finds prompts like the previewed one (may be nil)
+	similarFunc func(promptID string) ([]string, error)
 
 	// Tag truncation (T006)
 	tagTruncateLength int
@@ -211,7 +271,7 @@ type FinderModel struct {
 }
 
 // NewFinderModel creates a new finder model
-func NewFinderModel(prompts []models.Prompt) FinderModel {
+func NewFinderModel(prompts []models.Prompt, opts FinderOptions) FinderModel {
 	// Extract available sources
 	sourceSet := make(map[string]bool)
 	for _, p := range prompts {
@@ -293,6 +353,10 @@ func NewFinderModel(prompts []models.Prompt) FinderModel {
 		inputMode:           ModeNormal,
 		textInput:           ti,
 		searchInput:         searchInput,
+		searchFunc:          opts.Search,
+		sortOrder:           opts.Sort,
+		metadataChangedFunc: opts.MetadataChanged,
+		similarFunc:         opts.Similar,
 		tagTruncateLength:   25,
 		truncatedTags:       make(map[string]string),
 		previewMinHeight:    15,
@@ -358,7 +422,7 @@ func (m *FinderModel) applyFilters() {
 		filtered = append(filtered, p)
 	}
 
-	m.filteredPrompts = filtered
+	m.filteredPrompts = m.sortPrompts(filtered)
 
 	// Update list items with bookmark indicators
 	items := make([]list.Item, len(filtered))
@@ -376,10 +440,26 @@ func (m *FinderModel) applyFilters() {
 
 // applySearchFilter searches prompts by ID, name, and description (T013)
 func (m *FinderModel) applySearchFilter(prompts []models.Prompt, query string) []models.Prompt {
+	m.searchMatches = nil
 	if query == "" {
 		return prompts
 	}
 
+	if m.searchFunc != nil {
+		hits, err := m.searchFunc(query, m.sortOrder)
+		if err == nil {
+			ids := make([]string, len(hits))
+			m.searchMatches = make(map[string][]string, len(hits))
+			for i, hit := range hits {
+				ids[i] = hit.ID
+				m.searchMatches[hit.ID] = searchMatches(hit)
+			}
+			return rankPrompts(prompts, ids)
+		}
+		// Usually an incomplete query while typing - fall back to substring matching
+		m.setStatus(err.Error(), 3*time.Second)
+	}
+
 	queryLower := strings.ToLower(query)
 	var results []models.Prompt
 
@@ -395,6 +475,105 @@ func (m *FinderModel) applySearchFilter(prompts []models.Prompt, query string) [
 	return results
 }
 
+// searchMatches formats the matched description and content fragments of a hit
+// for display, description first.
+func searchMatches(hit SearchHit) []string {
+	var matches []string
+	for _, field := range []string{"description", "content"} {
+		for _, fragment := range hit.Highlights[field] {
+			matches = append(matches, index.FormatFragment(fragment, func(term string) string {
+				return matchStyle.Render(term)
+			}))
+		}
+	}
+	return matches
+}
+
+// searchItems wraps search results as list items, showing the best match of
+// each prompt in place of its description.
+func (m *FinderModel) searchItems(prompts []models.Prompt) []list.Item {
+	items := make([]list.Item, len(prompts))
+	for i, p := range prompts {
+		item := PromptItem{
+			Prompt:     p,
+			Bookmarked: m.bookmarkedIDs[p.ID],
+		}
+		if matches := m.searchMatches[p.ID]; len(matches) > 0 {
+			item.Snippet = matches[0]
+		}
+		items[i] = item
+	}
+	return items
+}
+
+// sortPrompts orders prompts by the current sort order. Score order is the
+// order the prompts were passed in.
+func (m *FinderModel) sortPrompts(prompts []models.Prompt) []models.Prompt {
+	if m.searchFunc == nil || m.sortOrder == "" || m.sortOrder == index.SortScore {
+		return prompts
+	}
+
+	hits, err := m.searchFunc("", m.sortOrder)
+	if err != nil {
+		m.setStatus(err.Error(), 3*time.Second)
+		return prompts
+	}
+
+	position := make(map[string]int, len(hits))
+	for i, hit := range hits {
+		position[hit.ID] = i
+	}
+
+	sorted := make([]models.Prompt, len(prompts))
+	copy(sorted, prompts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, iok := position[sorted[i].ID]
+		pj, jok := position[sorted[j].ID]
+		if iok != jok {
+			return iok // Prompts the search returned come first
+		}
+		return pi < pj
+	})
+	return sorted
+}
+
+// cycleSort switches to the next sort order and reorders the list, keeping
+// the current search results.
+func (m *FinderModel) cycleSort() {
+	next := index.SortOrders[0]
+	for i, order := range index.SortOrders {
+		if order == m.sortOrder && i+1 < len(index.SortOrders) {
+			next = index.SortOrders[i+1]
+		}
+	}
+	m.sortOrder = next
+	m.setStatus("Sorted by "+next, 2*time.Second)
+
+	m.applyFilters()
+	if m.searchQuery != "" {
+		m.preSearchList = m.filteredPrompts
+		m.filteredPrompts = m.applySearchFilter(m.preSearchList, m.searchQuery)
+		m.list.SetItems(m.searchItems(m.filteredPrompts))
+		m.updatePagination()
+	}
+}
+
+// rankPrompts returns the prompts whose IDs are in ids, in the order of ids.
+func rankPrompts(prompts []models.Prompt, ids []string) []models.Prompt {
+	byID := make(map[string]models.Prompt, len(prompts))
+	for _, p := range prompts {
+		byID[p.ID] = p
+	}
+
+	results := make([]models.Prompt, 0, len(ids))
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			results = append(results, p)
+		}
+	}
+	return results
+}
+
 // truncateTag truncates tag names to max 25 visual characters (T030, T031)
 func truncateTag(tag string) string {
 	const MaxTagDisplayLength = 25
@@ -490,6 +669,7 @@ func (m *FinderModel) toggleBookmarkInPreview(removeOnly bool) {
 	promptID := m.currentPrompt.ID
 	isBookmarked := m.bookmarkedIDs[promptID]
 	bmMgr := bookmark.NewManager()
+	defer m.metadataChanged(promptID)
 
 	if removeOnly {
 		// ctrl+x: Only remove if bookmarked
@@ -584,18 +764,18 @@ func (m *FinderModel) reloadData() {
 		m.filteredPrompts = m.applySearchFilter(m.preSearchList, m.searchQuery)
 
 		// Update list items with search results and bookmark indicators
-		items := make([]list.Item, len(m.filteredPrompts))
-		for i, p := range m.filteredPrompts {
-			items[i] = PromptItem{
-				Prompt:     p,
-				Bookmarked: m.bookmarkedIDs[p.ID],
-			}
-		}
-		m.list.SetItems(items)
+		m.list.SetItems(m.searchItems(m.filteredPrompts))
 		m.updatePagination()
 	}
 }
 
+// metadataChanged reports a change to a prompt's tags or bookmark
+func (m *FinderModel) metadataChanged(promptID string) {
+	if m.metadataChangedFunc != nil {
+		m.metadataChangedFunc(promptID)
+	}
+}
+
 // setStatus sets a status message with timeout
 func (m *FinderModel) setStatus(msg string, duration time.Duration) {
 	m.statusMessage = msg
@@ -659,7 +839,8 @@ func (m FinderModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.searchQuery = ""
 				m.searchInput.SetValue("")
 				m.filteredPrompts = m.preSearchList // Restore pre-search list
-				m.applyFilters()                    // Reapply filters to update list display
+				m.searchMatches = nil
+				m.applyFilters() // Reapply filters to update list display
 				return m, nil
 
 			case key.Matches(msg, m.keys.Select): // Enter to apply search
@@ -697,7 +878,8 @@ func (m FinderModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.searchMode = false
 					m.searchInput.SetValue("")
 					m.filteredPrompts = m.preSearchList // Restore pre-search list
-					m.applyFilters()                    // Reapply filters to update list display
+					m.searchMatches = nil
+					m.applyFilters() // Reapply filters to update list display
 					return m, cmd
 				}
 
@@ -705,14 +887,7 @@ func (m FinderModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.filteredPrompts = m.applySearchFilter(m.preSearchList, m.searchQuery)
 
 				// Update list with search results and bookmark indicators
-				items := make([]list.Item, len(m.filteredPrompts))
-				for i, p := range m.filteredPrompts {
-					items[i] = PromptItem{
-						Prompt:     p,
-						Bookmarked: m.bookmarkedIDs[p.ID],
-					}
-				}
-				m.list.SetItems(items)
+				m.list.SetItems(m.searchItems(m.filteredPrompts))
 
 				// Update pagination after search results change
 				m.updatePagination()
@@ -748,6 +923,10 @@ func (m FinderModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			return m, nil
 
+		case key.Matches(msg, m.keys.Sort):
+			m.cycleSort()
+			return m, nil
+
 		case key.Matches(msg, m.keys.SwitchPanel):
 			// Switch between filters and list panels
 			if m.activePanel == PanelFilters {
@@ -904,6 +1083,7 @@ func (m FinderModel) processInput() (tea.Model, tea.Cmd) {
 // addTags replaces tags for the current prompt (updates, not merges)
 func (m FinderModel) addTags(tagsStr string) (tea.Model, tea.Cmd) {
 	tagMgr := tag.NewManager()
+	defer m.metadataChanged(m.currentPromptID)
 
 	// Parse new tags (comma-separated)
 	tags := strings.Split(tagsStr, ",")
@@ -963,6 +1143,7 @@ func (m FinderModel) addAlias(aliasName string) (tea.Model, tea.Cmd) {
 
 	// Add bookmark with alias (alias is stored in bookmarks)
 	mgr := bookmark.NewManager()
+	defer m.metadataChanged(m.currentPromptID)
 	bm := models.Bookmark{
 		PromptID: m.currentPromptID,
 		Notes:    fmt.Sprintf("Alias: %s", aliasName),
@@ -983,6 +1164,7 @@ func (m FinderModel) addAlias(aliasName string) (tea.Model, tea.Cmd) {
 // addNotes adds notes to the bookmark
 func (m FinderModel) addNotes(notes string) (tea.Model, tea.Cmd) {
 	mgr := bookmark.NewManager()
+	defer m.metadataChanged(m.currentPromptID)
 
 	// Check if already bookmarked
 	if !m.bookmarkedIDs[m.currentPromptID] {
@@ -1022,6 +1204,7 @@ func (m FinderModel) removeTag(idx int) (tea.Model, tea.Cmd) {
 
 	tagToRemove := m.promptTags[idx]
 	tagMgr := tag.NewManager()
+	defer m.metadataChanged(m.currentPromptID)
 
 	if err := tagMgr.RemoveTags(m.currentPromptID, []string{tagToRemove}); err != nil {
 		m.setStatus(fmt.Sprintf("Error: %v", err), 3*time.Second)
@@ -1179,6 +1362,12 @@ func (m FinderModel) updateListPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
+			// Similar prompts are best effort; the preview works without them
+			m.currentSimilar = nil
+			if m.similarFunc != nil {
+				m.currentSimilar, _ = m.similarFunc(m.currentPrompt.ID)
+			}
+
 			m.inputMode = ModeViewingPrompt
 			m.previewScroll = 0
 			return m, nil
@@ -1195,6 +1384,7 @@ func (m FinderModel) updateListPanel(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 // toggleBookmark toggles bookmark status for a prompt
 func (m FinderModel) toggleBookmark(promptID string) (tea.Model, tea.Cmd) {
 	mgr := bookmark.NewManager()
+	defer m.metadataChanged(promptID)
 
 	if m.bookmarkedIDs[promptID] {
 		// Remove bookmark
@@ -1478,6 +1668,14 @@ func (m *FinderModel) renderPromptPreview() string {
 	if len(m.currentPrompt.Tags) > 0 {
 		meta.WriteString(fmt.Sprintf("Tags: %s\n", strings.Join(m.currentPrompt.Tags, ", ")))
 	}
+	if m.currentPrompt.TokenCount > 0 {
+		meta.WriteString(fmt.Sprintf("Tokens: ~%d\n", m.currentPrompt.TokenCount))
+	}
+
+	// Where the search matched
+	for _, match := range m.searchMatches[m.currentPrompt.ID] {
+		meta.WriteString(fmt.Sprintf("Match: %s\n", match))
+	}
 
 	// Get user tags if any
 	tagMgr := tag.NewManager()
@@ -1491,6 +1689,10 @@ func (m *FinderModel) renderPromptPreview() string {
 		meta.WriteString("Bookmarked: Yes\n")
 	}
 
+	if len(m.currentSimilar) > 0 {
+		meta.WriteString(fmt.Sprintf("Similar: %s\n", strings.Join(m.currentSimilar, ", ")))
+	}
+
 	// Calculate content area height (dialog height - metadata - title - help - padding)
 	contentHeight := dialogHeight - 10
 
@@ -1799,15 +2001,15 @@ func (m *FinderModel) generateHelpText() string {
 
 	case m.activePanel == PanelFilters:
 		// T025: Filter Panel help
-		return "↑/↓: navigate | Space: toggle | Tab: switch panel | /: search | q: quit"
+		return "↑/↓: navigate | Space: toggle | Tab: switch panel | /: search | s: sort | q: quit"
 
 	default:
 		// T024: Normal mode (prompts list) - check for multiple pages
 		hasMultiplePages := m.totalPages > 1
 		if hasMultiplePages {
-			return "p: preview | enter: select | ←/→: pages | /: search | ctrl+g: get | ctrl+s: bookmark | ctrl+t: tags | tab: filters"
+			return "p: preview | enter: select | ←/→: pages | /: search | s: sort | ctrl+g: get | ctrl+s: bookmark | ctrl+t: tags | tab: filters"
 		}
-		return "p: preview | enter: select | /: search | ctrl+g: get | ctrl+s: bookmark | ctrl+t: tags | ctrl+a: alias | tab: filters"
+		return "p: preview | enter: select | /: search | s: sort | ctrl+g: get | ctrl+s: bookmark | ctrl+t: tags | ctrl+a: alias | tab: filters"
 	}
 }
 
@@ -1922,6 +2124,9 @@ func (m *FinderModel) renderListPanel(width int) string {
 	promptsContent = lipgloss.JoinVertical(lipgloss.Left, promptsContent, bookmarkLegend)
 
 	promptsTitle := fmt.Sprintf("PROMPTS (%d %s)", itemCount, itemWord)
+	if m.sortOrder != "" && m.sortOrder != index.SortScore {
+		promptsTitle = fmt.Sprintf("PROMPTS (%d %s, by %s)", itemCount, itemWord, m.sortOrder)
+	}
 	// T054: Add pagination to prompts panel border
 	borderedPrompts := renderBorderedBox(promptsTitle, promptsContent, width, m.activePanel == PanelList, m.getPaginationText())
 
@@ -1957,8 +2162,8 @@ func (m FinderModel) ShouldTag() bool {
 }
 
 // RunFinder runs the interactive finder and returns the selected prompt ID and action
-func RunFinder(prompts []models.Prompt) (selectedID string, action string, err error) {
-	p := tea.NewProgram(NewFinderModel(prompts), tea.WithAltScreen())
+func RunFinder(prompts []models.Prompt, opts FinderOptions) (selectedID string, action string, err error) {
+	p := tea.NewProgram(NewFinderModel(prompts, opts), tea.WithAltScreen())
 
 	finalModel, err := p.Run()
 	if err != nil {
@@ -2,6 +2,7 @@ package web
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -13,8 +14,12 @@ import (
 
 	"github.com/whisller/pkit/internal/alias"
 	"github.com/whisller/pkit/internal/bookmark"
+	"github.com/whisller/pkit/internal/config"
+	"github.com/whisller/pkit/internal/diff"
 	"github.com/whisller/pkit/internal/index"
+	"github.com/whisller/pkit/internal/similarity"
 	"github.com/whisller/pkit/internal/tag"
+	"github.com/whisller/pkit/internal/tokens"
 	"github.com/whisller/pkit/pkg/models"
 )
 
@@ -23,14 +28,33 @@ type PromptListItem struct {
 	Prompt     models.Prompt
 	Bookmarked bool
 	Tags       []string
+	Duplicates []string // IDs of near-duplicates collapsed into this item
+
+	// Matches highlighted with <mark> (set when searching)
+	DescriptionHTML template.HTML // Description, when it matched
+	Snippet         template.HTML // Fragment of the content, when it matched
 }
 
+// similarPrompts is how many similar prompts the detail page lists.
+const similarPrompts = 5
+
 // PromptDetail represents a prompt in detail view.
 type PromptDetail struct {
 	Prompt     models.Prompt
 	Bookmarked bool
 	Tags       []string
 	Bookmark   *models.Bookmark
+	Duplicates []string        // IDs of near-duplicate prompts, offered for comparison
+	Similar    []models.Prompt // Prompts like this one ("more like this")
+}
+
+// CompareView represents a side-by-side comparison of two prompts.
+type CompareView struct {
+	A, B      string // Identifiers as requested (alias, prompt ID, optionally @revision)
+	Rows      []diff.Row
+	Changed   bool
+	Additions int
+	Deletions int
 }
 
 // FilterState represents active filters.
@@ -39,6 +63,8 @@ type FilterState struct {
 	SourceFilters []string
 	TagFilters    []string
 	Bookmarked    bool
+	Collapse      bool
+	Sort          string // One of index.SortOrders ("" uses search.sort from config.yml)
 	Page          int
 	PerPage       int
 }
@@ -107,24 +133,38 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
 	filters := parseFilters(r.URL.Query())
+	searchCfg := searchConfig()
+	if filters.Sort == "" {
+		filters.Sort = defaultSort(searchCfg)
+	}
 
 	// Use indexer to search (reuses CLI search logic)
-	// NOTE: Don't filter by tags in indexer - user tags are in tags.yml, not in the index
+	// User tags and bookmarks are indexed with the prompts, so they are filtered in the index
 	// NOTE: Don't filter by source in indexer - we need all sources visible in filter UI
 	searchOpts := index.SearchOptions{
-		Query:      filters.SearchQuery,
-		SourceID:   "", // Always empty - filter sources manually to keep all filter options visible
-		MaxResults: 10000, // Get all results, we'll paginate in memory
-	}
-
+		Query:         filters.SearchQuery,
+		SourceID:      "", // Always empty - filter sources manually to keep all filter options visible
+		Tags:          filters.TagFilters,
+		Bookmarked:    filters.Bookmarked,
+		MaxResults:    0, // Get all results, we'll paginate in memory
+		Fuzzy:         searchCfg.FuzzyMatch,
+		Prefix:        true, // The search box updates as you type
+		CaseSensitive: searchCfg.CaseSensitive,
+		Highlight:     filters.SearchQuery != "",
+		Sort:          filters.Sort,
+	}
+
+	// A query syntax error is shown next to the search box instead of failing the page
+	var queryErr *index.QueryError
 	results, err := s.indexer.Search(searchOpts)
-	if err != nil {
+	if errors.As(err, &queryErr) {
+		results = nil
+	} else if err != nil {
 		http.Error(w, "Search failed", http.StatusInternalServerError)
 		return
 	}
 
-	// Convert to list items and apply user-specific filters (bookmarks, tags, sources)
-	s.cache.mu.RLock()
+	// Convert to list items and apply the source filter
 	items := []PromptListItem{}
 	for _, result := range results {
 		// Apply source filter if any sources selected
@@ -141,65 +181,51 @@ func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		// Apply bookmarked filter (user-specific, not in index)
-		_, isBookmarked := s.cache.bookmarks[result.Prompt.ID]
-		if filters.Bookmarked && !isBookmarked {
-			continue
-		}
+		// Sorted copy of the indexed user tags
+		tags := make([]string, len(result.UserMetadata.UserTags))
+		copy(tags, result.UserMetadata.UserTags)
+		sort.Strings(tags)
 
-		// Get tags for this prompt
-		tags := s.cache.tags[result.Prompt.ID]
-		if tags == nil {
-			tags = []string{}
-		} else {
-			tagsCopy := make([]string, len(tags))
-			copy(tagsCopy, tags)
-			sort.Strings(tagsCopy)
-			tags = tagsCopy
+		item := PromptListItem{
+			Prompt:     result.Prompt,
+			Bookmarked: result.UserMetadata.Bookmarked,
+			Tags:       tags,
 		}
 
-		// Apply tag filters (user tags are not in index, filter manually)
-		if len(filters.TagFilters) > 0 {
-			hasAllTags := true
-			for _, filterTag := range filters.TagFilters {
-				found := false
-				for _, tag := range tags {
-					if tag == filterTag {
-						found = true
-						break
-					}
-				}
-				if !found {
-					hasAllTags = false
-					break
-				}
-			}
-			if !hasAllTags {
-				continue
-			}
+		// Highlight matches in the description, or show where the content matched
+		// Fragments are HTML-escaped by the index, only the <mark> tags are markup
+		if fragment := result.BestFragment("description"); fragment != "" {
+			item.DescriptionHTML = template.HTML(fragment)
+		} else if fragment := result.BestFragment("content"); fragment != "" {
+			item.Snippet = template.HTML(fragment)
 		}
 
-		items = append(items, PromptListItem{
-			Prompt:     result.Prompt,
-			Bookmarked: isBookmarked,
-			Tags:       tags,
-		})
+		items = append(items, item)
+	}
+
+	// Collapse near-duplicates after filtering, keeping the best-ranked of each group
+	if filters.Collapse {
+		items = collapseItems(items)
 	}
-	s.cache.mu.RUnlock()
 
 	// Paginate results
 	paginated := paginate(items, filters.Page, filters.PerPage)
 
 	// Get unique sources and tags for filter UI
-	// Build from ALL results (not filtered items) so all options remain visible
+	// Sources come from results before the source filter (plus selected ones) and
+	// tags from all user tags, so options remain visible while filtering
 	sources := make(map[string]bool)
 	allTags := make(map[string]bool)
 
-	s.cache.mu.RLock()
 	for _, result := range results {
 		sources[result.Prompt.SourceID] = true
-		// Get tags for this prompt
-		tags := s.cache.tags[result.Prompt.ID]
+	}
+	for _, source := range filters.SourceFilters {
+		sources[source] = true
+	}
+
+	s.cache.mu.RLock()
+	for _, tags := range s.cache.tags {
 		for _, tag := range tags {
 			allTags[tag] = true
 		}
@@ -232,6 +258,10 @@ func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
 		"Filters":       filters,
 		"Sources":       sourceList,
 		"Tags":          tagList,
+		"SortOrders":    index.SortOrders,
+	}
+	if queryErr != nil {
+		data["QueryError"] = queryErr.Error()
 	}
 
 	// Render template (errors handled by renderTemplate)
@@ -277,6 +307,9 @@ func (s *Server) handleDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Count the resolved content (includes may differ from the indexed count)
+	prompt.TokenCount = tokens.Count(prompt.Content)
+
 	// Get bookmark status
 	s.cache.mu.RLock()
 	bookmark, isBookmarked := s.cache.bookmarks[promptID]
@@ -304,6 +337,19 @@ func (s *Server) handleDetail(w http.ResponseWriter, r *http.Request) {
 		detail.Bookmark = &bookmark
 	}
 
+	// Near-duplicates make good comparison candidates
+	if duplicates, err := s.indexer.DuplicatesOf(prompt.ID, similarity.DefaultThreshold); err == nil {
+		for _, r := range duplicates {
+			detail.Duplicates = append(detail.Duplicates, r.Prompt.ID)
+		}
+	}
+
+	if similar, err := s.indexer.Similar(prompt.ID, index.SimilarOptions{MaxResults: similarPrompts}); err == nil {
+		for _, r := range similar {
+			detail.Similar = append(detail.Similar, r.Prompt)
+		}
+	}
+
 	// Prepare template data
 	data := map[string]interface{}{
 		"Detail": detail,
@@ -313,6 +359,67 @@ func (s *Server) handleDetail(w http.ResponseWriter, r *http.Request) {
 	_ = s.renderTemplate(w, "detail", data)
 }
 
+// handleCompare handles GET /compare?a=...&b=... - side-by-side diff of two prompts.
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	a := strings.TrimSpace(r.URL.Query().Get("a"))
+	b := strings.TrimSpace(r.URL.Query().Get("b"))
+
+	if a == "" || b == "" {
+		data := map[string]interface{}{
+			"Error":    "Nothing to Compare",
+			"Message":  "Choose two prompts to compare (alias, prompt ID, or ID@revision).",
+			"BackLink": "/",
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_ = s.renderTemplate(w, "error", data)
+		return
+	}
+
+	// Resolve both sides without recording usage
+	aliases, _ := alias.LoadAliases()
+	bookmarks, _ := bookmark.LoadBookmarks()
+	resolver := bookmark.NewResolver(s.indexer, aliases, bookmarks)
+
+	contents := make([]string, 2)
+	for i, spec := range []string{a, b} {
+		prompt, err := resolver.ResolveAt(spec)
+		if err != nil {
+			data := map[string]interface{}{
+				"Error":    "Prompt Not Found",
+				"Message":  fmt.Sprintf("Could not load '%s': %v", spec, err),
+				"BackLink": "/",
+			}
+			w.WriteHeader(http.StatusNotFound)
+			_ = s.renderTemplate(w, "error", data)
+			return
+		}
+		contents[i] = prompt.Content
+	}
+
+	edits := diff.Lines(contents[0], contents[1])
+	view := CompareView{
+		A:       a,
+		B:       b,
+		Rows:    diff.SideBySide(edits),
+		Changed: diff.Changed(edits),
+	}
+	for _, e := range edits {
+		switch e.Op {
+		case diff.Insert:
+			view.Additions++
+		case diff.Delete:
+			view.Deletions++
+		}
+	}
+
+	data := map[string]interface{}{
+		"Compare": view,
+	}
+
+	// Render template (errors handled by renderTemplate)
+	_ = s.renderTemplate(w, "compare", data)
+}
+
 // handleBookmarkToggle handles POST /api/bookmarks - toggle bookmark.
 func (s *Server) handleBookmarkToggle(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
@@ -396,8 +503,9 @@ func (s *Server) handleBookmarkToggle(w http.ResponseWriter, r *http.Request) {
 		message = "Bookmarked"
 	}
 
-	// Reload cache (errors ignored - cache will be reloaded on next request)
+	// Reload cache and update the indexed metadata (errors ignored - the YAML files are the source of truth)
 	_ = s.loadCache()
+	_ = bookmark.SyncUserMetadata(s.indexer, req.PromptID)
 
 	// Return success - JSON encoding to HTTP response rarely fails
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{
@@ -471,8 +579,9 @@ func (s *Server) handleTagUpdate(w http.ResponseWriter, r *http.Request) {
 		message = "Tags cleared"
 	}
 
-	// Reload cache (errors ignored - cache will be reloaded on next request)
+	// Reload cache and update the indexed metadata (errors ignored - the YAML files are the source of truth)
 	_ = s.loadCache()
+	_ = bookmark.SyncUserMetadata(s.indexer, req.PromptID)
 
 	// Return success - JSON encoding to HTTP response rarely fails
 	_ = json.NewEncoder(w).Encode(map[string]interface{}{
@@ -483,11 +592,108 @@ func (s *Server) handleTagUpdate(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleSearch handles GET /api/search - real-time search.
+// Query parameters: q (query syntax as in 'pkit search'), source, tags, bookmarked, sort, limit, offset.
+// The last word of q also matches as a prefix, unless q ends in a space.
+// Responds with {total, facets, items}: the number of matches, counts per source and tag, and one page of hits.
 func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
-	// Stub - optional feature for AJAX
 	w.Header().Set("Content-Type", "application/json")
-	// Write to HTTP response rarely fails
-	_, _ = w.Write([]byte(`{"results": [], "total": 0}`))
+
+	params := r.URL.Query()
+	limit, _ := strconv.Atoi(params.Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	offset, _ := strconv.Atoi(params.Get("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	searchCfg := searchConfig()
+	sortOrder := params.Get("sort")
+	if sortOrder == "" {
+		sortOrder = defaultSort(searchCfg)
+	}
+	if err := index.ValidateSort(sortOrder); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		// JSON encoding error response rarely fails
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	page, err := s.indexer.SearchPage(index.SearchOptions{
+		Query:         params.Get("q"),
+		SourceID:      params.Get("source"),
+		Tags:          params["tags"],
+		Bookmarked:    params.Get("bookmarked") == "true",
+		MaxResults:    limit,
+		Offset:        offset,
+		Fuzzy:         searchCfg.FuzzyMatch,
+		Prefix:        true,
+		CaseSensitive: searchCfg.CaseSensitive,
+		Highlight:     true,
+		Sort:          sortOrder,
+		Facets:        true,
+	})
+	if err != nil {
+		response := map[string]interface{}{"error": err.Error()}
+		status := http.StatusInternalServerError
+
+		var queryErr *index.QueryError
+		if errors.As(err, &queryErr) {
+			response["position"] = queryErr.Pos
+			status = http.StatusBadRequest
+		}
+
+		w.WriteHeader(status)
+		// JSON encoding to HTTP response rarely fails
+		_ = json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	type searchHit struct {
+		ID          string     `json:"id"`
+		SourceID    string     `json:"source_id"`
+		Name        string     `json:"name"`
+		Description string     `json:"description"`
+		Tags        []string   `json:"tags"`
+		UserTags    []string   `json:"user_tags"`
+		Aliases     []string   `json:"aliases"`
+		TokenCount  int        `json:"token_count"`
+		Bookmarked  bool       `json:"bookmarked"`
+		UsageCount  int        `json:"usage_count,omitempty"`
+		LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+		Score       float64    `json:"score"`
+
+		// Matched fragments per field, HTML-escaped with <mark> around matches
+		Highlights map[string][]string `json:"highlights,omitempty"`
+	}
+
+	hits := make([]searchHit, 0, len(page.Results))
+	for _, result := range page.Results {
+		hits = append(hits, searchHit{
+			ID:          result.Prompt.ID,
+			SourceID:    result.Prompt.SourceID,
+			Name:        result.Prompt.Name,
+			Description: result.Prompt.Description,
+			Tags:        result.Prompt.Tags,
+			UserTags:    result.UserMetadata.UserTags,
+			Aliases:     result.UserMetadata.Aliases,
+			TokenCount:  result.Prompt.TokenCount,
+			Bookmarked:  result.UserMetadata.Bookmarked,
+			UsageCount:  result.UserMetadata.UsageCount,
+			LastUsedAt:  result.UserMetadata.LastUsedAt,
+			Score:       result.Score,
+			Highlights:  result.Highlights,
+		})
+	}
+
+	// JSON encoding to HTTP response rarely fails
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"total":  page.Total,
+		"facets": page.Facets,
+		"items":  hits,
+	})
 }
 
 // parseFilters extracts filter state from query parameters.
@@ -497,16 +703,60 @@ func parseFilters(query url.Values) FilterState {
 		page = 1
 	}
 
+	// An unknown sort order falls back to the default
+	sortOrder := query.Get("sort")
+	if index.ValidateSort(sortOrder) != nil {
+		sortOrder = ""
+	}
+
 	return FilterState{
 		SearchQuery:   query.Get("search"),
 		SourceFilters: query["sources"],
 		TagFilters:    query["tags"],
 		Bookmarked:    query.Get("bookmarked") == "true",
+		Collapse:      query.Get("collapse") == "true",
+		Sort:          sortOrder,
 		Page:          page,
 		PerPage:       50,
 	}
 }
 
+// searchConfig returns the search settings of config.yml, or the defaults when
+// it can't be loaded.
+func searchConfig() models.SearchConfig {
+	if cfg, err := config.Load(); err == nil {
+		return cfg.Search
+	}
+	return models.DefaultConfig().Search
+}
+
+// defaultSort returns the result order configured in search.sort, or score.
+func defaultSort(cfg models.SearchConfig) string {
+	if cfg.Sort != "" {
+		return cfg.Sort
+	}
+	return index.SortScore
+}
+
+// collapseItems keeps the first item of every group of near-duplicates.
+func collapseItems(items []PromptListItem) []PromptListItem {
+	fingerprints := make([]string, len(items))
+	for i, item := range items {
+		fingerprints[i] = item.Prompt.Fingerprint
+	}
+
+	keep, duplicates := similarity.Collapse(fingerprints, similarity.DefaultThreshold)
+
+	collapsed := make([]PromptListItem, 0, len(keep))
+	for _, i := range keep {
+		item := items[i]
+		for _, d := range duplicates[i] {
+			item.Duplicates = append(item.Duplicates, items[d].Prompt.ID)
+		}
+		collapsed = append(collapsed, item)
+	}
+	return collapsed
+}
 
 // paginate paginates a list of items.
 func paginate(items []PromptListItem, page int, perPage int) PaginatedResult {
@@ -23,6 +23,7 @@ type Server struct {
 	bookmarkMgr    *bookmark.Manager
 	tagMgr         *tag.Manager
 	cache          dataCache
+	stopSocket     func() error
 	startTime      time.Time
 }
 
@@ -56,6 +57,19 @@ func NewServer(port int, indexPath string) (*Server, error) {
 		startTime:   time.Now(),
 	}
 
+	// Other pkit commands reach the index through the socket while it is open here
+	if !indexer.Remote() {
+		stopSocket, err := indexer.ServeSocket()
+		if err != nil {
+			fmt.Printf("Warning: other pkit commands can't use the index while the server runs: %v\n", err)
+		} else {
+			s.stopSocket = stopSocket
+		}
+	}
+
+	// Frecency needs the usage of bookmarks used while the index was read-only
+	_ = bookmark.SyncUserMetadata(indexer)
+
 	// Initialize cache
 	s.cache.bookmarks = make(map[string]models.Bookmark)
 	s.cache.tags = make(map[string][]string)
@@ -83,7 +97,16 @@ func (s *Server) Start() error {
 // Shutdown gracefully shuts down the server.
 func (s *Server) Shutdown(ctx context.Context) error {
 	fmt.Println("\nShutting down server...")
-	return s.server.Shutdown(ctx)
+	if err := s.server.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	if s.stopSocket != nil {
+		if err := s.stopSocket(); err != nil {
+			return fmt.Errorf("failed to stop index socket: %w", err)
+		}
+	}
+	return s.indexer.Close()
 }
 
 // loadCache loads user-specific data into memory cache.
@@ -124,6 +147,7 @@ func (s *Server) registerRoutes() {
 	// HTML endpoints - register FIRST
 	s.mux.HandleFunc("/", s.handleList)
 	s.mux.HandleFunc("/prompts/", s.handleDetail)
+	s.mux.HandleFunc("/compare", s.handleCompare)
 
 	// JSON API endpoints
 	s.mux.HandleFunc("/api/bookmarks", s.handleBookmarkToggle)
@@ -170,10 +170,63 @@ function showCopySuccess(button) {
     }, 2000);
 }
 
+// Search as you type: reload the results for the current filters without
+// reloading the page, so the search box keeps focus
+function initLiveSearch() {
+    const input = document.getElementById('search');
+    const results = document.getElementById('results');
+    if (!input || !results) {
+        return;
+    }
+
+    let timer;
+    let latest = 0;
+
+    input.addEventListener('input', () => {
+        clearTimeout(timer);
+        timer = setTimeout(() => {
+            const params = new URLSearchParams(new FormData(input.form));
+            const url = `${input.form.getAttribute('action') || '/'}?${params}`;
+            const request = ++latest;
+
+            fetch(url)
+                .then(response => response.text())
+                .then(html => {
+                    // Ignore responses overtaken by later keystrokes
+                    if (request !== latest) {
+                        return;
+                    }
+                    const page = new DOMParser().parseFromString(html, 'text/html');
+                    const newResults = page.getElementById('results');
+                    if (!newResults) {
+                        return;
+                    }
+                    results.innerHTML = newResults.innerHTML;
+
+                    for (const id of ['result-count', 'query-error']) {
+                        const current = document.getElementById(id);
+                        const updated = page.getElementById(id);
+                        if (current && updated) {
+                            current.textContent = updated.textContent;
+                            current.hidden = updated.hidden;
+                        }
+                    }
+
+                    history.replaceState(null, '', url);
+                })
+                .catch(error => {
+                    console.error('Live search error:', error);
+                });
+        }, 250);
+    });
+}
+
 // Initialize on page load
 document.addEventListener('DOMContentLoaded', () => {
     console.log('pkit web interface loaded');
 
+    initLiveSearch();
+
     // Add Enter key handler for tag input
     const tagInput = document.getElementById('tag-input');
     if (tagInput) {
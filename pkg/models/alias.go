@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -11,7 +12,15 @@ type Alias struct {
 	Name string `yaml:"name" json:"name"`
 
 	// Reference to prompt: <source_id>:<prompt_name>
-	PromptID string `yaml:"prompt_id" json:"prompt_id"`
+	// Empty when the alias points at a composition
+	PromptID string `yaml:"prompt_id,omitempty" json:"prompt_id,omitempty"`
+
+	// Prompt IDs or aliases stacked into one document, in order
+	// Used instead of PromptID (e.g., ["local:house-style", "fabric:review_code"])
+	Compose []string `yaml:"compose,omitempty" json:"compose,omitempty"`
+
+	// Separator placed between composed prompts (defaults to a blank line)
+	Separator string `yaml:"separator,omitempty" json:"separator,omitempty"`
 
 	// Creation timestamp (RFC3339)
 	CreatedAt time.Time `yaml:"created_at" json:"created_at"`
@@ -26,9 +35,26 @@ func (a *Alias) Validate() error {
 		return fmt.Errorf("alias name cannot be empty")
 	}
 
-	if a.PromptID == "" {
-		return fmt.Errorf("prompt_id cannot be empty")
+	if a.PromptID == "" && len(a.Compose) == 0 {
+		return fmt.Errorf("prompt_id or compose must be set")
+	}
+
+	if a.PromptID != "" && len(a.Compose) > 0 {
+		return fmt.Errorf("prompt_id and compose cannot both be set")
 	}
 
 	return nil
 }
+
+// IsComposition reports whether the alias stacks several prompts instead of pointing at one.
+func (a *Alias) IsComposition() bool {
+	return len(a.Compose) > 0
+}
+
+// Target returns a human-readable description of what the alias points at.
+func (a *Alias) Target() string {
+	if a.IsComposition() {
+		return strings.Join(a.Compose, " + ")
+	}
+	return a.PromptID
+}
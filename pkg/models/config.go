@@ -24,6 +24,18 @@ type Config struct {
 
 	// Cache settings
 	Cache CacheConfig `yaml:"cache" json:"cache" validate:"required"`
+
+	// Named executor profiles for 'pkit run' (override built-in profiles by name)
+	Executors map[string]ExecutorConfig `yaml:"executors,omitempty" json:"executors,omitempty" validate:"dive"`
+
+	// Executor used by 'pkit run' when --with is not given
+	DefaultExecutor string `yaml:"default_executor,omitempty" json:"default_executor,omitempty"`
+
+	// Token counting preferences
+	Tokens TokensConfig `yaml:"tokens,omitempty" json:"tokens,omitempty"`
+
+	// Embedding provider for semantic search ('pkit search --semantic')
+	Embeddings EmbeddingsConfig `yaml:"embeddings,omitempty" json:"embeddings,omitempty"`
 }
 
 // GitHubConfig contains GitHub API configuration
@@ -48,6 +60,9 @@ type SearchConfig struct {
 
 	// Case sensitive search
 	CaseSensitive bool `yaml:"case_sensitive" json:"case_sensitive"`
+
+	// Default result order (score, usage, recent, updated, name, frecency)
+	Sort string `yaml:"sort,omitempty" json:"sort,omitempty" validate:"omitempty,oneof=score usage recent updated name frecency"`
 }
 
 // DisplayConfig contains display preferences
@@ -71,6 +86,24 @@ type CacheConfig struct {
 	AutoRebuild bool `yaml:"auto_rebuild" json:"auto_rebuild"`
 }
 
+// TokensConfig contains token counting settings
+type TokensConfig struct {
+	// BPE encoding used for token counts (cl100k_base, o200k_base, estimate)
+	Encoding string `yaml:"encoding,omitempty" json:"encoding,omitempty" validate:"omitempty,oneof=cl100k_base o200k_base estimate"`
+
+	// Warn when a document assembled by 'pkit get' exceeds this many tokens (0 disables)
+	Budget int `yaml:"budget,omitempty" json:"budget,omitempty" validate:"gte=0"`
+}
+
+// EmbeddingsConfig contains semantic search settings
+type EmbeddingsConfig struct {
+	// Shell command that reads a text on stdin and writes its embedding vector to stdout
+	Command string `yaml:"command,omitempty" json:"command,omitempty"`
+
+	// Weight of vector similarity against the keyword score (0-1, 0 means 0.5)
+	Weight float64 `yaml:"weight,omitempty" json:"weight,omitempty" validate:"gte=0,lte=1"`
+}
+
 // RateLimit tracks GitHub API rate limit consumption
 type RateLimit struct {
 	// Maximum requests allowed in window
@@ -133,6 +166,10 @@ func DefaultConfig() *Config {
 			Enabled:     true,
 			AutoRebuild: true,
 		},
+		Tokens: TokensConfig{
+			Encoding: "cl100k_base",
+			Budget:   100000,
+		},
 	}
 }
 
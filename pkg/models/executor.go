@@ -0,0 +1,45 @@
+package models
+
+// ExecutorConfig describes how 'pkit run' invokes an execution tool (claude, llm, mods, fabric...).
+type ExecutorConfig struct {
+	// Binary to execute (looked up in PATH)
+	Command string `yaml:"command" json:"command" validate:"required"`
+
+	// Argument template. Placeholders:
+	//   {{prompt}}      - resolved prompt text
+	//   {{prompt_file}} - path to a temporary file holding the prompt text
+	//   {{args}}        - extra arguments given after "--" (appended at the end if absent)
+	Args []string `yaml:"args,omitempty" json:"args,omitempty"`
+
+	// What the tool receives on stdin
+	// Valid values: "input" (piped input, default), "prompt" (prompt only),
+	// "combined" (prompt followed by piped input), "none"
+	Stdin string `yaml:"stdin,omitempty" json:"stdin,omitempty" validate:"omitempty,oneof=input prompt combined none"`
+}
+
+// DefaultExecutors returns the built-in executor profiles.
+// Entries in the config's executors section override these by name.
+func DefaultExecutors() map[string]ExecutorConfig {
+	return map[string]ExecutorConfig{
+		"claude": {
+			Command: "claude",
+			Args:    []string{"-p", "--system-prompt", "{{prompt}}", "{{args}}"},
+			Stdin:   "input",
+		},
+		"llm": {
+			Command: "llm",
+			Args:    []string{"-s", "{{prompt}}", "{{args}}"},
+			Stdin:   "input",
+		},
+		"mods": {
+			Command: "mods",
+			Args:    []string{"{{args}}", "{{prompt}}"},
+			Stdin:   "input",
+		},
+		"fabric": {
+			Command: "fabric",
+			Args:    []string{"{{args}}"},
+			Stdin:   "combined",
+		},
+	}
+}
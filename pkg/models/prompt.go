@@ -34,6 +34,16 @@ type Prompt struct {
 	// May be empty if source format doesn't provide it
 	Version string `json:"version,omitempty"`
 
+	// Language the prompt is written in (ISO 639-1 code), from the source or
+	// detected by the parser
+	Language string `json:"language,omitempty"`
+
+	// Estimated token count of Content, computed at index time
+	TokenCount int `json:"token_count,omitempty"`
+
+	// MinHash signature of Content (hex), computed at index time to detect near-duplicates
+	Fingerprint string `json:"fingerprint,omitempty"`
+
 	// Relative file path within source repository
 	FilePath string `json:"file_path" validate:"required"`
 
@@ -25,6 +25,10 @@ type Source struct {
 	// Valid values: "fabric_pattern", "awesome_chatgpt", "markdown"
 	Format string `yaml:"format" json:"format" validate:"required,oneof=fabric_pattern awesome_chatgpt markdown"`
 
+	// Language of the prompts (ISO 639-1 code, e.g. "de", "ja"), which sets
+	// how they are analyzed for search. Detected per prompt when empty.
+	Language string `yaml:"language,omitempty" json:"language,omitempty" validate:"omitempty,oneof=ar cjk ckb da de en es fa fi fr hi hr hu it ja ko nl no pl pt ro ru sv tr zh"`
+
 	// Current git commit SHA
 	CommitSHA string `yaml:"commit_sha" json:"commit_sha" validate:"omitempty,git_sha"`
 
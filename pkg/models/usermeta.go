@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// UserMetadata is what the user has attached to a prompt: tags, bookmark and
+// aliases. It is written into the search index next to the prompt so it can be
+// searched and filtered there.
+type UserMetadata struct {
+	// User-defined tags (from tags.yml)
+	UserTags []string `json:"user_tags,omitempty"`
+
+	// Whether the prompt is bookmarked (from bookmarks.yml)
+	Bookmarked bool `json:"bookmarked"`
+
+	// Names of aliases pointing at the prompt, directly or through a composition
+	Aliases []string `json:"aliases,omitempty"`
+
+	// Bookmark notes
+	Notes string `json:"notes,omitempty"`
+
+	// Bookmark usage count and last use
+	UsageCount int        `json:"usage_count,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}